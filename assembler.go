@@ -0,0 +1,265 @@
+package osrscache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var opcodesByName = func() map[string]uint16 {
+	opcodes := make(map[string]uint16, len(opcodeNames))
+	for opcode, name := range opcodeNames {
+		opcodes[name] = opcode
+	}
+	return opcodes
+}()
+
+// ParseOpcode returns the opcode with the given symbolic name, or the opcode
+// written as a number.
+func ParseOpcode(name string) (uint16, error) {
+	if opcode, ok := opcodesByName[name]; ok {
+		return opcode, nil
+	}
+	opcode, err := strconv.ParseUint(name, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("unknown opcode %q", name)
+	}
+	return uint16(opcode), nil
+}
+
+// labelRef is a jump or switch case whose offset is resolved once every label
+// has been seen.
+type labelRef struct {
+	line  int
+	pc    int
+	label string
+	set   func(offset int32)
+}
+
+// Assemble parses .rs2asm text, as written by Disassemble, into a script.
+// Comments start with a semicolon, and a switch instruction is followed by its
+// cases written as "key: LABEL".
+func Assemble(r io.Reader) (*ClientScript, error) {
+	script := NewClientScript(0)
+	labels := make(map[string]int)
+	var refs []labelRef
+	currentSwitch := -1
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "."):
+			if err := script.parseDirective(line); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			continue
+		case strings.HasSuffix(line, ":") && !strings.ContainsAny(line, " \t"):
+			label := strings.TrimSuffix(line, ":")
+			if _, ok := labels[label]; ok {
+				return nil, fmt.Errorf("line %d: duplicate label %s", lineNumber, label)
+			}
+			labels[label] = len(script.Instructions)
+			currentSwitch = -1
+			continue
+		}
+
+		if key, label, ok := strings.Cut(line, ":"); ok && !strings.Contains(key, " ") {
+			value, err := strconv.ParseInt(key, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: parsing switch key: %w", lineNumber, err)
+			}
+			if currentSwitch < 0 {
+				return nil, fmt.Errorf("line %d: switch case outside of a switch", lineNumber)
+			}
+			table, index := currentSwitch, len(script.Switches[currentSwitch])
+			script.Switches[table] = append(script.Switches[table], SwitchCase{Key: int32(value)})
+			refs = append(refs, labelRef{
+				line:  lineNumber,
+				pc:    len(script.Instructions) - 1,
+				label: strings.TrimSpace(label),
+				set:   func(offset int32) { script.Switches[table][index].Offset = offset },
+			})
+			continue
+		}
+
+		name, operand, _ := strings.Cut(line, " ")
+		operand = strings.TrimSpace(operand)
+		opcode, err := ParseOpcode(name)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		pc := len(script.Instructions)
+		ins := Instruction{Opcode: opcode}
+		currentSwitch = -1
+		switch {
+		case opcode == OpSconst:
+			ins.StringOperand, err = strconv.Unquote(operand)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: parsing string operand %s: %w", lineNumber, operand, err)
+			}
+		case IsJump(opcode):
+			if operand == "" {
+				return nil, fmt.Errorf("line %d: %s without a label", lineNumber, name)
+			}
+			refs = append(refs, labelRef{
+				line:  lineNumber,
+				pc:    pc,
+				label: operand,
+				set:   func(offset int32) { script.Instructions[pc].IntOperand = offset },
+			})
+		case opcode == OpSwitch:
+			ins.IntOperand = int32(len(script.Switches))
+			script.Switches = append(script.Switches, nil)
+			currentSwitch = len(script.Switches) - 1
+		case operand != "":
+			value, err := strconv.ParseInt(operand, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: parsing operand: %w", lineNumber, err)
+			}
+			ins.IntOperand = int32(value)
+		case hasIntOperand(opcode):
+			return nil, fmt.Errorf("line %d: %s without an operand", lineNumber, name)
+		}
+		script.Instructions = append(script.Instructions, ins)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading assembly: %w", err)
+	}
+
+	for _, ref := range refs {
+		target, ok := labels[ref.label]
+		if !ok {
+			return nil, fmt.Errorf("line %d: undefined label %s", ref.line, ref.label)
+		}
+		ref.set(int32(target - ref.pc - 1))
+	}
+	return script, nil
+}
+
+// parseDirective applies a header line such as ".int_var_count 2".
+func (s *ClientScript) parseDirective(line string) error {
+	directive, value, _ := strings.Cut(line, " ")
+	value = strings.TrimSpace(value)
+
+	if directive == ".name" {
+		name, err := strconv.Unquote(value)
+		if err != nil {
+			return fmt.Errorf("parsing name %s: %w", value, err)
+		}
+		s.Name = name
+		return nil
+	}
+
+	var field *uint16
+	switch directive {
+	case ".id":
+		field = &s.ID
+	case ".int_stack_count":
+		field = &s.IntArgumentCount
+	case ".string_stack_count":
+		field = &s.StringArgumentCount
+	case ".int_var_count":
+		field = &s.LocalIntCount
+	case ".string_var_count":
+		field = &s.LocalStringCount
+	case ".long_stack_count":
+		field = &s.LongArgumentCount
+	case ".long_var_count":
+		field = &s.LocalLongCount
+	default:
+		return fmt.Errorf("unknown directive %s", directive)
+	}
+
+	n, err := strconv.ParseUint(value, 10, 16)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", directive, err)
+	}
+	*field = uint16(n)
+	return nil
+}
+
+// stripComment removes a semicolon comment from line, ignoring semicolons
+// inside string operands.
+func stripComment(line string) string {
+	quoted, escaped := false, false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ';' && !quoted:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// Encode serializes the script in the format Read decodes.
+func (s *ClientScript) Encode() ([]byte, error) {
+	if strings.IndexByte(s.Name, 0) >= 0 {
+		return nil, fmt.Errorf("name contains a null byte")
+	}
+	buf := append([]byte(s.Name), 0)
+
+	for pc, ins := range s.Instructions {
+		buf = binary.BigEndian.AppendUint16(buf, ins.Opcode)
+		switch {
+		case ins.Opcode == OpSconst:
+			if strings.IndexByte(ins.StringOperand, 0) >= 0 {
+				return nil, fmt.Errorf("instruction %d: string operand contains a null byte", pc)
+			}
+			buf = append(buf, ins.StringOperand...)
+			buf = append(buf, 0)
+		case hasIntOperand(ins.Opcode):
+			buf = binary.BigEndian.AppendUint32(buf, uint32(ins.IntOperand))
+		default:
+			if ins.IntOperand < 0 || ins.IntOperand > 0xFF {
+				return nil, fmt.Errorf("instruction %d: operand %d of %s does not fit in a byte", pc, ins.IntOperand, OpcodeName(ins.Opcode))
+			}
+			buf = append(buf, uint8(ins.IntOperand))
+		}
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(s.Instructions)))
+	counts := []uint16{s.LocalIntCount, s.LocalStringCount, s.IntArgumentCount, s.StringArgumentCount}
+	if s.LocalLongCount != 0 || s.LongArgumentCount != 0 {
+		counts = []uint16{s.LocalIntCount, s.LocalStringCount, s.LocalLongCount, s.IntArgumentCount, s.StringArgumentCount, s.LongArgumentCount}
+	}
+	for _, count := range counts {
+		buf = binary.BigEndian.AppendUint16(buf, count)
+	}
+
+	if len(s.Switches) > 0xFF {
+		return nil, fmt.Errorf("too many switch tables: %d", len(s.Switches))
+	}
+	switchStart := len(buf)
+	buf = append(buf, uint8(len(s.Switches)))
+	for i, table := range s.Switches {
+		if len(table) > 0xFFFF {
+			return nil, fmt.Errorf("switch %d has too many cases: %d", i, len(table))
+		}
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(table)))
+		for _, c := range table {
+			buf = binary.BigEndian.AppendUint32(buf, uint32(c.Key))
+			buf = binary.BigEndian.AppendUint32(buf, uint32(c.Offset))
+		}
+	}
+
+	switchLength := len(buf) - switchStart
+	if switchLength > 0xFFFF {
+		return nil, fmt.Errorf("switch tables of %d bytes are too large", switchLength)
+	}
+	return binary.BigEndian.AppendUint16(buf, uint16(switchLength)), nil
+}
@@ -0,0 +1,92 @@
+package osrscache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// scriptBytes encodes a script by hand in the cache format, independently of
+// Encode.
+func scriptBytes() []byte {
+	buf := append([]byte("roundtrip"), 0)
+	intOp := func(opcode uint16, operand int32) {
+		buf = binary.BigEndian.AppendUint16(buf, opcode)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(operand))
+	}
+	byteOp := func(opcode uint16, operand uint8) {
+		buf = binary.BigEndian.AppendUint16(buf, opcode)
+		buf = append(buf, operand)
+	}
+	stringOp := func(s string) {
+		buf = binary.BigEndian.AppendUint16(buf, OpSconst)
+		buf = append(append(buf, s...), 0)
+	}
+
+	intOp(OpIload, 0)               // 0
+	intOp(OpSwitch, 0)              // 1
+	stringOp(`neg; "quoted" \ tab`) // 2
+	intOp(OpJump, 3)                // 3: to 7
+	stringOp("one")                 // 4
+	intOp(OpJump, 1)                // 5: to 7
+	stringOp("")                    // 6
+	intOp(OpSstore, 0)              // 7
+	intOp(OpIload, 0)               // 8
+	intOp(OpSwitch, 1)              // 9
+	intOp(OpIconst, -5)             // 10
+	byteOp(OpPopInt, 0)             // 11
+	byteOp(OpReturn, 0)             // 12
+
+	buf = binary.BigEndian.AppendUint32(buf, 13)
+	for _, count := range []uint16{1, 1, 1, 0} {
+		buf = binary.BigEndian.AppendUint16(buf, count)
+	}
+
+	switchStart := len(buf)
+	buf = append(buf, 2)
+	tables := [][][2]int32{
+		{{-1, 0}, {1, 2}, {2147483647, 4}, {-2147483648, 4}},
+		{{-100, 2}},
+	}
+	for _, table := range tables {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(table)))
+		for _, c := range table {
+			buf = binary.BigEndian.AppendUint32(buf, uint32(c[0]))
+			buf = binary.BigEndian.AppendUint32(buf, uint32(c[1]))
+		}
+	}
+	return binary.BigEndian.AppendUint16(buf, uint16(len(buf)-switchStart))
+}
+
+func TestAssembleRoundTrip(t *testing.T) {
+	data := scriptBytes()
+
+	script := NewClientScript(7)
+	if err := script.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(script.Switches) != 2 || script.Switches[0][0].Key != -1 {
+		t.Fatalf("switches = %v", script.Switches)
+	}
+
+	var b strings.Builder
+	if err := script.Disassemble(&b); err != nil {
+		t.Fatal(err)
+	}
+	assembled, err := Assemble(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("assembling:\n%s\n%v", b.String(), err)
+	}
+	if assembled.ID != 7 {
+		t.Errorf("id = %d, want 7", assembled.ID)
+	}
+
+	encoded, err := assembled.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Errorf("reassembled bytes differ\n got %x\nwant %x\nassembly:\n%s", encoded, data, b.String())
+	}
+}
@@ -0,0 +1,80 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Audio is PCM audio in the sample format of a WAV file: unsigned 8-bit or
+// signed little-endian 16-bit samples, interleaved by channel.
+type Audio struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	Data          []byte
+}
+
+// NewAudio8 converts signed 8-bit mono samples.
+func NewAudio8(sampleRate int, samples []int8) *Audio {
+	data := make([]byte, len(samples))
+	for i, sample := range samples {
+		data[i] = byte(int(sample) + 128)
+	}
+	return &Audio{SampleRate: sampleRate, Channels: 1, BitsPerSample: 8, Data: data}
+}
+
+// NewAudio16 converts signed 16-bit samples interleaved by channel.
+func NewAudio16(sampleRate, channels int, samples []int16) *Audio {
+	data := make([]byte, 0, len(samples)*2)
+	for _, sample := range samples {
+		data = binary.LittleEndian.AppendUint16(data, uint16(sample))
+	}
+	return &Audio{SampleRate: sampleRate, Channels: channels, BitsPerSample: 16, Data: data}
+}
+
+// Duration returns the length of the audio in seconds.
+func (a *Audio) Duration() float64 {
+	frameSize := a.Channels * a.BitsPerSample / 8
+	if frameSize == 0 || a.SampleRate == 0 {
+		return 0
+	}
+	return float64(len(a.Data)/frameSize) / float64(a.SampleRate)
+}
+
+// WriteWAV writes the audio as a RIFF WAVE file.
+func (a *Audio) WriteWAV(w io.Writer) error {
+	if a.BitsPerSample != 8 && a.BitsPerSample != 16 {
+		return fmt.Errorf("unsupported bits per sample: %d", a.BitsPerSample)
+	}
+	blockAlign := a.Channels * a.BitsPerSample / 8
+
+	dataSize := len(a.Data)
+	padded := dataSize + dataSize%2
+
+	header := make([]byte, 0, 44)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(36+padded))
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, 1)
+	header = binary.LittleEndian.AppendUint16(header, uint16(a.Channels))
+	header = binary.LittleEndian.AppendUint32(header, uint32(a.SampleRate))
+	header = binary.LittleEndian.AppendUint32(header, uint32(a.SampleRate*blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, uint16(blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, uint16(a.BitsPerSample))
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(dataSize))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(a.Data); err != nil {
+		return err
+	}
+	if padded != dataSize {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package osrscache
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestWriteWAV(t *testing.T) {
+	tests := []struct {
+		name  string
+		audio *Audio
+		want  []byte
+	}{
+		{
+			name:  "odd 8-bit",
+			audio: NewAudio8(22050, []int8{-128, 0, 127}),
+			want: slices.Concat(
+				[]byte("RIFF"), []byte{40, 0, 0, 0}, // 36 plus the padded data
+				[]byte("WAVEfmt "), []byte{16, 0, 0, 0},
+				[]byte{1, 0, 1, 0},       // PCM, mono
+				[]byte{0x22, 0x56, 0, 0}, // 22050Hz
+				[]byte{0x22, 0x56, 0, 0}, // bytes per second
+				[]byte{1, 0, 8, 0},       // block align and bits per sample
+				[]byte("data"), []byte{3, 0, 0, 0},
+				[]byte{0, 128, 255, 0}, // samples and padding
+			),
+		},
+		{
+			name:  "stereo 16-bit",
+			audio: NewAudio16(44100, 2, []int16{-1, 256}),
+			want: slices.Concat(
+				[]byte("RIFF"), []byte{40, 0, 0, 0},
+				[]byte("WAVEfmt "), []byte{16, 0, 0, 0},
+				[]byte{1, 0, 2, 0},
+				[]byte{0x44, 0xAC, 0, 0},
+				[]byte{0x10, 0xB1, 2, 0}, // 176400 bytes per second
+				[]byte{4, 0, 16, 0},
+				[]byte("data"), []byte{4, 0, 0, 0},
+				[]byte{0xFF, 0xFF, 0, 1},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.audio.WriteWAV(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.want) {
+				t.Errorf("wav =\n% x\nwant\n% x", buf.Bytes(), tt.want)
+			}
+		})
+	}
+
+	audio := &Audio{SampleRate: 8000, Channels: 1, BitsPerSample: 24}
+	if err := audio.WriteWAV(&bytes.Buffer{}); err == nil {
+		t.Error("want an error for 24-bit audio")
+	}
+}
@@ -1,13 +1,32 @@
 package osrscache
 
-import "fmt"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
 
 type Cache struct {
 	Store Store
+
+	decodeOpts []DecodeOption
+
+	paramsMu sync.Mutex
+	params   map[uint16]*ParamDefinition
 }
 
-func New(store Store) *Cache {
-	return &Cache{Store: store}
+// New creates a cache backed by store. The options control how definitions
+// are decoded, e.g. WithLenientDecoding to tolerate opcodes added by newer
+// game updates.
+func New(store Store, opts ...DecodeOption) *Cache {
+	return &Cache{Store: store, decodeOpts: opts}
 }
 
 func (c *Cache) Index(archiveID uint8) (*Index, error) {
@@ -23,7 +42,7 @@ func (c *Cache) Index(archiveID uint8) (*Index, error) {
 
 	index, err := ReadIndex(decompressedGroupData)
 	if err != nil {
-		return nil, fmt.Errorf("creating reference table index: %w", err)
+		return nil, &CorruptGroupError{Archive: 255, Group: uint32(archiveID), Reason: err.Error()}
 	}
 	return index, nil
 }
@@ -51,11 +70,28 @@ func (c *Cache) Files(archiveID uint8, groupID uint32) (map[uint32][]byte, error
 
 	files, err := group.Unpack(decompressGroupData)
 	if err != nil {
-		return nil, fmt.Errorf("unpacking group: %w", err)
+		return nil, &CorruptGroupError{Archive: archiveID, Group: groupID, Reason: err.Error()}
 	}
 	return files, nil
 }
 
+// GroupSeq streams the decompressed groups of an archive in ascending group ID
+// order, reading each group from the store only when it is reached.
+func (c *Cache) GroupSeq(archiveID uint8) *Seq[uint32, []byte] {
+	return newGroupSeq(c, archiveID, func(groupID uint32) ([]byte, error) {
+		groupData, err := c.Store.Read(archiveID, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("reading group data: %w", err)
+		}
+
+		decompressedGroupData, err := DecompressData(groupData)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing group data: %w", err)
+		}
+		return decompressedGroupData, nil
+	})
+}
+
 func (c *Cache) Item(id uint16) (*Item, error) {
 	files, err := c.Files(2, 10)
 	if err != nil {
@@ -64,16 +100,22 @@ func (c *Cache) Item(id uint16) (*Item, error) {
 
 	data, ok := files[uint32(id)]
 	if !ok {
-		return nil, fmt.Errorf("item %d not found", id)
+		return nil, fmt.Errorf("item %d: %w", id, ErrNotFound)
 	}
 
 	item := NewItem(id)
-	if err := item.Read(data); err != nil {
+	if err := item.Read(data, c.decodeOpts...); err != nil {
 		return nil, fmt.Errorf("reading item: %w", err)
 	}
+	if err := item.link(c.itemLookup(files)); err != nil {
+		return nil, fmt.Errorf("linking item: %w", err)
+	}
 	return item, nil
 }
 
+// Items decodes all items, resolving noted, bought and placeholder items
+// against the items they represent. Items whose templates are missing are
+// kept unlinked and logged as a warning.
 func (c *Cache) Items() (map[uint16]*Item, error) {
 	files, err := c.Files(2, 10)
 	if err != nil {
@@ -83,14 +125,115 @@ func (c *Cache) Items() (map[uint16]*Item, error) {
 	items := make(map[uint16]*Item, len(files))
 	for id, data := range files {
 		item := NewItem(uint16(id))
-		if err := item.Read(data); err != nil {
+		if err := item.Read(data, c.decodeOpts...); err != nil {
 			return nil, fmt.Errorf("reading item: %w", err)
 		}
 		items[uint16(id)] = item
 	}
+	for _, err := range linkItems(items) {
+		c.warnUnlinked(err.ID, err.Err)
+	}
+	return items, nil
+}
+
+// LoadItems decodes all items concurrently. Items that fail to decode are
+// reported in a DecodeErrors alongside the items that succeeded, as are items
+// that fail to link, which are kept unlinked.
+func (c *Cache) LoadItems(ctx context.Context, opts ...LoadOption) (map[uint16]*Item, error) {
+	files, err := c.Files(2, 10)
+	if err != nil {
+		return nil, fmt.Errorf("getting item files: %w", err)
+	}
+
+	items, err := decodeConcurrent(ctx, fileIDs(files), opts, func(id uint32) (*Item, error) {
+		item := NewItem(uint16(id))
+		if err := item.Read(files[id], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading item: %w", err)
+		}
+		return item, nil
+	})
+
+	var errs DecodeErrors
+	if err != nil && !errors.As(err, &errs) {
+		return nil, err
+	}
+
+	errs = append(errs, linkItems(items)...)
+	if len(errs) > 0 {
+		errs.sort()
+		return items, errs
+	}
 	return items, nil
 }
 
+// ItemSeq streams items in ascending ID order without decoding the ones
+// that are never reached.
+func (c *Cache) ItemSeq() *Seq[uint16, *Item] {
+	return newFileSeq(c, 2, 10, func(id uint16, files map[uint32][]byte) (*Item, error) {
+		item := NewItem(id)
+		if err := item.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading item: %w", err)
+		}
+		if err := item.link(c.itemLookup(files)); err != nil {
+			c.warnUnlinked(uint32(id), err)
+		}
+		return item, nil
+	})
+}
+
+// warnUnlinked reports an item that bulk decoding keeps unlinked rather than
+// failing every other item with it.
+func (c *Cache) warnUnlinked(id uint32, err error) {
+	newDecodeOptions(c.decodeOpts).logger.Warn("item left unlinked",
+		slog.Int("id", int(id)),
+		slog.Any("error", err),
+	)
+}
+
+// itemLookup decodes unlinked items from the item files on demand.
+func (c *Cache) itemLookup(files map[uint32][]byte) func(id uint16) (*Item, error) {
+	return func(id uint16) (*Item, error) {
+		data, ok := files[uint32(id)]
+		if !ok {
+			return nil, fmt.Errorf("item %d: %w", id, ErrNotFound)
+		}
+
+		item := NewItem(id)
+		if err := item.Read(data, c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading item: %w", err)
+		}
+		return item, nil
+	}
+}
+
+// linkItems links every item against the others in items. Items that cannot
+// be linked are kept as decoded and reported. Lookups are resolved against copies
+// taken before linking, so templates are always unlinked whatever order the
+// items are linked in.
+func linkItems(items map[uint16]*Item) DecodeErrors {
+	unlinked := make(map[uint16]*Item, len(items))
+	for id, item := range items {
+		clone := *item
+		unlinked[id] = &clone
+	}
+	lookup := func(id uint16) (*Item, error) {
+		item, ok := unlinked[id]
+		if !ok {
+			return nil, fmt.Errorf("item %d: %w", id, ErrNotFound)
+		}
+		return item, nil
+	}
+
+	var errs DecodeErrors
+	for id, item := range items {
+		if err := item.link(lookup); err != nil {
+			errs = append(errs, &DecodeError{ID: uint32(id), Err: fmt.Errorf("linking item: %w", err)})
+		}
+	}
+	errs.sort()
+	return errs
+}
+
 func (c *Cache) ExportItems(outputDir string, mode JSONExportMode) error {
 	items, err := c.Items()
 	if err != nil {
@@ -107,11 +250,11 @@ func (c *Cache) NPC(id uint16) (*NPC, error) {
 
 	data, ok := files[uint32(id)]
 	if !ok {
-		return nil, fmt.Errorf("npc %d not found", id)
+		return nil, fmt.Errorf("npc %d: %w", id, ErrNotFound)
 	}
 
 	npc := NewNPC(uint16(id))
-	if err := npc.Read(data); err != nil {
+	if err := npc.Read(data, c.decodeOpts...); err != nil {
 		return nil, fmt.Errorf("reading npc: %w", err)
 	}
 	return npc, nil
@@ -126,7 +269,7 @@ func (c *Cache) NPCs() (map[uint16]*NPC, error) {
 	npcs := make(map[uint16]*NPC, len(files))
 	for id, data := range files {
 		npc := NewNPC(uint16(id))
-		if err := npc.Read(data); err != nil {
+		if err := npc.Read(data, c.decodeOpts...); err != nil {
 			return nil, fmt.Errorf("reading npc: %w", err)
 		}
 		npcs[uint16(id)] = npc
@@ -134,6 +277,35 @@ func (c *Cache) NPCs() (map[uint16]*NPC, error) {
 	return npcs, nil
 }
 
+// LoadNPCs decodes all npcs concurrently. NPCs that fail to decode are
+// reported in a DecodeErrors alongside the npcs that succeeded.
+func (c *Cache) LoadNPCs(ctx context.Context, opts ...LoadOption) (map[uint16]*NPC, error) {
+	files, err := c.Files(2, 9)
+	if err != nil {
+		return nil, fmt.Errorf("getting npc files: %w", err)
+	}
+
+	return decodeConcurrent(ctx, fileIDs(files), opts, func(id uint32) (*NPC, error) {
+		npc := NewNPC(uint16(id))
+		if err := npc.Read(files[id], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading npc: %w", err)
+		}
+		return npc, nil
+	})
+}
+
+// NPCSeq streams npcs in ascending ID order without decoding the ones
+// that are never reached.
+func (c *Cache) NPCSeq() *Seq[uint16, *NPC] {
+	return newFileSeq(c, 2, 9, func(id uint16, files map[uint32][]byte) (*NPC, error) {
+		npc := NewNPC(id)
+		if err := npc.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading npc: %w", err)
+		}
+		return npc, nil
+	})
+}
+
 func (c *Cache) ExportNPCs(outputDir string, mode JSONExportMode) error {
 	npcs, err := c.NPCs()
 	if err != nil {
@@ -150,11 +322,11 @@ func (c *Cache) Object(id uint16) (*Object, error) {
 
 	data, ok := files[uint32(id)]
 	if !ok {
-		return nil, fmt.Errorf("object %d not found", id)
+		return nil, fmt.Errorf("object %d: %w", id, ErrNotFound)
 	}
 
 	obj := NewObject(uint16(id))
-	if err := obj.Read(data); err != nil {
+	if err := obj.Read(data, c.decodeOpts...); err != nil {
 		return nil, fmt.Errorf("reading object: %w", err)
 	}
 	return obj, nil
@@ -169,7 +341,7 @@ func (c *Cache) Objects() (map[uint16]*Object, error) {
 	objs := make(map[uint16]*Object, len(files))
 	for id, data := range files {
 		obj := NewObject(uint16(id))
-		if err := obj.Read(data); err != nil {
+		if err := obj.Read(data, c.decodeOpts...); err != nil {
 			return nil, fmt.Errorf("reading object: %w", err)
 		}
 		objs[uint16(id)] = obj
@@ -177,6 +349,35 @@ func (c *Cache) Objects() (map[uint16]*Object, error) {
 	return objs, nil
 }
 
+// LoadObjects decodes all objects concurrently. Objects that fail to decode
+// are reported in a DecodeErrors alongside the objects that succeeded.
+func (c *Cache) LoadObjects(ctx context.Context, opts ...LoadOption) (map[uint16]*Object, error) {
+	files, err := c.Files(2, 6)
+	if err != nil {
+		return nil, fmt.Errorf("getting object files: %w", err)
+	}
+
+	return decodeConcurrent(ctx, fileIDs(files), opts, func(id uint32) (*Object, error) {
+		obj := NewObject(uint16(id))
+		if err := obj.Read(files[id], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading object: %w", err)
+		}
+		return obj, nil
+	})
+}
+
+// ObjectSeq streams objects in ascending ID order without decoding the ones
+// that are never reached.
+func (c *Cache) ObjectSeq() *Seq[uint16, *Object] {
+	return newFileSeq(c, 2, 6, func(id uint16, files map[uint32][]byte) (*Object, error) {
+		obj := NewObject(id)
+		if err := obj.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading object: %w", err)
+		}
+		return obj, nil
+	})
+}
+
 func (c *Cache) ExportObjects(outputDir string, mode JSONExportMode) error {
 	npcs, err := c.Objects()
 	if err != nil {
@@ -193,11 +394,11 @@ func (c *Cache) Enum(id uint16) (*Enum, error) {
 
 	data, ok := files[uint32(id)]
 	if !ok {
-		return nil, fmt.Errorf("enum %d not found", id)
+		return nil, fmt.Errorf("enum %d: %w", id, ErrNotFound)
 	}
 
 	enum := NewEnum(id)
-	if err := enum.Read(data); err != nil {
+	if err := enum.Read(data, c.decodeOpts...); err != nil {
 		return nil, fmt.Errorf("reading enum: %w", err)
 	}
 	return enum, nil
@@ -212,7 +413,7 @@ func (c *Cache) Enums() (map[uint16]*Enum, error) {
 	enums := make(map[uint16]*Enum, len(files))
 	for id, data := range files {
 		enum := NewEnum(uint16(id))
-		if err := enum.Read(data); err != nil {
+		if err := enum.Read(data, c.decodeOpts...); err != nil {
 			return nil, fmt.Errorf("reading enum: %w", err)
 		}
 		enums[uint16(id)] = enum
@@ -220,6 +421,18 @@ func (c *Cache) Enums() (map[uint16]*Enum, error) {
 	return enums, nil
 }
 
+// EnumSeq streams enums in ascending ID order without decoding the ones
+// that are never reached.
+func (c *Cache) EnumSeq() *Seq[uint16, *Enum] {
+	return newFileSeq(c, 2, 8, func(id uint16, files map[uint32][]byte) (*Enum, error) {
+		enum := NewEnum(id)
+		if err := enum.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading enum: %w", err)
+		}
+		return enum, nil
+	})
+}
+
 func (c *Cache) ExportEnums(outputDir string, mode JSONExportMode) error {
 	enums, err := c.Enums()
 	if err != nil {
@@ -236,11 +449,11 @@ func (c *Cache) Struct(id uint16) (*Struct, error) {
 
 	data, ok := files[uint32(id)]
 	if !ok {
-		return nil, fmt.Errorf("struct %d not found", id)
+		return nil, fmt.Errorf("struct %d: %w", id, ErrNotFound)
 	}
 
 	str := NewStruct(id)
-	if err := str.Read(data); err != nil {
+	if err := str.Read(data, c.decodeOpts...); err != nil {
 		return nil, fmt.Errorf("reading struct: %w", err)
 	}
 	return str, nil
@@ -255,7 +468,7 @@ func (c *Cache) Structs() (map[uint16]*Struct, error) {
 	structs := make(map[uint16]*Struct, len(files))
 	for id, data := range files {
 		def := NewStruct(uint16(id))
-		if err := def.Read(data); err != nil {
+		if err := def.Read(data, c.decodeOpts...); err != nil {
 			return nil, fmt.Errorf("reading struct type: %w", err)
 		}
 		structs[uint16(id)] = def
@@ -263,6 +476,18 @@ func (c *Cache) Structs() (map[uint16]*Struct, error) {
 	return structs, nil
 }
 
+// StructSeq streams structs in ascending ID order without decoding the ones
+// that are never reached.
+func (c *Cache) StructSeq() *Seq[uint16, *Struct] {
+	return newFileSeq(c, 2, 34, func(id uint16, files map[uint32][]byte) (*Struct, error) {
+		str := NewStruct(id)
+		if err := str.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading struct: %w", err)
+		}
+		return str, nil
+	})
+}
+
 func (c *Cache) ExportStructs(outputDir string, mode JSONExportMode) error {
 	structs, err := c.Structs()
 	if err != nil {
@@ -271,6 +496,376 @@ func (c *Cache) ExportStructs(outputDir string, mode JSONExportMode) error {
 	return NewJSONExporter(structs, outputDir).ExportToJSON(mode, "struct")
 }
 
+func (c *Cache) Param(id uint16) (*ParamDefinition, error) {
+	files, err := c.Files(2, 11)
+	if err != nil {
+		return nil, fmt.Errorf("getting param files: %w", err)
+	}
+
+	data, ok := files[uint32(id)]
+	if !ok {
+		return nil, fmt.Errorf("param %d: %w", id, ErrNotFound)
+	}
+
+	param := NewParamDefinition(id)
+	if err := param.Read(data, c.decodeOpts...); err != nil {
+		return nil, fmt.Errorf("reading param: %w", err)
+	}
+	return param, nil
+}
+
+func (c *Cache) Params() (map[uint16]*ParamDefinition, error) {
+	files, err := c.Files(2, 11)
+	if err != nil {
+		return nil, fmt.Errorf("getting param files: %w", err)
+	}
+
+	params := make(map[uint16]*ParamDefinition, len(files))
+	for id, data := range files {
+		param := NewParamDefinition(uint16(id))
+		if err := param.Read(data, c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading param %d: %w", id, err)
+		}
+		params[uint16(id)] = param
+	}
+	return params, nil
+}
+
+// ParamSeq streams params in ascending ID order without decoding the ones
+// that are never reached.
+func (c *Cache) ParamSeq() *Seq[uint16, *ParamDefinition] {
+	return newFileSeq(c, 2, 11, func(id uint16, files map[uint32][]byte) (*ParamDefinition, error) {
+		param := NewParamDefinition(id)
+		if err := param.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading param: %w", err)
+		}
+		return param, nil
+	})
+}
+
+func (c *Cache) ExportParams(outputDir string, mode JSONExportMode) error {
+	params, err := c.Params()
+	if err != nil {
+		return fmt.Errorf("getting params: %w", err)
+	}
+	return NewJSONExporter(params, outputDir).ExportToJSON(mode, "param")
+}
+
+// cachedParam returns a param definition from a table that is decoded once
+// and kept for the lifetime of the cache, since typed param accessors look
+// up defaults for every definition they are called on.
+func (c *Cache) cachedParam(id uint16) (*ParamDefinition, error) {
+	c.paramsMu.Lock()
+	defer c.paramsMu.Unlock()
+
+	if c.params == nil {
+		params, err := c.Params()
+		if err != nil {
+			return nil, err
+		}
+		c.params = params
+	}
+
+	param, ok := c.params[id]
+	if !ok {
+		return nil, fmt.Errorf("param %d: %w", id, ErrNotFound)
+	}
+	return param, nil
+}
+
+func (c *Cache) SpotAnim(id uint16) (*SpotAnim, error) {
+	files, err := c.Files(2, 13)
+	if err != nil {
+		return nil, fmt.Errorf("getting spotanim files: %w", err)
+	}
+
+	data, ok := files[uint32(id)]
+	if !ok {
+		return nil, fmt.Errorf("spotanim %d: %w", id, ErrNotFound)
+	}
+
+	spotAnim := NewSpotAnim(id)
+	if err := spotAnim.Read(data, c.decodeOpts...); err != nil {
+		return nil, fmt.Errorf("reading spotanim: %w", err)
+	}
+	return spotAnim, nil
+}
+
+func (c *Cache) SpotAnims() (map[uint16]*SpotAnim, error) {
+	files, err := c.Files(2, 13)
+	if err != nil {
+		return nil, fmt.Errorf("getting spotanim files: %w", err)
+	}
+
+	spotAnims := make(map[uint16]*SpotAnim, len(files))
+	for id, data := range files {
+		spotAnim := NewSpotAnim(uint16(id))
+		if err := spotAnim.Read(data, c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading spotanim %d: %w", id, err)
+		}
+		spotAnims[uint16(id)] = spotAnim
+	}
+	return spotAnims, nil
+}
+
+// SpotAnimSeq streams spotanims in ascending ID order without decoding the
+// ones that are never reached.
+func (c *Cache) SpotAnimSeq() *Seq[uint16, *SpotAnim] {
+	return newFileSeq(c, 2, 13, func(id uint16, files map[uint32][]byte) (*SpotAnim, error) {
+		spotAnim := NewSpotAnim(id)
+		if err := spotAnim.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading spotanim: %w", err)
+		}
+		return spotAnim, nil
+	})
+}
+
+func (c *Cache) ExportSpotAnims(outputDir string, mode JSONExportMode) error {
+	spotAnims, err := c.SpotAnims()
+	if err != nil {
+		return fmt.Errorf("getting spotanims: %w", err)
+	}
+	return NewJSONExporter(spotAnims, outputDir).ExportToJSON(mode, "spotanim")
+}
+
+func (c *Cache) IdentKit(id uint16) (*IdentKit, error) {
+	files, err := c.Files(2, 3)
+	if err != nil {
+		return nil, fmt.Errorf("getting identkit files: %w", err)
+	}
+
+	data, ok := files[uint32(id)]
+	if !ok {
+		return nil, fmt.Errorf("identkit %d: %w", id, ErrNotFound)
+	}
+
+	kit := NewIdentKit(id)
+	if err := kit.Read(data, c.decodeOpts...); err != nil {
+		return nil, fmt.Errorf("reading identkit: %w", err)
+	}
+	return kit, nil
+}
+
+func (c *Cache) IdentKits() (map[uint16]*IdentKit, error) {
+	files, err := c.Files(2, 3)
+	if err != nil {
+		return nil, fmt.Errorf("getting identkit files: %w", err)
+	}
+
+	kits := make(map[uint16]*IdentKit, len(files))
+	for id, data := range files {
+		kit := NewIdentKit(uint16(id))
+		if err := kit.Read(data, c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading identkit %d: %w", id, err)
+		}
+		kits[uint16(id)] = kit
+	}
+	return kits, nil
+}
+
+// IdentKitSeq streams identkits in ascending ID order without decoding the
+// ones that are never reached.
+func (c *Cache) IdentKitSeq() *Seq[uint16, *IdentKit] {
+	return newFileSeq(c, 2, 3, func(id uint16, files map[uint32][]byte) (*IdentKit, error) {
+		kit := NewIdentKit(id)
+		if err := kit.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading identkit: %w", err)
+		}
+		return kit, nil
+	})
+}
+
+func (c *Cache) ExportIdentKits(outputDir string, mode JSONExportMode) error {
+	kits, err := c.IdentKits()
+	if err != nil {
+		return fmt.Errorf("getting identkits: %w", err)
+	}
+	return NewJSONExporter(kits, outputDir).ExportToJSON(mode, "identkit")
+}
+
+func (c *Cache) Inventory(id uint16) (*InventoryDefinition, error) {
+	files, err := c.Files(2, 5)
+	if err != nil {
+		return nil, fmt.Errorf("getting inventory files: %w", err)
+	}
+
+	data, ok := files[uint32(id)]
+	if !ok {
+		return nil, fmt.Errorf("inventory %d: %w", id, ErrNotFound)
+	}
+
+	inv := NewInventoryDefinition(id)
+	if err := inv.Read(data, c.decodeOpts...); err != nil {
+		return nil, fmt.Errorf("reading inventory: %w", err)
+	}
+	return inv, nil
+}
+
+func (c *Cache) Inventories() (map[uint16]*InventoryDefinition, error) {
+	files, err := c.Files(2, 5)
+	if err != nil {
+		return nil, fmt.Errorf("getting inventory files: %w", err)
+	}
+
+	inventories := make(map[uint16]*InventoryDefinition, len(files))
+	for id, data := range files {
+		inv := NewInventoryDefinition(uint16(id))
+		if err := inv.Read(data, c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading inventory %d: %w", id, err)
+		}
+		inventories[uint16(id)] = inv
+	}
+	return inventories, nil
+}
+
+// InventorySeq streams inventories in ascending ID order without decoding the
+// ones that are never reached.
+func (c *Cache) InventorySeq() *Seq[uint16, *InventoryDefinition] {
+	return newFileSeq(c, 2, 5, func(id uint16, files map[uint32][]byte) (*InventoryDefinition, error) {
+		inv := NewInventoryDefinition(id)
+		if err := inv.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading inventory: %w", err)
+		}
+		return inv, nil
+	})
+}
+
+func (c *Cache) ExportInventories(outputDir string, mode JSONExportMode) error {
+	inventories, err := c.Inventories()
+	if err != nil {
+		return fmt.Errorf("getting inventories: %w", err)
+	}
+	return NewJSONExporter(inventories, outputDir).ExportToJSON(mode, "inventory")
+}
+
+func (c *Cache) HealthBar(id uint16) (*HealthBar, error) {
+	files, err := c.Files(2, 32)
+	if err != nil {
+		return nil, fmt.Errorf("getting healthbar files: %w", err)
+	}
+
+	data, ok := files[uint32(id)]
+	if !ok {
+		return nil, fmt.Errorf("healthbar %d: %w", id, ErrNotFound)
+	}
+
+	bar := NewHealthBar(id)
+	if err := bar.Read(data, c.decodeOpts...); err != nil {
+		return nil, fmt.Errorf("reading healthbar: %w", err)
+	}
+	return bar, nil
+}
+
+func (c *Cache) HealthBars() (map[uint16]*HealthBar, error) {
+	files, err := c.Files(2, 32)
+	if err != nil {
+		return nil, fmt.Errorf("getting healthbar files: %w", err)
+	}
+
+	bars := make(map[uint16]*HealthBar, len(files))
+	for id, data := range files {
+		bar := NewHealthBar(uint16(id))
+		if err := bar.Read(data, c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading healthbar %d: %w", id, err)
+		}
+		bars[uint16(id)] = bar
+	}
+	return bars, nil
+}
+
+// HealthBarSeq streams healthbars in ascending ID order without decoding the
+// ones that are never reached.
+func (c *Cache) HealthBarSeq() *Seq[uint16, *HealthBar] {
+	return newFileSeq(c, 2, 32, func(id uint16, files map[uint32][]byte) (*HealthBar, error) {
+		bar := NewHealthBar(id)
+		if err := bar.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading healthbar: %w", err)
+		}
+		return bar, nil
+	})
+}
+
+func (c *Cache) ExportHealthBars(outputDir string, mode JSONExportMode) error {
+	bars, err := c.HealthBars()
+	if err != nil {
+		return fmt.Errorf("getting healthbars: %w", err)
+	}
+	return NewJSONExporter(bars, outputDir).ExportToJSON(mode, "healthbar")
+}
+
+func (c *Cache) Hitsplat(id uint16) (*Hitsplat, error) {
+	files, err := c.Files(2, 33)
+	if err != nil {
+		return nil, fmt.Errorf("getting hitsplat files: %w", err)
+	}
+
+	data, ok := files[uint32(id)]
+	if !ok {
+		return nil, fmt.Errorf("hitsplat %d: %w", id, ErrNotFound)
+	}
+
+	splat := NewHitsplat(id)
+	if err := splat.Read(data, c.decodeOpts...); err != nil {
+		return nil, fmt.Errorf("reading hitsplat: %w", err)
+	}
+	return splat, nil
+}
+
+func (c *Cache) Hitsplats() (map[uint16]*Hitsplat, error) {
+	files, err := c.Files(2, 33)
+	if err != nil {
+		return nil, fmt.Errorf("getting hitsplat files: %w", err)
+	}
+
+	splats := make(map[uint16]*Hitsplat, len(files))
+	for id, data := range files {
+		splat := NewHitsplat(uint16(id))
+		if err := splat.Read(data, c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading hitsplat %d: %w", id, err)
+		}
+		splats[uint16(id)] = splat
+	}
+	return splats, nil
+}
+
+// HitsplatSeq streams hitsplats in ascending ID order without decoding the
+// ones that are never reached.
+func (c *Cache) HitsplatSeq() *Seq[uint16, *Hitsplat] {
+	return newFileSeq(c, 2, 33, func(id uint16, files map[uint32][]byte) (*Hitsplat, error) {
+		splat := NewHitsplat(id)
+		if err := splat.Read(files[uint32(id)], c.decodeOpts...); err != nil {
+			return nil, fmt.Errorf("reading hitsplat: %w", err)
+		}
+		return splat, nil
+	})
+}
+
+func (c *Cache) ExportHitsplats(outputDir string, mode JSONExportMode) error {
+	splats, err := c.Hitsplats()
+	if err != nil {
+		return fmt.Errorf("getting hitsplats: %w", err)
+	}
+	return NewJSONExporter(splats, outputDir).ExportToJSON(mode, "hitsplat")
+}
+
+func (c *Cache) Model(id uint16) (*Model, error) {
+	archiveData, err := c.Store.Read(7, uint32(id))
+	if err != nil {
+		return nil, fmt.Errorf("reading model archive: %w", err)
+	}
+
+	decompressedData, err := DecompressData(archiveData)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing model archive: %w", err)
+	}
+
+	model := NewModel(id)
+	if err := model.Read(decompressedData); err != nil {
+		return nil, fmt.Errorf("reading model: %w", err)
+	}
+	return model, nil
+}
+
 func (c *Cache) Sprite(id uint16) (*Sprite, error) {
 	archiveData, err := c.Store.Read(8, uint32(id))
 	if err != nil {
@@ -296,7 +891,7 @@ func (c *Cache) Sprites() (map[uint16]*Sprite, error) {
 	}
 
 	sprites := make(map[uint16]*Sprite, len(groups))
-	for group := range groups {
+	for _, group := range groups {
 		archiveData, err := c.Store.Read(8, uint32(group))
 		if err != nil {
 			return nil, fmt.Errorf("reading sprite archive: %w", err)
@@ -316,6 +911,26 @@ func (c *Cache) Sprites() (map[uint16]*Sprite, error) {
 	return sprites, nil
 }
 
+// LoadSprites reads and decodes all sprite groups concurrently. Sprites that
+// fail to decode are reported in a DecodeErrors alongside the sprites that
+// succeeded.
+func (c *Cache) LoadSprites(ctx context.Context, opts ...LoadOption) (map[uint16]*Sprite, error) {
+	groups, err := c.Store.GroupList(8)
+	if err != nil {
+		return nil, fmt.Errorf("getting index: %w", err)
+	}
+
+	return decodeConcurrent(ctx, groups, opts, func(id uint32) (*Sprite, error) {
+		return c.Sprite(uint16(id))
+	})
+}
+
+// SpriteSeq streams sprites in ascending ID order, reading each sprite group
+// from the store only when it is reached.
+func (c *Cache) SpriteSeq() *Seq[uint16, *Sprite] {
+	return newGroupSeq(c, 8, c.Sprite)
+}
+
 func (c *Cache) ExportSprites(outputDir string) error {
 	sprites, err := c.Sprites()
 	if err != nil {
@@ -332,7 +947,7 @@ func (c *Cache) Texture(id uint16) (*Texture, error) {
 
 	data, ok := files[uint32(id)]
 	if !ok {
-		return nil, fmt.Errorf("texture %d not found", id)
+		return nil, fmt.Errorf("texture %d: %w", id, ErrNotFound)
 	}
 
 	texture := NewTexture(id)
@@ -359,6 +974,18 @@ func (c *Cache) Textures() (map[uint16]*Texture, error) {
 	return textures, nil
 }
 
+// TextureSeq streams textures in ascending ID order without decoding the ones
+// that are never reached.
+func (c *Cache) TextureSeq() *Seq[uint16, *Texture] {
+	return newFileSeq(c, 9, 0, func(id uint16, files map[uint32][]byte) (*Texture, error) {
+		texture := NewTexture(id)
+		if err := texture.Read(files[uint32(id)]); err != nil {
+			return nil, fmt.Errorf("reading texture: %w", err)
+		}
+		return texture, nil
+	})
+}
+
 func (c *Cache) ExportTextures(outputDir string, mode JSONExportMode) error {
 	textures, err := c.Textures()
 	if err != nil {
@@ -366,3 +993,390 @@ func (c *Cache) ExportTextures(outputDir string, mode JSONExportMode) error {
 	}
 	return NewJSONExporter(textures, outputDir).ExportToJSON(mode, "texture")
 }
+
+func (c *Cache) SoundEffect(id uint16) (*SoundEffect, error) {
+	groupData, err := c.Store.Read(4, uint32(id))
+	if err != nil {
+		return nil, fmt.Errorf("reading sound effect group: %w", err)
+	}
+
+	data, err := DecompressData(groupData)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing sound effect group: %w", err)
+	}
+
+	sound := NewSoundEffect(id)
+	if err := sound.Read(data); err != nil {
+		return nil, fmt.Errorf("reading sound effect %d: %w", id, err)
+	}
+	return sound, nil
+}
+
+func (c *Cache) SoundEffects() (map[uint16]*SoundEffect, error) {
+	groups, err := c.Store.GroupList(4)
+	if err != nil {
+		return nil, fmt.Errorf("getting index: %w", err)
+	}
+
+	sounds := make(map[uint16]*SoundEffect, len(groups))
+	for _, group := range groups {
+		sound, err := c.SoundEffect(uint16(group))
+		if err != nil {
+			return nil, fmt.Errorf("getting sound effect: %w", err)
+		}
+		sounds[uint16(group)] = sound
+	}
+	return sounds, nil
+}
+
+// SoundEffectSeq streams sound effects in ascending ID order, reading each
+// group from the store only when it is reached.
+func (c *Cache) SoundEffectSeq() *Seq[uint16, *SoundEffect] {
+	return newGroupSeq(c, 4, c.SoundEffect)
+}
+
+// ExportSoundEffects synthesizes every sound effect to sound_<id>.wav in
+// outputDir.
+func (c *Cache) ExportSoundEffects(outputDir string) error {
+	sounds, err := c.SoundEffects()
+	if err != nil {
+		return fmt.Errorf("getting sound effects: %w", err)
+	}
+	return NewAudioExporter(sounds, outputDir).ExportToWAV("sound")
+}
+
+func (c *Cache) musicTrack(archiveID uint8, id uint32) (*MusicTrack, error) {
+	groupData, err := c.Store.Read(archiveID, id)
+	if err != nil {
+		return nil, fmt.Errorf("reading track group: %w", err)
+	}
+
+	data, err := DecompressData(groupData)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing track group: %w", err)
+	}
+
+	track := NewMusicTrack(id)
+	if err := track.Read(data); err != nil {
+		return nil, fmt.Errorf("reading track %d: %w", id, err)
+	}
+	return track, nil
+}
+
+func (c *Cache) musicTrackByName(archiveID uint8, name string) (*MusicTrack, error) {
+	index, err := c.Index(archiveID)
+	if err != nil {
+		return nil, fmt.Errorf("getting music index: %w", err)
+	}
+
+	group, err := index.GroupByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.musicTrack(archiveID, group.ID)
+}
+
+// MusicTrack decodes the song in archive 6 whose group is named name, such as
+// "harmony".
+func (c *Cache) MusicTrack(name string) (*MusicTrack, error) {
+	return c.musicTrackByName(6, name)
+}
+
+func (c *Cache) MusicTrackByID(id uint32) (*MusicTrack, error) {
+	return c.musicTrack(6, id)
+}
+
+// MusicTrackSeq streams the songs of archive 6 in ascending group ID order.
+func (c *Cache) MusicTrackSeq() *Seq[uint32, *MusicTrack] {
+	return newGroupSeq(c, 6, c.MusicTrackByID)
+}
+
+// Jingle decodes the jingle in archive 11 whose group is named name.
+func (c *Cache) Jingle(name string) (*MusicTrack, error) {
+	return c.musicTrackByName(11, name)
+}
+
+func (c *Cache) JingleByID(id uint32) (*MusicTrack, error) {
+	return c.musicTrack(11, id)
+}
+
+// JingleSeq streams the jingles of archive 11 in ascending group ID order.
+func (c *Cache) JingleSeq() *Seq[uint32, *MusicTrack] {
+	return newGroupSeq(c, 11, c.JingleByID)
+}
+
+// ExportMusicTracks writes every song to music_<id>.mid in outputDir.
+func (c *Cache) ExportMusicTracks(outputDir string) error {
+	return exportMIDI(c.MusicTrackSeq(), outputDir, "music")
+}
+
+// ExportJingles writes every jingle to jingle_<id>.mid in outputDir.
+func (c *Cache) ExportJingles(outputDir string) error {
+	return exportMIDI(c.JingleSeq(), outputDir, "jingle")
+}
+
+// MusicPatch decodes the instrument in archive 15 group id.
+func (c *Cache) MusicPatch(id uint32) (*MusicPatch, error) {
+	data, err := c.audioGroup(15, id)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := NewMusicPatch(id)
+	if err := patch.Read(data); err != nil {
+		return nil, fmt.Errorf("reading patch %d: %w", id, err)
+	}
+	return patch, nil
+}
+
+// MusicPatchSeq streams the patches of archive 15 in ascending ID order.
+func (c *Cache) MusicPatchSeq() *Seq[uint32, *MusicPatch] {
+	return newGroupSeq(c, 15, c.MusicPatch)
+}
+
+// VorbisSetup decodes the setup shared by the samples of archive 14.
+func (c *Cache) VorbisSetup() (*VorbisSetup, error) {
+	data, err := c.audioGroup(14, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	setup, err := ReadVorbisSetup(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading vorbis setup: %w", err)
+	}
+	return setup, nil
+}
+
+// MusicSample reads the Vorbis sample in archive 14 group id, which is decoded
+// with VorbisSample.Decode.
+func (c *Cache) MusicSample(id uint32) (*VorbisSample, error) {
+	data, err := c.audioGroup(14, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := NewVorbisSample(id)
+	if err := sample.Read(data); err != nil {
+		return nil, fmt.Errorf("reading sample %d: %w", id, err)
+	}
+	return sample, nil
+}
+
+func (c *Cache) audioGroup(archiveID uint8, id uint32) ([]byte, error) {
+	groupData, err := c.Store.Read(archiveID, id)
+	if err != nil {
+		return nil, fmt.Errorf("reading group %d: %w", id, err)
+	}
+
+	data, err := DecompressData(groupData)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing group %d: %w", id, err)
+	}
+	return data, nil
+}
+
+// RenderMusicTrack renders the song in archive 6 named name.
+func (c *Cache) RenderMusicTrack(name string, opts ...SynthOption) (*Audio, error) {
+	track, err := c.MusicTrack(name)
+	if err != nil {
+		return nil, fmt.Errorf("getting music track: %w", err)
+	}
+	synth, err := NewSynthesizer(c, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating synthesizer: %w", err)
+	}
+	return synth.Render(track)
+}
+
+func (c *Cache) Interface(id uint16) (*Interface, error) {
+	files, err := c.Files(3, uint32(id))
+	if err != nil {
+		return nil, fmt.Errorf("getting interface %d files: %w", id, err)
+	}
+
+	iface := &Interface{ID: id, Components: make(map[uint16]*Widget, len(files))}
+	for _, fileID := range fileIDs(files) {
+		widget := NewWidget(id, uint16(fileID))
+		if err := widget.Read(files[fileID]); err != nil {
+			return nil, fmt.Errorf("reading component %d:%d: %w", id, fileID, err)
+		}
+		iface.Components[uint16(fileID)] = widget
+	}
+
+	for _, fileID := range fileIDs(files) {
+		widget := iface.Components[uint16(fileID)]
+		if widget.ParentID < 0 || uint16(widget.ParentID>>16) != id {
+			continue
+		}
+		if parent, ok := iface.Components[uint16(widget.ParentID)]; ok {
+			parent.Children = append(parent.Children, widget.ID)
+		}
+	}
+	return iface, nil
+}
+
+func (c *Cache) Interfaces() (map[uint16]*Interface, error) {
+	groups, err := c.Store.GroupList(3)
+	if err != nil {
+		return nil, fmt.Errorf("getting index: %w", err)
+	}
+
+	interfaces := make(map[uint16]*Interface, len(groups))
+	for _, group := range groups {
+		iface, err := c.Interface(uint16(group))
+		if err != nil {
+			return nil, fmt.Errorf("getting interface: %w", err)
+		}
+		interfaces[uint16(group)] = iface
+	}
+	return interfaces, nil
+}
+
+// InterfaceSeq streams interfaces in ascending ID order, reading each
+// interface group from the store only when it is reached.
+func (c *Cache) InterfaceSeq() *Seq[uint16, *Interface] {
+	return newGroupSeq(c, 3, c.Interface)
+}
+
+func (c *Cache) ExportInterfaces(outputDir string, mode JSONExportMode) error {
+	interfaces, err := c.Interfaces()
+	if err != nil {
+		return fmt.Errorf("getting interfaces: %w", err)
+	}
+	return NewJSONExporter(interfaces, outputDir).ExportToJSON(mode, "interface")
+}
+
+func (c *Cache) Script(id uint16) (*ClientScript, error) {
+	data, err := c.scriptData(id)
+	if err != nil {
+		return nil, err
+	}
+
+	script := NewClientScript(id)
+	if err := script.Read(data); err != nil {
+		return nil, fmt.Errorf("reading script %d: %w", id, err)
+	}
+	return script, nil
+}
+
+func (c *Cache) scriptData(id uint16) ([]byte, error) {
+	groupData, err := c.Store.Read(12, uint32(id))
+	if err != nil {
+		return nil, fmt.Errorf("reading script group: %w", err)
+	}
+
+	data, err := DecompressData(groupData)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing script group: %w", err)
+	}
+	return data, nil
+}
+
+func (c *Cache) Scripts() (map[uint16]*ClientScript, error) {
+	groups, err := c.Store.GroupList(12)
+	if err != nil {
+		return nil, fmt.Errorf("getting index: %w", err)
+	}
+
+	scripts := make(map[uint16]*ClientScript, len(groups))
+	for _, group := range groups {
+		script, err := c.Script(uint16(group))
+		if err != nil {
+			return nil, fmt.Errorf("getting script: %w", err)
+		}
+		scripts[uint16(group)] = script
+	}
+	return scripts, nil
+}
+
+// ScriptSeq streams scripts in ascending ID order, reading each script group
+// from the store only when it is reached.
+func (c *Cache) ScriptSeq() *Seq[uint16, *ClientScript] {
+	return newGroupSeq(c, 12, c.Script)
+}
+
+// ExportScripts disassembles every script to <id>.rs2asm in outputDir.
+func (c *Cache) ExportScripts(outputDir string) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	seq := c.ScriptSeq()
+	for id, script := range seq.All() {
+		var b strings.Builder
+		if err := script.Disassemble(&b); err != nil {
+			return fmt.Errorf("disassembling script %d: %w", id, err)
+		}
+
+		filename := filepath.Join(outputDir, fmt.Sprintf("%d.rs2asm", id))
+		if err := os.WriteFile(filename, []byte(b.String()), 0644); err != nil {
+			return fmt.Errorf("writing file %s: %w", filename, err)
+		}
+	}
+	return seq.Err()
+}
+
+// ExportDecompiledScripts decompiles every script to <id>.rs2 in outputDir.
+func (c *Cache) ExportDecompiledScripts(outputDir string) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	decompiler := NewDecompiler(c)
+	seq := c.ScriptSeq()
+	for id, script := range seq.All() {
+		var b strings.Builder
+		if err := decompiler.Decompile(&b, script); err != nil {
+			return fmt.Errorf("decompiling script %d: %w", id, err)
+		}
+
+		filename := filepath.Join(outputDir, fmt.Sprintf("%d.rs2", id))
+		if err := os.WriteFile(filename, []byte(b.String()), 0644); err != nil {
+			return fmt.Errorf("writing file %s: %w", filename, err)
+		}
+	}
+	return seq.Err()
+}
+
+// VerifyScripts disassembles and reassembles every script, and returns the
+// IDs of the scripts whose reassembled bytes differ from the cache.
+func (c *Cache) VerifyScripts() ([]uint16, error) {
+	groups, err := c.Store.GroupList(12)
+	if err != nil {
+		return nil, fmt.Errorf("getting index: %w", err)
+	}
+	slices.Sort(groups)
+
+	var mismatched []uint16
+	for _, group := range groups {
+		id := uint16(group)
+		data, err := c.scriptData(id)
+		if err != nil {
+			return nil, err
+		}
+
+		script := NewClientScript(id)
+		if err := script.Read(data); err != nil {
+			return nil, fmt.Errorf("reading script %d: %w", id, err)
+		}
+
+		var b strings.Builder
+		if err := script.Disassemble(&b); err != nil {
+			return nil, fmt.Errorf("disassembling script %d: %w", id, err)
+		}
+		assembled, err := Assemble(strings.NewReader(b.String()))
+		if err != nil {
+			return nil, fmt.Errorf("assembling script %d: %w", id, err)
+		}
+		encoded, err := assembled.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("encoding script %d: %w", id, err)
+		}
+
+		if !bytes.Equal(encoded, data) {
+			mismatched = append(mismatched, id)
+		}
+	}
+	return mismatched, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joeychilson/osrscache"
+)
+
+func runAsm(args []string) error {
+	flags := flag.NewFlagSet("asm", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default: input name with .cs2)")
+	verify := flags.Bool("verify", false, "round-trip every script of a cache directory instead")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected an .rs2asm file, or a cache directory with -verify")
+	}
+
+	if *verify {
+		cache, err := openCache(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		mismatched, err := cache.VerifyScripts()
+		if err != nil {
+			return err
+		}
+		for _, id := range mismatched {
+			fmt.Printf("script %d does not round-trip\n", id)
+		}
+		if len(mismatched) > 0 {
+			return fmt.Errorf("%d scripts do not round-trip", len(mismatched))
+		}
+		return nil
+	}
+
+	input, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	script, err := osrscache.Assemble(input)
+	if err != nil {
+		return fmt.Errorf("assembling %s: %w", flags.Arg(0), err)
+	}
+	data, err := script.Encode()
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", flags.Arg(0), err)
+	}
+
+	path := *output
+	if path == "" {
+		path = strings.TrimSuffix(flags.Arg(0), filepath.Ext(flags.Arg(0))) + ".cs2"
+	}
+	return os.WriteFile(path, data, 0644)
+}
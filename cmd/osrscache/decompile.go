@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/joeychilson/osrscache"
+)
+
+func runDecompile(args []string) error {
+	flags := flag.NewFlagSet("decompile", flag.ExitOnError)
+	output := flags.String("o", "decompiled", "output directory when decompiling every script")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return fmt.Errorf("expected a cache directory and an optional script id")
+	}
+
+	cache, err := openCache(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 1 {
+		return cache.ExportDecompiledScripts(*output)
+	}
+
+	id, err := strconv.ParseUint(flags.Arg(1), 10, 16)
+	if err != nil {
+		return fmt.Errorf("parsing script id: %w", err)
+	}
+
+	script, err := cache.Script(uint16(id))
+	if err != nil {
+		return err
+	}
+	return osrscache.NewDecompiler(cache).Decompile(os.Stdout, script)
+}
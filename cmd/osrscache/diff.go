@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joeychilson/osrscache/diff"
+)
+
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the report as JSON instead of a changelog")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected old and new cache directories")
+	}
+
+	oldCache, err := openCache(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	newCache, err := openCache(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report, err := diff.Caches(oldCache, newCache)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return report.WriteChangelog(os.Stdout)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+func runDisasm(args []string) error {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	output := flags.String("o", "scripts", "output directory when disassembling every script")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return fmt.Errorf("expected a cache directory and an optional script id")
+	}
+
+	cache, err := openCache(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 1 {
+		return cache.ExportScripts(*output)
+	}
+
+	id, err := strconv.ParseUint(flags.Arg(1), 10, 16)
+	if err != nil {
+		return fmt.Errorf("parsing script id: %w", err)
+	}
+
+	script, err := cache.Script(uint16(id))
+	if err != nil {
+		return err
+	}
+	return script.Disassemble(os.Stdout)
+}
@@ -0,0 +1,82 @@
+// Command osrscache inspects Old School RuneScape caches.
+//
+// Usage:
+//
+//	osrscache diff [-json] old/ new/
+//	osrscache render [-width w] [-height h] [-o out.png] cache/ id
+//	osrscache disasm [-o dir] cache/ [id]
+//	osrscache asm [-o out.cs2] file.rs2asm
+//	osrscache asm -verify cache/
+//	osrscache decompile [-o dir] cache/ [id]
+//	osrscache music [-jingle] [-rate hz] [-o out.wav] cache/ name
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joeychilson/osrscache"
+	"github.com/joeychilson/osrscache/jagex"
+	"github.com/joeychilson/osrscache/openrs2"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"diff", "diff [-json] old/ new/", runDiff},
+	{"render", "render [-width w] [-height h] [-o out.png] cache/ id", runRender},
+	{"disasm", "disasm [-o dir] cache/ [id]", runDisasm},
+	{"asm", "asm [-o out.cs2] file.rs2asm | asm -verify cache/", runAsm},
+	{"decompile", "decompile [-o dir] cache/ [id]", runDecompile},
+	{"music", "music [-jingle] [-rate hz] [-o out.wav] cache/ name", runMusic},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "osrscache %s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  osrscache %s\n", cmd.usage)
+	}
+}
+
+// openCache opens a cache directory, using the Jagex store when it holds a
+// main_file_cache.dat2 and the OpenRS2 store otherwise.
+func openCache(path string) (*osrscache.Cache, error) {
+	if _, err := os.Stat(filepath.Join(path, jagex.DataFileName)); err == nil {
+		store, err := jagex.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening jagex store: %w", err)
+		}
+		return osrscache.New(store), nil
+	}
+
+	store, err := openrs2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening openrs2 store: %w", err)
+	}
+	return osrscache.New(store), nil
+}
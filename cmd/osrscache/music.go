@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joeychilson/osrscache"
+)
+
+func runMusic(args []string) error {
+	flags := flag.NewFlagSet("music", flag.ExitOnError)
+	jingle := flags.Bool("jingle", false, "render a jingle from archive 11 instead of a song")
+	rate := flags.Int("rate", osrscache.DefaultSynthSampleRate, "output sample rate")
+	output := flags.String("o", "", "output file (default <name>.wav)")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected a cache directory and a track name")
+	}
+	name := flags.Arg(1)
+
+	cache, err := openCache(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var track *osrscache.MusicTrack
+	if *jingle {
+		track, err = cache.Jingle(name)
+	} else {
+		track, err = cache.MusicTrack(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	synth, err := osrscache.NewSynthesizer(cache, osrscache.WithSynthSampleRate(*rate))
+	if err != nil {
+		return err
+	}
+	audio, err := synth.Render(track)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = name + ".wav"
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("creating output: %w", err)
+	}
+
+	if err := audio.WriteWAV(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing wav: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"strconv"
+
+	"github.com/joeychilson/osrscache"
+)
+
+func runRender(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	width := flags.Int("width", 765, "viewport width")
+	height := flags.Int("height", 503, "viewport height")
+	output := flags.String("o", "", "output file (default interface_<id>.png)")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected a cache directory and an interface id")
+	}
+
+	id, err := strconv.ParseUint(flags.Arg(1), 10, 16)
+	if err != nil {
+		return fmt.Errorf("parsing interface id: %w", err)
+	}
+
+	cache, err := openCache(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	img, err := osrscache.NewInterfaceRenderer(cache).Render(uint16(id), *width, *height)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = fmt.Sprintf("interface_%d.png", id)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("creating output: %w", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("encoding png: %w", err)
+	}
+	return f.Close()
+}
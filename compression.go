@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
+	"encoding/binary"
 	"fmt"
 	"io"
 )
@@ -19,30 +20,30 @@ func DecompressData(data []byte) ([]byte, error) {
 
 	compressionType, err := reader.ReadByte()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read compression type: %w", err)
+		return nil, &DecompressionError{Err: fmt.Errorf("failed to read compression type: %w", err)}
 	}
 
 	compressedLength, err := reader.ReadUint32()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read compressed length: %w", err)
+		return nil, &DecompressionError{Compression: compressionType, Err: fmt.Errorf("failed to read compressed length: %w", err)}
 	}
 
 	if compressionType == CompressionNone {
 		uncompressedData := make([]byte, compressedLength)
 		_, err := io.ReadFull(reader, uncompressedData)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read uncompressed data: %w", err)
+			return nil, &DecompressionError{Compression: compressionType, Err: fmt.Errorf("failed to read uncompressed data: %w", err)}
 		}
 		return uncompressedData, nil
 	}
 
 	uncompressedLength, err := reader.ReadUint32()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read uncompressed length: %w", err)
+		return nil, &DecompressionError{Compression: compressionType, Err: fmt.Errorf("failed to read uncompressed length: %w", err)}
 	}
 
 	if uint32(reader.Len()) < compressedLength {
-		return nil, fmt.Errorf("archive data shorter than expected: %d < %d", reader.Len(), compressedLength)
+		return nil, &DecompressionError{Compression: compressionType, Err: fmt.Errorf("archive data shorter than expected: %d < %d", reader.Len(), compressedLength)}
 	}
 
 	var decompressor io.Reader
@@ -51,7 +52,7 @@ func DecompressData(data []byte) ([]byte, error) {
 	case CompressionGZIP:
 		gzipReader, err := gzip.NewReader(reader)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+			return nil, &DecompressionError{Compression: compressionType, Err: fmt.Errorf("failed to create gzip reader: %w", err)}
 		}
 		defer gzipReader.Close()
 		decompressor = gzipReader
@@ -59,13 +60,30 @@ func DecompressData(data []byte) ([]byte, error) {
 		bzip2Header := []byte{'B', 'Z', 'h', '1'}
 		decompressor = bzip2.NewReader(io.MultiReader(bytes.NewReader(bzip2Header), reader))
 	default:
-		return nil, fmt.Errorf("unknown compression type: %d", compressionType)
+		return nil, &DecompressionError{Compression: compressionType, Err: fmt.Errorf("unknown compression type: %d", compressionType)}
 	}
 
 	uncompressedData := make([]byte, uncompressedLength)
 	n, err := io.ReadFull(decompressor, uncompressedData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decompress data (read %d bytes): %w", n, err)
+		return nil, &DecompressionError{Compression: compressionType, Err: fmt.Errorf("failed to decompress data (read %d bytes): %w", n, err)}
 	}
 	return uncompressedData, nil
 }
+
+// ContainerLength returns the length of the container at the start of data,
+// excluding the version trailer that stores append to some groups.
+func ContainerLength(data []byte) (int, error) {
+	if len(data) < 5 {
+		return 0, fmt.Errorf("container too short: %d bytes", len(data))
+	}
+
+	length := 5 + int(binary.BigEndian.Uint32(data[1:5]))
+	if data[0] != CompressionNone {
+		length += 4
+	}
+	if length > len(data) {
+		return 0, fmt.Errorf("container length %d exceeds data length %d", length, len(data))
+	}
+	return length, nil
+}
@@ -0,0 +1,80 @@
+package osrscache
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	lenient bool
+	logger  *slog.Logger
+}
+
+// WithLenientDecoding makes definition decoders keep unknown opcodes instead
+// of failing. The opcode and every byte after it are stored in the definition's
+// Unknown field and decoding of that definition stops there.
+func WithLenientDecoding() DecodeOption {
+	return func(o *decodeOptions) {
+		o.lenient = true
+	}
+}
+
+// WithStrictDecoding makes definition decoders fail on unknown opcodes. This
+// is the default, except for objects and NPCs, which have always decoded past
+// opcodes they do not know and stay lenient unless this option is given.
+func WithStrictDecoding() DecodeOption {
+	return func(o *decodeOptions) {
+		o.lenient = false
+	}
+}
+
+// WithLogger sets the logger that lenient decoding reports unknown opcodes to.
+// It defaults to slog.Default.
+func WithLogger(logger *slog.Logger) DecodeOption {
+	return func(o *decodeOptions) {
+		o.logger = logger
+	}
+}
+
+func newDecodeOptions(opts []DecodeOption) *decodeOptions {
+	o := &decodeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.logger == nil {
+		o.logger = slog.Default()
+	}
+	return o
+}
+
+// newLenientDecodeOptions is newDecodeOptions for definitions that are lenient
+// by default.
+func newLenientDecodeOptions(opts []DecodeOption) *decodeOptions {
+	return newDecodeOptions(append([]DecodeOption{WithLenientDecoding()}, opts...))
+}
+
+type UnknownOpcode struct {
+	Opcode uint8  `json:"opcode"`
+	Data   []byte `json:"data"`
+}
+
+func (o *decodeOptions) unknownOpcode(definition string, id uint16, opcode uint8, reader *Reader) (*UnknownOpcode, error) {
+	if !o.lenient {
+		return nil, &UnknownOpcodeError{Type: definition, ID: id, Opcode: opcode}
+	}
+
+	data, err := reader.ReadBytes(reader.Len())
+	if err != nil {
+		return nil, fmt.Errorf("reading unknown opcode data: %w", err)
+	}
+
+	o.logger.Warn("unknown opcode",
+		slog.String("definition", definition),
+		slog.Int("id", int(id)),
+		slog.Int("opcode", int(opcode)),
+		slog.Int("remaining", len(data)),
+	)
+	return &UnknownOpcode{Opcode: opcode, Data: data}, nil
+}
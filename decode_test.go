@@ -0,0 +1,99 @@
+package osrscache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+var quietLogger = WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+type decoder interface {
+	Read(data []byte, opts ...DecodeOption) error
+}
+
+func TestUnknownOpcode(t *testing.T) {
+	// Opcode 200 is unknown to every decoder.
+	data := []byte{200, 7, 8, 0}
+
+	tests := []struct {
+		name          string
+		new           func() decoder
+		strictDefault bool
+	}{
+		{"item", func() decoder { return NewItem(1) }, true},
+		{"object", func() decoder { return NewObject(1) }, false},
+		{"npc", func() decoder { return NewNPC(1) }, false},
+	}
+	unknown := func(d decoder) *UnknownOpcode {
+		switch d := d.(type) {
+		case *Item:
+			return d.Unknown
+		case *Object:
+			return d.Unknown
+		case *NPC:
+			return d.Unknown
+		}
+		return nil
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/strict", func(t *testing.T) {
+			err := tt.new().Read(data, WithStrictDecoding())
+			var opErr *UnknownOpcodeError
+			if !errors.As(err, &opErr) {
+				t.Fatalf("err = %v, want UnknownOpcodeError", err)
+			}
+			if opErr.Opcode != 200 || opErr.ID != 1 || opErr.Type != tt.name {
+				t.Errorf("err = %+v, want opcode 200 in %s 1", opErr, tt.name)
+			}
+		})
+
+		t.Run(tt.name+"/lenient", func(t *testing.T) {
+			d := tt.new()
+			if err := d.Read(data, WithLenientDecoding(), quietLogger); err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			got := unknown(d)
+			if got == nil || got.Opcode != 200 || !bytes.Equal(got.Data, []byte{7, 8, 0}) {
+				t.Errorf("Unknown = %+v, want opcode 200 with data [7 8 0]", got)
+			}
+		})
+
+		t.Run(tt.name+"/default", func(t *testing.T) {
+			d := tt.new()
+			err := d.Read(data, quietLogger)
+			if tt.strictDefault {
+				if err == nil {
+					t.Fatal("Read succeeded, want an error by default")
+				}
+				return
+			}
+			if err != nil || unknown(d) == nil {
+				t.Errorf("Read = %v, Unknown = %v, want lenient by default", err, unknown(d))
+			}
+		})
+	}
+}
+
+func TestObjectFlagOpcodes(t *testing.T) {
+	obj := NewObject(1)
+	if err := obj.Read([]byte{23, 89, 90, 60, 0, 5, 0}, WithStrictDecoding()); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !obj.ModelClipped || obj.RandomizeAnimStart || !obj.DeferAnimChange || obj.MapAreaID != 5 {
+		t.Errorf("got %+v", obj)
+	}
+}
+
+func TestNPCFlagOpcodes(t *testing.T) {
+	npc := NewNPC(1)
+	if err := npc.Read([]byte{126, 0, 3, 129, 130, 145, 146, 1, 2, 147, 0}, WithStrictDecoding()); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if npc.FootprintSize != 3 || !npc.IdleAnimRestart || !npc.HideForOverlap || npc.OverlapTint != 0x102 || npc.ZBuffer {
+		t.Errorf("got %+v", npc)
+	}
+}
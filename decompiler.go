@@ -0,0 +1,1252 @@
+package osrscache
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OpEnum is the enum command, which pushes a string or an int depending on
+// its output type operand.
+const OpEnum = 3408
+
+// stackEffect is the number of values a command pops from and pushes to the
+// int and string stacks.
+type stackEffect struct {
+	ints, strings             int
+	intResults, stringResults int
+}
+
+// componentEffects are the stack effects of the cc_ commands, which act on
+// the component selected by their operand. The if_ command 1000 opcodes up
+// pops the component from the int stack instead.
+var componentEffects = map[uint16]stackEffect{
+	1000: {ints: 4},
+	1001: {ints: 4},
+	1003: {ints: 1},
+	1005: {ints: 1},
+	1006: {ints: 1},
+
+	1100: {ints: 2},
+	1101: {ints: 1},
+	1102: {ints: 1},
+	1103: {ints: 1},
+	1104: {ints: 1},
+	1105: {ints: 1},
+	1106: {ints: 1},
+	1107: {ints: 1},
+	1108: {ints: 1},
+	1109: {ints: 6},
+	1110: {ints: 1},
+	1111: {ints: 1},
+	1112: {strings: 1},
+	1113: {ints: 1},
+	1114: {ints: 3},
+	1115: {ints: 1},
+	1116: {ints: 1},
+	1117: {ints: 1},
+	1118: {ints: 1},
+	1119: {ints: 1},
+	1120: {ints: 2},
+	1121: {},
+
+	1200: {ints: 2},
+	1201: {ints: 1},
+	1202: {},
+	1205: {ints: 2},
+	1212: {ints: 2},
+
+	1300: {ints: 1, strings: 1},
+	1301: {ints: 2},
+	1302: {ints: 1},
+	1303: {ints: 1},
+	1304: {ints: 1},
+	1305: {strings: 1},
+	1306: {strings: 1},
+	1307: {},
+
+	1500: {intResults: 1},
+	1501: {intResults: 1},
+	1502: {intResults: 1},
+	1503: {intResults: 1},
+	1504: {intResults: 1},
+	1505: {intResults: 1},
+
+	1600: {intResults: 1},
+	1601: {intResults: 1},
+	1602: {stringResults: 1},
+	1603: {intResults: 1},
+	1604: {intResults: 1},
+	1605: {intResults: 1},
+	1606: {intResults: 1},
+	1607: {intResults: 1},
+	1608: {intResults: 1},
+	1609: {intResults: 1},
+	1611: {intResults: 1},
+	1612: {intResults: 1},
+
+	1700: {intResults: 1},
+	1701: {intResults: 1},
+
+	1800: {intResults: 1},
+	1801: {ints: 1, stringResults: 1},
+	1802: {stringResults: 1},
+}
+
+var commandEffects = func() map[uint16]stackEffect {
+	effects := map[uint16]stackEffect{
+		100: {ints: 3},
+		101: {},
+		102: {ints: 1},
+
+		200: {ints: 2, intResults: 1},
+		201: {ints: 1, intResults: 1},
+
+		1702: {intResults: 1},
+
+		1927: {},
+
+		2702: {ints: 1, intResults: 1},
+		2706: {intResults: 1},
+
+		3100: {strings: 1},
+		3101: {ints: 2},
+		3103: {},
+		3104: {strings: 1},
+		3105: {strings: 1},
+		3106: {strings: 1},
+		3107: {ints: 1, strings: 1},
+		3108: {ints: 3},
+		3109: {ints: 2},
+		3110: {ints: 1},
+		3111: {intResults: 1},
+		3112: {ints: 1},
+		3113: {ints: 1, strings: 1},
+		3115: {ints: 1},
+		3116: {ints: 1, strings: 2},
+		3117: {ints: 1},
+		3118: {ints: 1},
+		3119: {ints: 1},
+		3125: {ints: 1},
+		3126: {ints: 1},
+		3127: {ints: 1},
+		3128: {intResults: 1},
+		3132: {intResults: 2},
+		3141: {ints: 1},
+		3142: {intResults: 1},
+		3143: {ints: 1},
+		3144: {intResults: 1},
+
+		3200: {ints: 3},
+		3201: {ints: 1},
+		3202: {ints: 2},
+
+		3300: {intResults: 1},
+		3301: {ints: 2, intResults: 1},
+		3302: {ints: 2, intResults: 1},
+		3303: {ints: 2, intResults: 1},
+		3304: {ints: 1, intResults: 1},
+		3305: {ints: 1, intResults: 1},
+		3306: {ints: 1, intResults: 1},
+		3307: {ints: 1, intResults: 1},
+		3308: {intResults: 1},
+		3309: {ints: 1, intResults: 1},
+		3310: {ints: 1, intResults: 1},
+		3311: {ints: 1, intResults: 1},
+		3312: {intResults: 1},
+		3313: {ints: 2, intResults: 1},
+		3314: {ints: 2, intResults: 1},
+		3315: {ints: 2, intResults: 1},
+		3316: {intResults: 1},
+		3317: {intResults: 1},
+		3318: {intResults: 1},
+		3321: {intResults: 1},
+		3322: {intResults: 1},
+		3323: {intResults: 1},
+		3324: {intResults: 1},
+		3325: {ints: 4, intResults: 1},
+
+		3400: {ints: 2, stringResults: 1},
+		3411: {ints: 1, intResults: 1},
+
+		3600: {intResults: 1},
+		3601: {ints: 1, stringResults: 2},
+		3602: {ints: 1, intResults: 1},
+		3603: {ints: 1, intResults: 1},
+		3604: {ints: 1, strings: 1},
+		3605: {strings: 1},
+		3606: {strings: 1},
+		3607: {strings: 1},
+		3608: {strings: 1},
+		3609: {strings: 1, intResults: 1},
+		3611: {stringResults: 1},
+		3612: {intResults: 1},
+		3613: {ints: 1, stringResults: 1},
+		3614: {ints: 1, intResults: 1},
+		3615: {ints: 1, intResults: 1},
+		3616: {intResults: 1},
+		3617: {strings: 1},
+		3618: {intResults: 1},
+		3619: {strings: 1},
+		3620: {},
+		3621: {intResults: 1},
+		3622: {ints: 1, stringResults: 2},
+		3623: {strings: 1, intResults: 1},
+		3624: {ints: 1, intResults: 1},
+		3625: {stringResults: 1},
+		3626: {ints: 1, intResults: 1},
+		3627: {ints: 1, intResults: 1},
+
+		3903: {ints: 1, intResults: 1},
+		3904: {ints: 1, intResults: 1},
+		3905: {ints: 1, intResults: 1},
+		3906: {ints: 1, intResults: 1},
+		3907: {ints: 1, intResults: 1},
+		3908: {ints: 1, intResults: 1},
+		3910: {ints: 1, intResults: 1},
+		3911: {ints: 1, intResults: 1},
+		3912: {ints: 1, intResults: 1},
+		3913: {ints: 1, intResults: 1},
+		3914: {ints: 1},
+		3915: {ints: 1},
+		3916: {ints: 2},
+		3917: {ints: 1},
+		3918: {ints: 1},
+		3919: {intResults: 1},
+		3920: {ints: 1, intResults: 1},
+		3921: {ints: 1, stringResults: 1},
+		3922: {ints: 1, stringResults: 1},
+		3923: {ints: 1, stringResults: 1},
+		3924: {ints: 1, intResults: 1},
+		3925: {ints: 1, intResults: 1},
+		3926: {ints: 1, intResults: 1},
+
+		4000: {ints: 2, intResults: 1},
+		4001: {ints: 2, intResults: 1},
+		4002: {ints: 2, intResults: 1},
+		4003: {ints: 2, intResults: 1},
+		4004: {ints: 1, intResults: 1},
+		4005: {ints: 1, intResults: 1},
+		4006: {ints: 5, intResults: 1},
+		4007: {ints: 2, intResults: 1},
+		4008: {ints: 2, intResults: 1},
+		4009: {ints: 2, intResults: 1},
+		4010: {ints: 2, intResults: 1},
+		4011: {ints: 2, intResults: 1},
+		4012: {ints: 2, intResults: 1},
+		4013: {ints: 2, intResults: 1},
+		4014: {ints: 2, intResults: 1},
+		4015: {ints: 2, intResults: 1},
+		4016: {ints: 2, intResults: 1},
+		4017: {ints: 2, intResults: 1},
+		4018: {ints: 3, intResults: 1},
+
+		4100: {ints: 1, strings: 1, stringResults: 1},
+		4101: {strings: 2, stringResults: 1},
+		4102: {ints: 1, strings: 1, stringResults: 1},
+		4103: {strings: 1, stringResults: 1},
+		4104: {ints: 1, stringResults: 1},
+		4105: {strings: 2, stringResults: 1},
+		4106: {ints: 1, stringResults: 1},
+		4107: {strings: 2, intResults: 1},
+		4108: {ints: 2, strings: 1, intResults: 1},
+		4109: {ints: 2, strings: 1, intResults: 1},
+		4110: {ints: 1, strings: 2, stringResults: 1},
+		4111: {strings: 1, stringResults: 1},
+		4112: {ints: 1, strings: 1, stringResults: 1},
+		4113: {ints: 1, intResults: 1},
+		4114: {ints: 1, intResults: 1},
+		4115: {ints: 1, intResults: 1},
+		4116: {ints: 1, intResults: 1},
+		4117: {strings: 1, intResults: 1},
+		4118: {ints: 2, strings: 1, stringResults: 1},
+		4119: {strings: 1, stringResults: 1},
+		4120: {ints: 1, strings: 1, intResults: 1},
+		4121: {ints: 1, strings: 2, intResults: 1},
+
+		4200: {ints: 1, stringResults: 1},
+		4201: {ints: 2, stringResults: 1},
+		4202: {ints: 2, stringResults: 1},
+		4203: {ints: 1, intResults: 1},
+		4204: {ints: 1, intResults: 1},
+		4205: {ints: 1, intResults: 1},
+		4206: {ints: 1, intResults: 1},
+		4207: {ints: 1, intResults: 1},
+		4210: {ints: 1, strings: 1, intResults: 1},
+		4211: {intResults: 1},
+		4212: {},
+
+		5000: {intResults: 1},
+		5001: {ints: 3},
+		5002: {ints: 2, strings: 1},
+		5003: {ints: 2, intResults: 3, stringResults: 3},
+		5004: {ints: 1, intResults: 3, stringResults: 3},
+		5005: {intResults: 1},
+		5008: {ints: 1, strings: 1},
+		5009: {strings: 2},
+		5015: {stringResults: 1},
+		5016: {intResults: 1},
+		5017: {ints: 1, intResults: 1},
+		5018: {ints: 1, intResults: 1},
+		5019: {ints: 1, intResults: 1},
+		5020: {strings: 1},
+		5021: {strings: 1},
+		5022: {stringResults: 1},
+
+		5306: {intResults: 1},
+		5307: {ints: 1},
+		5308: {intResults: 1},
+		5309: {ints: 1},
+
+		5504: {ints: 2},
+		5505: {intResults: 1},
+		5506: {intResults: 1},
+		5530: {ints: 1},
+		5531: {intResults: 1},
+
+		5630: {},
+
+		6200: {ints: 2},
+		6201: {ints: 2},
+		6202: {ints: 4},
+		6203: {intResults: 2},
+		6204: {intResults: 2},
+		6205: {intResults: 2},
+
+		6500: {intResults: 1},
+		6501: {intResults: 4, stringResults: 2},
+		6502: {intResults: 4, stringResults: 2},
+		6506: {ints: 1, intResults: 4, stringResults: 2},
+		6507: {ints: 4},
+		6512: {ints: 1},
+		6518: {intResults: 1},
+		6519: {intResults: 1},
+
+		6601: {ints: 1, stringResults: 1},
+		6602: {ints: 1},
+		6603: {intResults: 1},
+		6604: {ints: 1},
+		6605: {intResults: 1},
+		6606: {ints: 1},
+		6607: {ints: 1},
+		6608: {ints: 1},
+		6609: {ints: 1},
+		6610: {intResults: 2},
+		6611: {ints: 1, intResults: 1},
+		6612: {ints: 1, intResults: 2},
+		6613: {ints: 1, intResults: 4},
+		6614: {ints: 1, intResults: 1},
+		6616: {intResults: 1},
+		6617: {ints: 1, intResults: 2},
+		6621: {ints: 2, intResults: 1},
+		6622: {intResults: 2},
+		6628: {ints: 1},
+		6629: {ints: 1},
+		6630: {ints: 1},
+		6631: {},
+		6632: {ints: 1},
+		6633: {ints: 2},
+		6634: {ints: 2},
+		6635: {intResults: 1},
+		6636: {ints: 1, intResults: 1},
+		6637: {ints: 1, intResults: 1},
+		6639: {intResults: 2},
+		6640: {intResults: 2},
+		6693: {ints: 1, stringResults: 1},
+		6694: {ints: 1, intResults: 1},
+		6695: {ints: 1, intResults: 1},
+		6696: {ints: 1, intResults: 1},
+		6697: {intResults: 1},
+		6699: {intResults: 1},
+	}
+	for opcode, effect := range componentEffects {
+		effects[opcode] = effect
+		effect.ints++
+		effects[opcode+1000] = effect
+	}
+	return effects
+}()
+
+// paramCommands are the commands that read a param of a config, pushing an
+// int or a string depending on the param's type.
+var paramCommands = map[uint16]bool{
+	4208: true,
+	6513: true,
+	6514: true,
+	6515: true,
+}
+
+// isHook reports whether opcode is a cc_seton or if_seton command, which
+// takes a script call described by a signature string.
+func isHook(opcode uint16) bool {
+	return opcode >= 1400 && opcode < 1500 || opcode >= 2400 && opcode < 2500
+}
+
+// infixOperators are the commands written as binary operators.
+var infixOperators = map[uint16]string{
+	4000: "+",
+	4001: "-",
+	4002: "*",
+	4003: "/",
+	4011: "%",
+	4014: "&",
+	4015: "|",
+}
+
+var comparisons = map[uint16]string{
+	OpIfIcmpne: "!",
+	OpIfIcmpeq: "=",
+	OpIfIcmplt: "<",
+	OpIfIcmpgt: ">",
+	OpIfIcmple: "<=",
+	OpIfIcmpge: ">=",
+}
+
+var negatedComparisons = map[uint16]string{
+	OpIfIcmpne: "=",
+	OpIfIcmpeq: "!",
+	OpIfIcmplt: ">=",
+	OpIfIcmpgt: "<=",
+	OpIfIcmple: ">",
+	OpIfIcmpge: "<",
+}
+
+// scriptSignature holds the arguments a script takes and the values it
+// returns.
+type scriptSignature struct {
+	intArgs, stringArgs       int
+	intResults, stringResults int
+	// incomplete reports that a command with an unknown stack effect runs
+	// before the return, so the result counts cannot be trusted.
+	incomplete bool
+}
+
+// Decompiler turns client scripts into RuneScript-like pseudocode. Scripts
+// invoked by the decompiled script are read from the cache to learn how many
+// arguments they take and values they return.
+type Decompiler struct {
+	cache *Cache
+
+	mu         sync.Mutex
+	signatures map[uint16]*scriptSignature
+}
+
+// NewDecompiler returns a decompiler resolving invoked scripts from cache,
+// which may be nil.
+func NewDecompiler(cache *Cache) *Decompiler {
+	return &Decompiler{cache: cache, signatures: make(map[uint16]*scriptSignature)}
+}
+
+// Decompile writes script as pseudocode. Structures the compiler emits for if,
+// while and switch statements are recovered, and any other branch is written
+// as a goto.
+func (d *Decompiler) Decompile(w io.Writer, script *ClientScript) error {
+	signature := d.infer(script)
+
+	var params []string
+	for i := range int(script.IntArgumentCount) {
+		params = append(params, fmt.Sprintf("int $int%d", i))
+	}
+	for i := range int(script.StringArgumentCount) {
+		params = append(params, fmt.Sprintf("string $string%d", i))
+	}
+	var results []string
+	if signature.incomplete {
+		signature.intResults, signature.stringResults = 0, 0
+	}
+	for range signature.intResults {
+		results = append(results, "int")
+	}
+	for range signature.stringResults {
+		results = append(results, "string")
+	}
+
+	name := script.Name
+	if name == "" {
+		name = fmt.Sprintf("script%d", script.ID)
+	}
+	header := fmt.Sprintf("[clientscript,%s](%s)", name, strings.Join(params, ", "))
+	if len(results) > 0 {
+		header += "(" + strings.Join(results, ", ") + ")"
+	}
+
+	// Labels are only known once every goto has been written, so scripts
+	// with unstructured branches are decompiled a second time.
+	body := d.newState(script)
+	body.region(0, len(script.Instructions))
+	if len(body.gotos) > 0 {
+		labels := body.gotos
+		body = d.newState(script)
+		body.labels = labels
+		body.region(0, len(script.Instructions))
+	}
+
+	_, err := io.WriteString(w, header+"\n"+body.out.String())
+	return err
+}
+
+// signature returns the signature of the script with the given ID, or false
+// when it cannot be read or is still being inferred.
+func (d *Decompiler) signature(id int32) (scriptSignature, bool) {
+	if id < 0 || id > 0xFFFF {
+		return scriptSignature{}, false
+	}
+
+	d.mu.Lock()
+	signature, seen := d.signatures[uint16(id)]
+	if !seen && d.cache != nil {
+		d.signatures[uint16(id)] = nil
+	}
+	d.mu.Unlock()
+	if seen {
+		if signature == nil || signature.incomplete {
+			return scriptSignature{}, false
+		}
+		return *signature, true
+	}
+	if d.cache == nil {
+		return scriptSignature{}, false
+	}
+
+	script, err := d.cache.Script(uint16(id))
+	if err != nil {
+		d.mu.Lock()
+		delete(d.signatures, uint16(id))
+		d.mu.Unlock()
+		return scriptSignature{}, false
+	}
+	inferred := d.infer(script)
+
+	d.mu.Lock()
+	d.signatures[uint16(id)] = &inferred
+	d.mu.Unlock()
+	return inferred, !inferred.incomplete
+}
+
+// infer reads the arguments of script from its header and its results from
+// the stack depth at the first reachable return. The results are incomplete
+// when a command with an unknown stack effect runs on the way.
+func (d *Decompiler) infer(script *ClientScript) scriptSignature {
+	signature := scriptSignature{
+		intArgs:    int(script.IntArgumentCount),
+		stringArgs: int(script.StringArgumentCount),
+	}
+
+	s := d.newState(script)
+	s.quiet = true
+	saved := make(map[int][2][]operand)
+	save := func(target int) {
+		if _, ok := saved[target]; !ok {
+			saved[target] = [2][]operand{slices.Clone(s.ints), slices.Clone(s.strings)}
+		}
+	}
+
+	reachable := true
+	for pc, ins := range script.Instructions {
+		if state, ok := saved[pc]; ok {
+			s.ints, s.strings = slices.Clone(state[0]), slices.Clone(state[1])
+			reachable = true
+		}
+		if !reachable {
+			continue
+		}
+
+		switch {
+		case ins.Opcode == OpReturn:
+			signature.intResults, signature.stringResults = len(s.ints), len(s.strings)
+			signature.incomplete = s.unknown
+			return signature
+		case ins.Opcode == OpJump:
+			save(pc + int(ins.IntOperand) + 1)
+			reachable = false
+		case IsJump(ins.Opcode):
+			s.popInt()
+			s.popInt()
+			save(pc + int(ins.IntOperand) + 1)
+		case ins.Opcode == OpSwitch:
+			s.popInt()
+			if int(ins.IntOperand) < len(script.Switches) {
+				for _, c := range script.Switches[ins.IntOperand] {
+					save(pc + int(c.Offset) + 1)
+				}
+			}
+		default:
+			s.exec(pc)
+		}
+	}
+	return signature
+}
+
+// operand is an expression on the simulated int or string stack.
+type operand struct {
+	text string
+	// binary reports whether text needs parentheses inside another operator.
+	binary bool
+	// value is the value of an int constant.
+	value *int32
+	// str is the value of a string constant.
+	str *string
+}
+
+func (o operand) nested() string {
+	if o.binary {
+		return "(" + o.text + ")"
+	}
+	return o.text
+}
+
+// decompileState is the simulated stacks and output of one decompilation.
+type decompileState struct {
+	d       *Decompiler
+	script  *ClientScript
+	targets map[int]bool
+	labels  map[int]bool
+	gotos   map[int]bool
+
+	ints    []operand
+	strings []operand
+	// unknown reports that a command with an unknown stack effect has run, so
+	// the stacks no longer match the client's.
+	unknown bool
+
+	quiet    bool
+	out      strings.Builder
+	indent   int
+	declared map[string]bool
+	calls    int
+}
+
+func (d *Decompiler) newState(script *ClientScript) *decompileState {
+	s := &decompileState{
+		d:        d,
+		script:   script,
+		targets:  script.jumpTargets(),
+		gotos:    make(map[int]bool),
+		declared: make(map[string]bool),
+	}
+	for i := range int(script.IntArgumentCount) {
+		s.declared[fmt.Sprintf("$int%d", i)] = true
+	}
+	for i := range int(script.StringArgumentCount) {
+		s.declared[fmt.Sprintf("$string%d", i)] = true
+	}
+	return s
+}
+
+func (s *decompileState) emit(format string, args ...any) {
+	if s.quiet {
+		return
+	}
+	s.out.WriteString(strings.Repeat("    ", s.indent))
+	fmt.Fprintf(&s.out, format, args...)
+	s.out.WriteByte('\n')
+}
+
+// flushStack writes the operands left on the stacks where control flow
+// merges, which the structures of the compiler never do, and before commands
+// with an unknown stack effect.
+func (s *decompileState) flushStack() {
+	for _, o := range s.ints {
+		s.emit("push_int(%s);", o.text)
+	}
+	for _, o := range s.strings {
+		s.emit("push_string(%s);", o.text)
+	}
+	s.ints, s.strings = s.ints[:0], s.strings[:0]
+}
+
+func (s *decompileState) pushInt(o operand)    { s.ints = append(s.ints, o) }
+func (s *decompileState) pushString(o operand) { s.strings = append(s.strings, o) }
+
+func (s *decompileState) popInt() operand {
+	return s.pop(&s.ints, "<int>")
+}
+
+func (s *decompileState) popString() operand {
+	return s.pop(&s.strings, "<string>")
+}
+
+func (s *decompileState) pop(stack *[]operand, missing string) operand {
+	if n := len(*stack); n > 0 {
+		o := (*stack)[n-1]
+		*stack = (*stack)[:n-1]
+		return o
+	}
+	return operand{text: missing}
+}
+
+// popArgs pops the int and string arguments of a call, ints first.
+func (s *decompileState) popArgs(ints, strs int) []string {
+	args := make([]string, ints+strs)
+	for i := strs - 1; i >= 0; i-- {
+		args[ints+i] = s.popString().text
+	}
+	for i := ints - 1; i >= 0; i-- {
+		args[i] = s.popInt().text
+	}
+	return args
+}
+
+func (s *decompileState) empty() bool {
+	return len(s.ints) == 0 && len(s.strings) == 0
+}
+
+func (s *decompileState) assign(name, typ string, value operand) {
+	if s.declared[name] {
+		s.emit("%s = %s;", name, value.text)
+		return
+	}
+	s.declared[name] = true
+	s.emit("def_%s %s = %s;", typ, name, value.text)
+}
+
+// exec simulates an instruction that does not branch.
+func (s *decompileState) exec(pc int) {
+	ins := s.script.Instructions[pc]
+	switch ins.Opcode {
+	case OpIconst:
+		value := ins.IntOperand
+		s.pushInt(operand{text: strconv.Itoa(int(value)), value: &value})
+	case OpSconst:
+		value := ins.StringOperand
+		s.pushString(operand{text: strconv.Quote(value), str: &value})
+	case OpGetVarp:
+		s.pushInt(operand{text: fmt.Sprintf("%%varp%d", ins.IntOperand)})
+	case OpSetVarp:
+		s.emit("%%varp%d = %s;", ins.IntOperand, s.popInt().text)
+	case OpGetVarbit:
+		s.pushInt(operand{text: fmt.Sprintf("%%varbit%d", ins.IntOperand)})
+	case OpSetVarbit:
+		s.emit("%%varbit%d = %s;", ins.IntOperand, s.popInt().text)
+	case OpGetVarcInt:
+		s.pushInt(operand{text: fmt.Sprintf("%%varcint%d", ins.IntOperand)})
+	case OpSetVarcInt:
+		s.emit("%%varcint%d = %s;", ins.IntOperand, s.popInt().text)
+	case OpGetVarcString, OpGetVarcStringOld:
+		s.pushString(operand{text: fmt.Sprintf("%%varcstr%d", ins.IntOperand)})
+	case OpSetVarcString, OpSetVarcStringOld:
+		s.emit("%%varcstr%d = %s;", ins.IntOperand, s.popString().text)
+	case OpIload:
+		s.pushInt(operand{text: fmt.Sprintf("$int%d", ins.IntOperand)})
+	case OpIstore:
+		s.assign(fmt.Sprintf("$int%d", ins.IntOperand), "int", s.popInt())
+	case OpSload:
+		s.pushString(operand{text: fmt.Sprintf("$string%d", ins.IntOperand)})
+	case OpSstore:
+		s.assign(fmt.Sprintf("$string%d", ins.IntOperand), "string", s.popString())
+	case OpJoinString:
+		parts := make([]string, max(ins.IntOperand, 0))
+		for i := len(parts) - 1; i >= 0; i-- {
+			parts[i] = s.popString().text
+		}
+		s.pushString(operand{text: "join(" + strings.Join(parts, ", ") + ")"})
+	case OpPopInt:
+		s.emit("%s;", s.popInt().text)
+	case OpPopString:
+		s.emit("%s;", s.popString().text)
+	case OpDefineArray:
+		typ := ScriptVarType(ins.IntOperand & 0xFFFF)
+		s.emit("def_%s $array%d(%s);", typ, ins.IntOperand>>16, s.popInt().text)
+	case OpGetArrayInt:
+		s.pushInt(operand{text: fmt.Sprintf("$array%d(%s)", ins.IntOperand, s.popInt().text)})
+	case OpSetArrayInt:
+		value := s.popInt()
+		index := s.popInt()
+		s.emit("$array%d(%s) = %s;", ins.IntOperand, index.text, value.text)
+	case OpInvoke:
+		s.invoke(ins.IntOperand)
+	case OpEnum:
+		s.enum()
+	default:
+		s.command(ins)
+	}
+}
+
+// pure reports whether an instruction only pushes an expression, so that it
+// can be simulated while looking ahead for a compound condition.
+func (s *decompileState) pure(ins Instruction) bool {
+	switch ins.Opcode {
+	case OpIconst, OpSconst, OpGetVarp, OpGetVarbit, OpGetVarcInt, OpGetVarcString,
+		OpGetVarcStringOld, OpIload, OpSload, OpJoinString, OpGetArrayInt, OpEnum:
+		return true
+	case OpInvoke:
+		signature, ok := s.d.signature(ins.IntOperand)
+		return ok && signature.intResults+signature.stringResults == 1
+	}
+	effect, ok := commandEffects[ins.Opcode]
+	return ok && effect.intResults+effect.stringResults == 1
+}
+
+func (s *decompileState) invoke(id int32) {
+	name := fmt.Sprintf("~script%d", id)
+	signature, ok := s.d.signature(id)
+	if !ok {
+		s.unknownCall(name)
+		return
+	}
+	call := name + "(" + strings.Join(s.popArgs(signature.intArgs, signature.stringArgs), ", ") + ")"
+	s.results(call, signature.intResults, signature.stringResults)
+}
+
+// enum pushes a string or an int depending on the constant output type.
+func (s *decompileState) enum() {
+	key := s.popInt()
+	enumID := s.popInt()
+	outputType := s.popInt()
+	inputType := s.popInt()
+
+	typeName := func(o operand) string {
+		if o.value != nil {
+			return ScriptVarType(*o.value).String()
+		}
+		return o.text
+	}
+	call := operand{text: fmt.Sprintf("enum(%s, %s, %s, %s)", typeName(inputType), typeName(outputType), enumID.text, key.text)}
+	if outputType.value != nil && ScriptVarType(*outputType.value) == TypeString {
+		s.pushString(call)
+	} else {
+		s.pushInt(call)
+	}
+}
+
+func (s *decompileState) command(ins Instruction) {
+	name := OpcodeName(ins.Opcode)
+	if ins.Opcode >= 100 && ins.IntOperand == 1 {
+		// Component commands with an operand of 1 act on the secondary
+		// component, written with a leading dot.
+		name = "." + name
+	}
+
+	switch {
+	case isHook(ins.Opcode):
+		s.hook(ins.Opcode, name)
+		return
+	case paramCommands[ins.Opcode]:
+		s.param(name)
+		return
+	}
+
+	effect, ok := commandEffects[ins.Opcode]
+	if !ok {
+		s.unknownCall(name)
+		return
+	}
+
+	if op, ok := infixOperators[ins.Opcode]; ok {
+		b := s.popInt()
+		a := s.popInt()
+		s.pushInt(operand{text: a.nested() + " " + op + " " + b.nested(), binary: true})
+		return
+	}
+	call := name + "(" + strings.Join(s.popArgs(effect.ints, effect.strings), ", ") + ")"
+	s.results(call, effect.intResults, effect.stringResults)
+}
+
+// hook writes a command that sets a component's event handler. The handler
+// is a script ID followed by its arguments, typed by the signature string on
+// top of the string stack, where a trailing Y adds a count of trigger IDs
+// pushed after the arguments. The if_ commands pop the component last.
+func (s *decompileState) hook(opcode uint16, name string) {
+	n := len(s.strings)
+	if n == 0 || s.strings[n-1].str == nil {
+		s.unknownCall(name)
+		return
+	}
+	signature := *s.strings[n-1].str
+
+	componentInts := 0
+	if opcode >= 2000 {
+		componentInts = 1
+	}
+	triggerCount, hasTriggers := 0, strings.HasSuffix(signature, "Y")
+	if hasTriggers {
+		signature = strings.TrimSuffix(signature, "Y")
+		i := len(s.ints) - 1 - componentInts
+		if i < 0 || s.ints[i].value == nil || *s.ints[i].value < 0 {
+			s.unknownCall(name)
+			return
+		}
+		triggerCount = int(*s.ints[i].value)
+	}
+
+	var component operand
+	if componentInts > 0 {
+		component = s.popInt()
+	}
+	s.popString()
+
+	var triggers []string
+	if hasTriggers {
+		s.popInt()
+		triggers = make([]string, triggerCount)
+		for i := triggerCount - 1; i >= 0; i-- {
+			triggers[i] = s.popInt().text
+		}
+	}
+	args := make([]string, len(signature))
+	for i := len(signature) - 1; i >= 0; i-- {
+		if signature[i] == 's' {
+			args[i] = s.popString().text
+		} else {
+			args[i] = s.popInt().text
+		}
+	}
+
+	script := s.popInt()
+	var handler string
+	switch {
+	case script.value != nil && *script.value == -1:
+		handler = "null"
+	case script.value != nil:
+		handler = fmt.Sprintf("~script%d(%s)", *script.value, strings.Join(args, ", "))
+	default:
+		handler = fmt.Sprintf("~script(%s)(%s)", script.text, strings.Join(args, ", "))
+	}
+	if hasTriggers {
+		handler += "{" + strings.Join(triggers, ", ") + "}"
+	}
+
+	if componentInts > 0 {
+		s.emit("%s(%s, %s);", name, handler, component.text)
+	} else {
+		s.emit("%s(%s);", name, handler)
+	}
+}
+
+// param pushes the value of a param of a config, whose type is read from the
+// cache when the param ID is a constant.
+func (s *decompileState) param(name string) {
+	n := len(s.ints)
+	if n == 0 || s.ints[n-1].value == nil || *s.ints[n-1].value < 0 || *s.ints[n-1].value > 0xFFFF || s.d.cache == nil {
+		s.unknownCall(name)
+		return
+	}
+	def, err := s.d.cache.cachedParam(uint16(*s.ints[n-1].value))
+	if err != nil {
+		s.unknownCall(name)
+		return
+	}
+
+	call := operand{text: name + "(" + strings.Join(s.popArgs(2, 0), ", ") + ")"}
+	if def.IsString() {
+		s.pushString(call)
+	} else {
+		s.pushInt(call)
+	}
+}
+
+// unknownCall writes a command whose stack effect is unknown as a statement
+// with elided arguments. The operands on the stacks are written out first,
+// since the command may consume any of them, and its results are left to the
+// instructions that follow, which pop <int> or <string> placeholders.
+func (s *decompileState) unknownCall(name string) {
+	s.flushStack()
+	s.emit("%s(...);", name)
+	s.unknown = true
+}
+
+// results pushes the values returned by a call. A call returning several
+// values is assigned to a temporary first so it is only written once.
+func (s *decompileState) results(call string, ints, strs int) {
+	switch ints + strs {
+	case 0:
+		s.emit("%s;", call)
+		return
+	case 1:
+		if ints == 1 {
+			s.pushInt(operand{text: call})
+		} else {
+			s.pushString(operand{text: call})
+		}
+		return
+	}
+
+	temp := fmt.Sprintf("$call%d", s.calls)
+	s.calls++
+	s.emit("%s = %s;", temp, call)
+	for i := range ints {
+		s.pushInt(operand{text: fmt.Sprintf("%s(%d)", temp, i)})
+	}
+	for i := range strs {
+		s.pushString(operand{text: fmt.Sprintf("%s(%d)", temp, ints+i)})
+	}
+}
+
+func (s *decompileState) target(pc int) int {
+	return pc + int(s.script.Instructions[pc].IntOperand) + 1
+}
+
+func (s *decompileState) isJumpTo(pc, target int) bool {
+	return pc >= 0 && pc < len(s.script.Instructions) &&
+		s.script.Instructions[pc].Opcode == OpJump && s.target(pc) == target
+}
+
+// region writes the instructions in [start, end).
+func (s *decompileState) region(start, end int) {
+	pc, exprStart := start, start
+	for pc < end {
+		if pc != start && s.targets[pc] {
+			s.flushStack()
+			exprStart = pc
+		}
+		if s.labels[pc] {
+			s.emit("LABEL%d:", pc)
+		}
+
+		ins := s.script.Instructions[pc]
+		switch {
+		case ins.Opcode == OpReturn:
+			s.ret(pc)
+			pc++
+		case ins.Opcode == OpSwitch:
+			pc = s.switchStatement(pc, end)
+		case ins.Opcode == OpJump:
+			s.flushStack()
+			s.gotoStatement(s.target(pc))
+			pc++
+		case IsJump(ins.Opcode):
+			pc = s.conditional(pc, end, exprStart)
+		default:
+			s.exec(pc)
+			pc++
+			if !s.empty() {
+				continue
+			}
+		}
+		exprStart = pc
+	}
+	s.flushStack()
+}
+
+func (s *decompileState) gotoStatement(target int) {
+	s.gotos[target] = true
+	s.emit("goto LABEL%d;", target)
+}
+
+func (s *decompileState) ret(pc int) {
+	values := make([]string, 0, len(s.ints)+len(s.strings))
+	for _, o := range s.ints {
+		values = append(values, o.text)
+	}
+	for _, o := range s.strings {
+		values = append(values, o.text)
+	}
+	s.ints, s.strings = s.ints[:0], s.strings[:0]
+
+	switch {
+	case len(values) > 0:
+		s.emit("return(%s);", strings.Join(values, ", "))
+	case pc != len(s.script.Instructions)-1:
+		s.emit("return;")
+	}
+}
+
+// comparison pops the operands of the branch at pc and returns its condition.
+func (s *decompileState) comparison(pc int, negate bool) operand {
+	b := s.popInt()
+	a := s.popInt()
+	op := comparisons[s.script.Instructions[pc].Opcode]
+	if negate {
+		op = negatedComparisons[s.script.Instructions[pc].Opcode]
+	}
+	return operand{text: a.nested() + " " + op + " " + b.nested(), binary: true}
+}
+
+// lookahead simulates the pure instructions from start up to the next
+// conditional branch, which it returns. It fails when an instruction has side
+// effects or control flow joins on the way.
+func (s *decompileState) lookahead(start, end int) (int, bool) {
+	for pc := start; pc < end; pc++ {
+		if pc != start && s.targets[pc] {
+			return 0, false
+		}
+		ins := s.script.Instructions[pc]
+		if IsJump(ins.Opcode) && ins.Opcode != OpJump {
+			return pc, true
+		}
+		if !s.pure(ins) {
+			return 0, false
+		}
+		s.exec(pc)
+	}
+	return 0, false
+}
+
+func (s *decompileState) snapshot() func() {
+	ints, strs := slices.Clone(s.ints), slices.Clone(s.strings)
+	return func() { s.ints, s.strings = ints, strs }
+}
+
+// condition recovers the condition of the branch at pc. The compiler branches
+// over a jump to the false block when a comparison holds, chaining further
+// comparisons for && and ||. It returns the first instruction of the true
+// block and the false target.
+func (s *decompileState) condition(pc, end int) (cond operand, trueStart, falseTarget int, ok bool) {
+	target := s.target(pc)
+	switch {
+	case target == pc+2 && pc+1 < end && s.script.Instructions[pc+1].Opcode == OpJump:
+		falseTarget = s.target(pc + 1)
+		if falseTarget <= pc+1 || falseTarget > end {
+			return operand{}, 0, 0, false
+		}
+		cond = s.comparison(pc, false)
+		trueStart = pc + 2
+		for {
+			restore := s.snapshot()
+			q, ok := s.lookahead(trueStart, falseTarget)
+			if !ok || s.target(q) != q+2 || !s.isJumpTo(q+1, falseTarget) {
+				restore()
+				return cond, trueStart, falseTarget, true
+			}
+			next := s.comparison(q, false)
+			cond = operand{text: cond.nested() + " & " + next.nested(), binary: true}
+			trueStart = q + 2
+		}
+
+	case target > pc+1 && target <= end:
+		cond = s.comparison(pc, false)
+		from := pc + 1
+		for {
+			q, ok := s.lookahead(from, target)
+			if !ok || s.target(q) != target {
+				break
+			}
+			next := s.comparison(q, false)
+			cond = operand{text: cond.nested() + " | " + next.nested(), binary: true}
+			if q+2 == target && q+1 < end && s.script.Instructions[q+1].Opcode == OpJump {
+				falseTarget = s.target(q + 1)
+				if falseTarget > q+1 && falseTarget <= end {
+					return cond, target, falseTarget, true
+				}
+				break
+			}
+			from = q + 1
+		}
+	}
+	return operand{}, 0, 0, false
+}
+
+// conditional writes an if or while statement for the branch at pc, or a
+// conditional goto when it does not match a compiled structure. It returns
+// the instruction following the statement.
+func (s *decompileState) conditional(pc, end, exprStart int) int {
+	restore := s.snapshot()
+	cond, trueStart, falseTarget, ok := s.condition(pc, end)
+	if !ok {
+		restore()
+		// A branch over the true block holds when its comparison fails.
+		target := s.target(pc)
+		if target > pc+1 && target <= end {
+			cond = s.comparison(pc, true)
+			trueStart, falseTarget, ok = pc+1, target, true
+		}
+	}
+	if !ok {
+		cond = s.comparison(pc, false)
+		s.flushStack()
+		s.gotos[s.target(pc)] = true
+		s.emit("if (%s) goto LABEL%d;", cond.text, s.target(pc))
+		return pc + 1
+	}
+
+	trueEnd, elseEnd, next := falseTarget, -1, falseTarget
+	keyword := "if"
+	if last := falseTarget - 1; last >= trueStart && s.script.Instructions[last].Opcode == OpJump {
+		switch target := s.target(last); {
+		case target == exprStart && target <= pc && s.targets[target]:
+			keyword = "while"
+			trueEnd = last
+		case target == falseTarget:
+			trueEnd = last
+		case target > falseTarget && target <= end:
+			trueEnd, elseEnd, next = last, target, target
+		}
+	}
+
+	s.emit("%s (%s) {", keyword, cond.text)
+	s.indent++
+	s.region(trueStart, trueEnd)
+	s.indent--
+	if elseEnd >= 0 {
+		s.emit("} else {")
+		s.indent++
+		s.region(falseTarget, elseEnd)
+		s.indent--
+	}
+	s.emit("}")
+	return next
+}
+
+// switchStatement writes the switch at pc. The compiler places the default
+// block after the switch and ends every block with a jump past the last case.
+func (s *decompileState) switchStatement(pc, end int) int {
+	value := s.popInt()
+	ins := s.script.Instructions[pc]
+	if int(ins.IntOperand) >= len(s.script.Switches) {
+		s.emit("switch_int (%s) {", value.text)
+		s.emit("}")
+		return pc + 1
+	}
+
+	cases := make(map[int][]string)
+	for _, c := range s.script.Switches[ins.IntOperand] {
+		target := pc + int(c.Offset) + 1
+		cases[target] = append(cases[target], strconv.Itoa(int(c.Key)))
+	}
+	targets := slices.Sorted(maps.Keys(cases))
+
+	exit := -1
+	valid := len(targets) > 0 && targets[0] > pc
+	if valid {
+		// The default block and every case but the last end with a jump
+		// to the exit.
+		for _, target := range targets {
+			if last := target - 1; last > pc && s.script.Instructions[last].Opcode == OpJump && s.target(last) > last {
+				exit = s.target(last)
+				break
+			}
+		}
+		valid = exit >= targets[len(targets)-1] && exit <= end
+	}
+	if !valid {
+		s.emit("switch_int (%s) {", value.text)
+		for _, target := range targets {
+			s.gotos[target] = true
+			s.emit("    case %s : goto LABEL%d;", strings.Join(cases[target], ", "), target)
+		}
+		s.emit("}")
+		return pc + 1
+	}
+
+	s.emit("switch_int (%s) {", value.text)
+	s.indent++
+	block := func(start, end int) {
+		if s.isJumpTo(end-1, exit) {
+			end--
+		}
+		s.indent++
+		s.region(start, end)
+		s.indent--
+	}
+	for i, target := range targets {
+		s.emit("case %s :", strings.Join(cases[target], ", "))
+		caseEnd := exit
+		if i+1 < len(targets) {
+			caseEnd = targets[i+1]
+		}
+		if target < exit {
+			block(target, caseEnd)
+		}
+	}
+	if defaultEnd := targets[0]; defaultEnd > pc+1 && !(defaultEnd == pc+2 && s.isJumpTo(pc+1, exit)) {
+		s.emit("case default :")
+		block(pc+1, defaultEnd)
+	}
+	s.indent--
+	s.emit("}")
+	return exit
+}
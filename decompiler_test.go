@@ -0,0 +1,240 @@
+package osrscache
+
+import (
+	"strings"
+	"testing"
+)
+
+// branch returns a branch instruction at pc targeting target.
+func branch(opcode uint16, pc, target int) Instruction {
+	return Instruction{Opcode: opcode, IntOperand: int32(target - pc - 1)}
+}
+
+func decompile(t *testing.T, d *Decompiler, script *ClientScript) string {
+	t.Helper()
+	var b strings.Builder
+	if err := d.Decompile(&b, script); err != nil {
+		t.Fatalf("Decompile: %v", err)
+	}
+	return b.String()
+}
+
+func TestDecompile(t *testing.T) {
+	tests := []struct {
+		name   string
+		script *ClientScript
+		want   string
+	}{
+		{
+			name: "if else",
+			script: &ClientScript{
+				ID:               1,
+				IntArgumentCount: 1,
+				Instructions: []Instruction{
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: OpIconst, IntOperand: 5},
+					branch(OpIfIcmpgt, 2, 4),
+					branch(OpJump, 3, 7),
+					{Opcode: OpIconst, IntOperand: 1},
+					{Opcode: OpIstore, IntOperand: 1},
+					branch(OpJump, 6, 9),
+					{Opcode: OpIconst, IntOperand: 2},
+					{Opcode: OpIstore, IntOperand: 1},
+					{Opcode: OpIload, IntOperand: 1},
+					{Opcode: OpReturn},
+				},
+			},
+			want: `[clientscript,script1](int $int0)(int)
+if ($int0 > 5) {
+    def_int $int1 = 1;
+} else {
+    $int1 = 2;
+}
+return($int1);
+`,
+		},
+		{
+			name: "while",
+			script: &ClientScript{
+				ID: 2,
+				Instructions: []Instruction{
+					{Opcode: OpIconst, IntOperand: 0},
+					{Opcode: OpIstore, IntOperand: 0},
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: OpIconst, IntOperand: 10},
+					branch(OpIfIcmplt, 4, 6),
+					branch(OpJump, 5, 11),
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: OpIconst, IntOperand: 1},
+					{Opcode: 4000},
+					{Opcode: OpIstore, IntOperand: 0},
+					branch(OpJump, 10, 2),
+					{Opcode: OpReturn},
+				},
+			},
+			want: `[clientscript,script2]()
+def_int $int0 = 0;
+while ($int0 < 10) {
+    $int0 = $int0 + 1;
+}
+`,
+		},
+		{
+			name: "switch",
+			script: &ClientScript{
+				ID:               3,
+				IntArgumentCount: 1,
+				Instructions: []Instruction{
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: OpSwitch, IntOperand: 0},
+					{Opcode: OpIconst, IntOperand: 0},
+					{Opcode: OpIstore, IntOperand: 1},
+					branch(OpJump, 4, 10),
+					{Opcode: OpIconst, IntOperand: 10},
+					{Opcode: OpIstore, IntOperand: 1},
+					branch(OpJump, 7, 10),
+					{Opcode: OpIconst, IntOperand: 20},
+					{Opcode: OpIstore, IntOperand: 1},
+					{Opcode: OpReturn},
+				},
+				Switches: [][]SwitchCase{{{Key: 1, Offset: 3}, {Key: -1, Offset: 3}, {Key: 2, Offset: 6}}},
+			},
+			want: `[clientscript,script3](int $int0)
+switch_int ($int0) {
+    case 1, -1 :
+        def_int $int1 = 10;
+    case 2 :
+        $int1 = 20;
+    case default :
+        $int1 = 0;
+}
+`,
+		},
+		{
+			name: "calls",
+			script: &ClientScript{
+				ID:                  4,
+				IntArgumentCount:    1,
+				StringArgumentCount: 1,
+				Instructions: []Instruction{
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: OpSload, IntOperand: 0},
+					{Opcode: 4100},
+					{Opcode: OpSstore, IntOperand: 1},
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: OpInvoke, IntOperand: 9},
+					{Opcode: OpIconst, IntOperand: 2},
+					{Opcode: 4002},
+					{Opcode: OpIstore, IntOperand: 1},
+					{Opcode: OpReturn},
+				},
+			},
+			want: `[clientscript,script4](int $int0, string $string0)
+def_string $string1 = append_num($int0, $string0);
+def_int $int1 = ~script9($int0) * 2;
+`,
+		},
+		{
+			// A command without a known stack effect must not take the
+			// operands around it as arguments or results.
+			name: "unknown command",
+			script: &ClientScript{
+				ID:               5,
+				IntArgumentCount: 1,
+				Instructions: []Instruction{
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: 7999},
+					{Opcode: 4000},
+					{Opcode: OpIstore, IntOperand: 1},
+					{Opcode: OpReturn},
+				},
+			},
+			want: `[clientscript,script5](int $int0)
+push_int($int0);
+7999(...);
+def_int $int1 = <int> + <int>;
+`,
+		},
+		{
+			name: "component commands",
+			script: &ClientScript{
+				ID:               10,
+				IntArgumentCount: 1,
+				Instructions: []Instruction{
+					{Opcode: OpIconst, IntOperand: 10},
+					{Opcode: OpIconst, IntOperand: 20},
+					{Opcode: OpIconst, IntOperand: 0},
+					{Opcode: OpIconst, IntOperand: 0},
+					{Opcode: 1000}, // cc_setposition
+					{Opcode: OpSconst, StringOperand: "Hello"},
+					{Opcode: 1112, IntOperand: 1}, // cc_settext
+					{Opcode: OpIconst, IntOperand: 489},
+					{Opcode: OpIload, IntOperand: 0},
+					{Opcode: OpSconst, StringOperand: "x"},
+					{Opcode: OpSconst, StringOperand: "is"},
+					{Opcode: 1409}, // cc_setonop
+					{Opcode: OpIconst, IntOperand: 500},
+					{Opcode: OpIconst, IntOperand: 7},
+					{Opcode: OpIconst, IntOperand: 1},
+					{Opcode: OpIconst, IntOperand: 9764864},
+					{Opcode: OpSconst, StringOperand: "Y"},
+					{Opcode: 2407}, // if_setonvartransmit
+					{Opcode: OpIconst, IntOperand: -1},
+					{Opcode: OpSconst, StringOperand: ""},
+					{Opcode: 1400}, // cc_setonclick
+					{Opcode: OpIconst, IntOperand: 9764864},
+					{Opcode: 2602}, // if_gettext
+					{Opcode: OpSstore, IntOperand: 0},
+					{Opcode: 1502}, // cc_getwidth
+					{Opcode: OpIconst, IntOperand: 2},
+					{Opcode: 4003},
+					{Opcode: OpIstore, IntOperand: 1},
+					{Opcode: OpReturn},
+				},
+			},
+			want: `[clientscript,script10](int $int0)
+cc_setposition(10, 20, 0, 0);
+.cc_settext("Hello");
+cc_setonop(~script489($int0, "x"));
+if_setonvartransmit(~script500(){7}, 9764864);
+cc_setonclick(null);
+def_string $string0 = if_gettext(9764864);
+def_int $int1 = cc_getwidth() / 2;
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecompiler(nil)
+			d.signatures[9] = &scriptSignature{intArgs: 1, intResults: 1}
+			if got := decompile(t, d, tt.script); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferUnknownCommand(t *testing.T) {
+	script := &ClientScript{
+		ID: 6,
+		Instructions: []Instruction{
+			{Opcode: 7999},
+			{Opcode: OpReturn},
+		},
+	}
+	if signature := NewDecompiler(nil).infer(script); !signature.incomplete {
+		t.Errorf("infer = %+v, want incomplete results", signature)
+	}
+}
+
+func TestCommandEffectsCoverNamedCommands(t *testing.T) {
+	for opcode, name := range opcodeNames {
+		if opcode < 100 || opcode == OpEnum || isHook(opcode) || paramCommands[opcode] {
+			continue
+		}
+		if _, ok := commandEffects[opcode]; !ok {
+			t.Errorf("%s (%d) has no stack effect", name, opcode)
+		}
+	}
+}
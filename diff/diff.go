@@ -0,0 +1,345 @@
+// Package diff compares two caches, typically consecutive game updates, at the
+// reference table level and field by field for config definitions.
+package diff
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/joeychilson/osrscache"
+)
+
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+type Report struct {
+	Archives    []ArchiveDiff    `json:"archives"`
+	Definitions []DefinitionDiff `json:"definitions"`
+}
+
+type ArchiveDiff struct {
+	Archive uint8         `json:"archive"`
+	Kind    ChangeKind    `json:"kind"`
+	Groups  []GroupChange `json:"groups"`
+}
+
+type GroupChange struct {
+	Group       uint32     `json:"group"`
+	Kind        ChangeKind `json:"kind"`
+	OldChecksum int32      `json:"old_checksum"`
+	NewChecksum int32      `json:"new_checksum"`
+	OldVersion  int32      `json:"old_version"`
+	NewVersion  int32      `json:"new_version"`
+}
+
+type DefinitionDiff struct {
+	Type   string        `json:"type"`
+	ID     uint16        `json:"id"`
+	Kind   ChangeKind    `json:"kind"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+const configArchive = 2
+
+// configGroups maps the config groups that are decoded field by field to the
+// definition type they hold.
+var configGroups = map[uint32]struct {
+	name   string
+	decode func(id uint16, data []byte) (any, error)
+}{
+	6:  {"object", decoder(osrscache.NewObject)},
+	8:  {"enum", decoder(osrscache.NewEnum)},
+	9:  {"npc", decoder(osrscache.NewNPC)},
+	10: {"item", decoder(osrscache.NewItem)},
+	34: {"struct", decoder(osrscache.NewStruct)},
+}
+
+type definition interface {
+	Read(data []byte, opts ...osrscache.DecodeOption) error
+}
+
+func decoder[T definition](newDefinition func(id uint16) T) func(id uint16, data []byte) (any, error) {
+	return func(id uint16, data []byte) (any, error) {
+		def := newDefinition(id)
+		if err := def.Read(data, osrscache.WithLenientDecoding()); err != nil {
+			return nil, err
+		}
+		return def, nil
+	}
+}
+
+// Caches compares every archive of oldCache and newCache. Groups are compared by
+// reference table checksum and version, and changed config groups are decoded
+// to report which definition fields changed. Definitions are decoded leniently
+// so that opcodes added by the newer cache do not abort the comparison.
+func Caches(oldCache, newCache *osrscache.Cache) (*Report, error) {
+	oldArchives, err := oldCache.Store.ArchiveList()
+	if err != nil {
+		return nil, fmt.Errorf("listing old archives: %w", err)
+	}
+
+	newArchives, err := newCache.Store.ArchiveList()
+	if err != nil {
+		return nil, fmt.Errorf("listing new archives: %w", err)
+	}
+
+	archives := slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(oldArchives), newArchives...))))
+
+	report := &Report{}
+	for _, archiveID := range archives {
+		if archiveID == 255 {
+			continue
+		}
+
+		oldIndex, err := index(oldCache, archiveID)
+		if err != nil {
+			return nil, fmt.Errorf("reading old reference table %d: %w", archiveID, err)
+		}
+
+		newIndex, err := index(newCache, archiveID)
+		if err != nil {
+			return nil, fmt.Errorf("reading new reference table %d: %w", archiveID, err)
+		}
+
+		archiveDiff := compareIndexes(archiveID, oldIndex, newIndex)
+		if archiveDiff == nil {
+			continue
+		}
+		report.Archives = append(report.Archives, *archiveDiff)
+
+		if archiveID != configArchive {
+			continue
+		}
+		for _, group := range archiveDiff.Groups {
+			if _, ok := configGroups[group.Group]; !ok {
+				continue
+			}
+
+			defs, err := compareConfigGroup(oldCache, newCache, group)
+			if err != nil {
+				return nil, fmt.Errorf("comparing config group %d: %w", group.Group, err)
+			}
+			report.Definitions = append(report.Definitions, defs...)
+		}
+	}
+	return report, nil
+}
+
+func index(cache *osrscache.Cache, archiveID uint8) (*osrscache.Index, error) {
+	index, err := cache.Index(archiveID)
+	if errors.Is(err, osrscache.ErrNotFound) {
+		return nil, nil
+	}
+	return index, err
+}
+
+func compareIndexes(archiveID uint8, oldIndex, newIndex *osrscache.Index) *ArchiveDiff {
+	archiveDiff := &ArchiveDiff{Archive: archiveID, Kind: Changed}
+	switch {
+	case oldIndex == nil && newIndex == nil:
+		return nil
+	case oldIndex == nil:
+		archiveDiff.Kind = Added
+		oldIndex = &osrscache.Index{}
+	case newIndex == nil:
+		archiveDiff.Kind = Removed
+		newIndex = &osrscache.Index{}
+	}
+
+	oldGroups := make(map[uint32]*osrscache.Group, len(oldIndex.Groups))
+	for _, group := range oldIndex.Groups {
+		oldGroups[group.ID] = group
+	}
+
+	for _, newGroup := range newIndex.Groups {
+		oldGroup, ok := oldGroups[newGroup.ID]
+		delete(oldGroups, newGroup.ID)
+
+		switch {
+		case !ok:
+			archiveDiff.Groups = append(archiveDiff.Groups, GroupChange{
+				Group:       newGroup.ID,
+				Kind:        Added,
+				NewChecksum: newGroup.Checksum,
+				NewVersion:  newGroup.Version,
+			})
+		case oldGroup.Checksum != newGroup.Checksum || oldGroup.Version != newGroup.Version:
+			archiveDiff.Groups = append(archiveDiff.Groups, GroupChange{
+				Group:       newGroup.ID,
+				Kind:        Changed,
+				OldChecksum: oldGroup.Checksum,
+				NewChecksum: newGroup.Checksum,
+				OldVersion:  oldGroup.Version,
+				NewVersion:  newGroup.Version,
+			})
+		}
+	}
+
+	for _, oldGroup := range oldGroups {
+		archiveDiff.Groups = append(archiveDiff.Groups, GroupChange{
+			Group:       oldGroup.ID,
+			Kind:        Removed,
+			OldChecksum: oldGroup.Checksum,
+			OldVersion:  oldGroup.Version,
+		})
+	}
+
+	if len(archiveDiff.Groups) == 0 && archiveDiff.Kind == Changed {
+		return nil
+	}
+	slices.SortFunc(archiveDiff.Groups, func(a, b GroupChange) int {
+		return cmp.Compare(a.Group, b.Group)
+	})
+	return archiveDiff
+}
+
+func compareConfigGroup(oldCache, newCache *osrscache.Cache, group GroupChange) ([]DefinitionDiff, error) {
+	config := configGroups[group.Group]
+
+	var oldFiles, newFiles map[uint32][]byte
+	var err error
+	if group.Kind != Added {
+		oldFiles, err = oldCache.Files(configArchive, group.Group)
+		if err != nil {
+			return nil, fmt.Errorf("reading old files: %w", err)
+		}
+	}
+	if group.Kind != Removed {
+		newFiles, err = newCache.Files(configArchive, group.Group)
+		if err != nil {
+			return nil, fmt.Errorf("reading new files: %w", err)
+		}
+	}
+
+	ids := make([]uint32, 0, max(len(oldFiles), len(newFiles)))
+	for id := range oldFiles {
+		ids = append(ids, id)
+	}
+	for id := range newFiles {
+		if _, ok := oldFiles[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	var defs []DefinitionDiff
+	for _, id := range ids {
+		oldData, inOld := oldFiles[id]
+		newData, inNew := newFiles[id]
+
+		switch {
+		case !inOld:
+			defs = append(defs, DefinitionDiff{Type: config.name, ID: uint16(id), Kind: Added})
+		case !inNew:
+			defs = append(defs, DefinitionDiff{Type: config.name, ID: uint16(id), Kind: Removed})
+		case !bytes.Equal(oldData, newData):
+			oldDef, err := config.decode(uint16(id), oldData)
+			if err != nil {
+				return nil, fmt.Errorf("decoding old %s %d: %w", config.name, id, err)
+			}
+
+			newDef, err := config.decode(uint16(id), newData)
+			if err != nil {
+				return nil, fmt.Errorf("decoding new %s %d: %w", config.name, id, err)
+			}
+
+			fields := Fields(oldDef, newDef)
+			if len(fields) > 0 {
+				defs = append(defs, DefinitionDiff{Type: config.name, ID: uint16(id), Kind: Changed, Fields: fields})
+			}
+		}
+	}
+	return defs, nil
+}
+
+// Fields compares two definitions of the same type and returns the fields that
+// differ. Nested structs are compared field by field and named with dotted
+// paths such as "InventoryModelData.Zoom".
+func Fields(oldDef, newDef any) []FieldChange {
+	var changes []FieldChange
+	compareValues("", reflect.ValueOf(oldDef), reflect.ValueOf(newDef), &changes)
+	return changes
+}
+
+func compareValues(path string, oldValue, newValue reflect.Value, changes *[]FieldChange) {
+	for oldValue.Kind() == reflect.Pointer && newValue.Kind() == reflect.Pointer && !oldValue.IsNil() && !newValue.IsNil() {
+		oldValue, newValue = oldValue.Elem(), newValue.Elem()
+	}
+
+	if oldValue.Kind() == reflect.Struct && newValue.Type() == oldValue.Type() {
+		for i := 0; i < oldValue.NumField(); i++ {
+			field := oldValue.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			if path != "" {
+				name = path + "." + name
+			}
+			compareValues(name, oldValue.Field(i), newValue.Field(i), changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+		*changes = append(*changes, FieldChange{Field: path, Old: oldValue.Interface(), New: newValue.Interface()})
+	}
+}
+
+// WriteChangelog writes a human readable summary of the report, one change per
+// line.
+func (r *Report) WriteChangelog(w io.Writer) error {
+	var b strings.Builder
+	for _, archive := range r.Archives {
+		fmt.Fprintf(&b, "archive %d %s: %d groups\n", archive.Archive, archive.Kind, len(archive.Groups))
+		for _, group := range archive.Groups {
+			switch group.Kind {
+			case Added:
+				fmt.Fprintf(&b, "  + group %d (version %d)\n", group.Group, group.NewVersion)
+			case Removed:
+				fmt.Fprintf(&b, "  - group %d (version %d)\n", group.Group, group.OldVersion)
+			case Changed:
+				fmt.Fprintf(&b, "  ~ group %d (version %d -> %d, crc %08x -> %08x)\n",
+					group.Group, group.OldVersion, group.NewVersion, uint32(group.OldChecksum), uint32(group.NewChecksum))
+			}
+		}
+	}
+
+	for _, def := range r.Definitions {
+		if def.Kind != Changed {
+			fmt.Fprintf(&b, "%s %d %s\n", def.Type, def.ID, def.Kind)
+			continue
+		}
+		for _, field := range def.Fields {
+			fmt.Fprintf(&b, "%s %d %s %s -> %s\n", def.Type, def.ID, field.Field, formatValue(field.Old), formatValue(field.New))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", v)
+}
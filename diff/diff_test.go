@@ -0,0 +1,228 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/joeychilson/osrscache"
+)
+
+// memStore is a Store of archives of groups of files. Groups are packed and
+// wrapped in uncompressed containers, and the reference tables of archive 255
+// are built from them with each group's checksum.
+type memStore map[uint8]map[uint32]map[uint32][]byte
+
+func (s memStore) ArchiveList() ([]uint8, error) {
+	return slices.Sorted(maps.Keys(s)), nil
+}
+
+func (s memStore) ArchiveExists(archiveID uint8) bool {
+	_, ok := s[archiveID]
+	return ok
+}
+
+func (s memStore) GroupList(archiveID uint8) ([]uint32, error) {
+	return slices.Sorted(maps.Keys(s[archiveID])), nil
+}
+
+func (s memStore) GroupExists(archiveID uint8, groupID uint32) bool {
+	_, ok := s[archiveID][groupID]
+	return ok
+}
+
+func (s memStore) Read(archiveID uint8, groupID uint32) ([]byte, error) {
+	if archiveID == 255 {
+		groups, ok := s[uint8(groupID)]
+		if !ok {
+			return nil, fmt.Errorf("reference table %d: %w", groupID, osrscache.ErrNotFound)
+		}
+		return container(referenceTable(groups)), nil
+	}
+	files, ok := s[archiveID][groupID]
+	if !ok {
+		return nil, fmt.Errorf("group %d in archive %d: %w", groupID, archiveID, osrscache.ErrNotFound)
+	}
+	return container(pack(files)), nil
+}
+
+func container(data []byte) []byte {
+	out := binary.BigEndian.AppendUint32([]byte{osrscache.CompressionNone}, uint32(len(data)))
+	return append(out, data...)
+}
+
+// pack joins the files of a group in one stripe.
+func pack(files map[uint32][]byte) []byte {
+	ids := slices.Sorted(maps.Keys(files))
+	if len(ids) == 1 {
+		return files[ids[0]]
+	}
+	var data, trailer []byte
+	prev := 0
+	for _, id := range ids {
+		data = append(data, files[id]...)
+		trailer = binary.BigEndian.AppendUint32(trailer, uint32(len(files[id])-prev))
+		prev = len(files[id])
+	}
+	return append(append(data, trailer...), 1)
+}
+
+// referenceTable builds a protocol 6 reference table of version 1 groups.
+func referenceTable(groups map[uint32]map[uint32][]byte) []byte {
+	ids := slices.Sorted(maps.Keys(groups))
+	buf := []byte{6, 0, 0, 0, 1, 0}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(ids)))
+	var prev uint32
+	for _, id := range ids {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(id-prev))
+		prev = id
+	}
+	for _, id := range ids {
+		buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(container(pack(groups[id]))))
+	}
+	for range ids {
+		buf = binary.BigEndian.AppendUint32(buf, 1)
+	}
+	for _, id := range ids {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(groups[id])))
+	}
+	for _, id := range ids {
+		var prev uint32
+		for _, file := range slices.Sorted(maps.Keys(groups[id])) {
+			buf = binary.BigEndian.AppendUint16(buf, uint16(file-prev))
+			prev = file
+		}
+	}
+	return buf
+}
+
+// itemBytes encodes an item with a value, one recolour and one integer param.
+func itemBytes(value int32, recolor uint16, param uint32) []byte {
+	b := binary.BigEndian.AppendUint32([]byte{12}, uint32(value))
+	b = append(b, 40, 1, 0, 10)
+	b = binary.BigEndian.AppendUint16(b, recolor)
+	b = append(b, 249, 1, 0, 0, 0, 1)
+	b = binary.BigEndian.AppendUint32(b, param)
+	return append(b, 0)
+}
+
+func TestCaches(t *testing.T) {
+	oldStore := memStore{
+		2: {
+			6: {1: {0}},
+			10: {
+				4151: itemBytes(120001, 20, 5),
+				4152: itemBytes(1, 1, 1),
+				4153: itemBytes(2, 2, 2),
+			},
+		},
+		3: {0: {0: {1}}},
+	}
+	newStore := memStore{
+		2: {
+			8: {0: {0}},
+			10: {
+				4151: itemBytes(120002, 30, 6),
+				4152: itemBytes(1, 1, 1),
+				4154: itemBytes(3, 3, 3),
+			},
+		},
+		5: {7: {0: {1}}},
+	}
+
+	report, err := Caches(osrscache.New(oldStore), osrscache.New(newStore))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldCRC := int32(crc32.ChecksumIEEE(container(pack(oldStore[2][10]))))
+	newCRC := int32(crc32.ChecksumIEEE(container(pack(newStore[2][10]))))
+	objectCRC := int32(crc32.ChecksumIEEE(container(pack(oldStore[2][6]))))
+	enumCRC := int32(crc32.ChecksumIEEE(container(pack(newStore[2][8]))))
+	archives := []ArchiveDiff{
+		{Archive: 2, Kind: Changed, Groups: []GroupChange{
+			{Group: 6, Kind: Removed, OldChecksum: objectCRC, OldVersion: 1},
+			{Group: 8, Kind: Added, NewChecksum: enumCRC, NewVersion: 1},
+			{Group: 10, Kind: Changed, OldChecksum: oldCRC, NewChecksum: newCRC, OldVersion: 1, NewVersion: 1},
+		}},
+		{Archive: 3, Kind: Removed, Groups: []GroupChange{
+			{Group: 0, Kind: Removed, OldChecksum: int32(crc32.ChecksumIEEE(container([]byte{1}))), OldVersion: 1},
+		}},
+		{Archive: 5, Kind: Added, Groups: []GroupChange{
+			{Group: 7, Kind: Added, NewChecksum: int32(crc32.ChecksumIEEE(container([]byte{1}))), NewVersion: 1},
+		}},
+	}
+	if !reflect.DeepEqual(report.Archives, archives) {
+		t.Errorf("archives = %+v, want %+v", report.Archives, archives)
+	}
+
+	definitions := []DefinitionDiff{
+		{Type: "object", ID: 1, Kind: Removed},
+		{Type: "enum", ID: 0, Kind: Added},
+		{Type: "item", ID: 4151, Kind: Changed, Fields: []FieldChange{
+			{Field: "Value", Old: int32(120001), New: int32(120002)},
+			{Field: "Params", Old: map[uint32]any{1: uint32(5)}, New: map[uint32]any{1: uint32(6)}},
+			{Field: "InventoryModelData.RecolorTo", Old: []uint16{20}, New: []uint16{30}},
+		}},
+		{Type: "item", ID: 4153, Kind: Removed},
+		{Type: "item", ID: 4154, Kind: Added},
+	}
+	if !reflect.DeepEqual(report.Definitions, definitions) {
+		t.Errorf("definitions = %+v, want %+v", report.Definitions, definitions)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteChangelog(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(`archive 2 changed: 3 groups
+  - group 6 (version 1)
+  + group 8 (version 1)
+  ~ group 10 (version 1 -> 1, crc %08x -> %08x)
+archive 3 removed: 1 groups
+  - group 0 (version 1)
+archive 5 added: 1 groups
+  + group 7 (version 1)
+object 1 removed
+enum 0 added
+item 4151 Value 120001 -> 120002
+item 4151 Params map[1:5] -> map[1:6]
+item 4151 InventoryModelData.RecolorTo [20] -> [30]
+item 4153 removed
+item 4154 added
+`, uint32(oldCRC), uint32(newCRC))
+	if got := buf.String(); got != want {
+		t.Errorf("changelog =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFields(t *testing.T) {
+	oldItem, newItem := osrscache.NewItem(1), osrscache.NewItem(1)
+	if changes := Fields(oldItem, newItem); changes != nil {
+		t.Errorf("identical items changed %+v", changes)
+	}
+
+	newItem.Name = "Abyssal whip"
+	newItem.ActionsInventory[1] = "Wield"
+	want := []FieldChange{
+		{Field: "Name", Old: "null", New: "Abyssal whip"},
+		{Field: "ActionsInventory", Old: [5]string{4: "Drop"}, New: [5]string{1: "Wield", 4: "Drop"}},
+	}
+	if changes := Fields(oldItem, newItem); !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+
+	var buf bytes.Buffer
+	report := &Report{Definitions: []DefinitionDiff{{Type: "item", ID: 1, Kind: Changed, Fields: want[:1]}}}
+	if err := report.WriteChangelog(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "item 1 Name \"null\" -> \"Abyssal whip\"\n"; got != want {
+		t.Errorf("changelog = %q, want %q", got, want)
+	}
+}
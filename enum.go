@@ -4,14 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 )
 
 type Enum struct {
 	ID           uint16
-	KeyType      byte
-	ValueType    byte
+	KeyType      ScriptVarType
+	ValueType    ScriptVarType
 	DefaultValue any
 	Values       map[int32]any
+	Unknown      *UnknownOpcode
 }
 
 func NewEnum(id uint16) *Enum {
@@ -22,7 +24,9 @@ func NewEnum(id uint16) *Enum {
 	}
 }
 
-func (e *Enum) Read(data []byte) error {
+func (e *Enum) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
 	reader := NewReader(data)
 	for {
 		opcode, err := reader.ReadUint8()
@@ -37,15 +41,17 @@ func (e *Enum) Read(data []byte) error {
 		}
 		switch opcode {
 		case 1:
-			e.KeyType, err = reader.ReadUint8()
+			keyType, err := reader.ReadUint8()
 			if err != nil {
 				return fmt.Errorf("reading key type: %w", err)
 			}
+			e.KeyType = ScriptVarType(keyType)
 		case 2:
-			e.ValueType, err = reader.ReadUint8()
+			valueType, err := reader.ReadUint8()
 			if err != nil {
 				return fmt.Errorf("reading value type: %w", err)
 			}
+			e.ValueType = ScriptVarType(valueType)
 		case 3:
 			defaultStr, err := reader.ReadString()
 			if err != nil {
@@ -83,8 +89,83 @@ func (e *Enum) Read(data []byte) error {
 				}
 			}
 		default:
-			return fmt.Errorf("unsupported opcode: %d", opcode)
+			e.Unknown, err = options.unknownOpcode("enum", e.ID, opcode, reader)
+			return err
 		}
 	}
 	return nil
 }
+
+// Coords decodes the values of a coord enum.
+func (e *Enum) Coords() (map[int32]Coord, error) {
+	if e.ValueType != TypeCoord {
+		return nil, fmt.Errorf("enum %d has %s values, not coord", e.ID, e.ValueType)
+	}
+
+	coords := make(map[int32]Coord, len(e.Values))
+	for key, value := range e.Values {
+		packed, ok := value.(int32)
+		if !ok {
+			return nil, fmt.Errorf("enum %d key %d is not an int", e.ID, key)
+		}
+		coords[key] = NewCoord(packed)
+	}
+	return coords, nil
+}
+
+// Items resolves the values of an obj or namedobj enum to their items.
+func (e *Enum) Items(cache *Cache) (map[int32]*Item, error) {
+	return resolveEnum(e, "item", []ScriptVarType{TypeObj, TypeNamedObj}, cache.Items)
+}
+
+// NPCs resolves the values of an npc enum to their NPCs.
+func (e *Enum) NPCs(cache *Cache) (map[int32]*NPC, error) {
+	return resolveEnum(e, "npc", []ScriptVarType{TypeNPC}, cache.NPCs)
+}
+
+// Objects resolves the values of a loc enum to their objects.
+func (e *Enum) Objects(cache *Cache) (map[int32]*Object, error) {
+	return resolveEnum(e, "object", []ScriptVarType{TypeLoc}, cache.Objects)
+}
+
+// Structs resolves the values of a struct enum to their structs.
+func (e *Enum) Structs(cache *Cache) (map[int32]*Struct, error) {
+	return resolveEnum(e, "struct", []ScriptVarType{TypeStruct}, cache.Structs)
+}
+
+// Enums resolves the values of an enum of enums, such as the per-page tables
+// of the collection log.
+func (e *Enum) Enums(cache *Cache) (map[int32]*Enum, error) {
+	return resolveEnum(e, "enum", []ScriptVarType{TypeEnum}, cache.Enums)
+}
+
+// resolveEnum maps every value of e to the definition it references. Values of
+// -1 mean no definition and are skipped.
+func resolveEnum[T any](e *Enum, name string, types []ScriptVarType, load func() (map[uint16]T, error)) (map[int32]T, error) {
+	if !slices.Contains(types, e.ValueType) {
+		return nil, fmt.Errorf("enum %d has %s values, not %s", e.ID, e.ValueType, types[0])
+	}
+
+	defs, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("getting %ss: %w", name, err)
+	}
+
+	resolved := make(map[int32]T, len(e.Values))
+	for key, value := range e.Values {
+		id, ok := value.(int32)
+		if !ok {
+			return nil, fmt.Errorf("enum %d key %d is not an int", e.ID, key)
+		}
+		if id < 0 {
+			continue
+		}
+
+		def, ok := defs[uint16(id)]
+		if !ok {
+			return nil, fmt.Errorf("enum %d key %d: %s %d: %w", e.ID, key, name, id, ErrNotFound)
+		}
+		resolved[key] = def
+	}
+	return resolved, nil
+}
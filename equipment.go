@@ -0,0 +1,215 @@
+package osrscache
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// NoWearPosition marks an unset wear position on an item.
+const NoWearPosition = 0xFF
+
+type EquipmentSlot uint8
+
+const (
+	SlotHead EquipmentSlot = iota
+	SlotCape
+	SlotAmulet
+	SlotWeapon
+	SlotBody
+	SlotShield
+	SlotArms
+	SlotLegs
+	SlotHair
+	SlotHands
+	SlotFeet
+	SlotJaw
+	SlotRing
+	SlotAmmo
+)
+
+var slotNames = [...]string{
+	SlotHead:   "head",
+	SlotCape:   "cape",
+	SlotAmulet: "amulet",
+	SlotWeapon: "weapon",
+	SlotBody:   "body",
+	SlotShield: "shield",
+	SlotArms:   "arms",
+	SlotLegs:   "legs",
+	SlotHair:   "hair",
+	SlotHands:  "hands",
+	SlotFeet:   "feet",
+	SlotJaw:    "jaw",
+	SlotRing:   "ring",
+	SlotAmmo:   "ammo",
+}
+
+func (s EquipmentSlot) String() string {
+	if int(s) < len(slotNames) {
+		return slotNames[s]
+	}
+	return fmt.Sprintf("slot(%d)", uint8(s))
+}
+
+func (s EquipmentSlot) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// EquipmentStats holds the bonuses of an equipable item. The weapon category
+// is not part of the cache; see the note on the param ID constants.
+type EquipmentStats struct {
+	ID             uint16        `json:"id"`
+	Name           string        `json:"name"`
+	Slot           EquipmentSlot `json:"slot"`
+	TwoHanded      bool          `json:"two_handed"`
+	StabAttack     int32         `json:"stab_attack"`
+	SlashAttack    int32         `json:"slash_attack"`
+	CrushAttack    int32         `json:"crush_attack"`
+	MagicAttack    int32         `json:"magic_attack"`
+	RangedAttack   int32         `json:"ranged_attack"`
+	StabDefence    int32         `json:"stab_defence"`
+	SlashDefence   int32         `json:"slash_defence"`
+	CrushDefence   int32         `json:"crush_defence"`
+	MagicDefence   int32         `json:"magic_defence"`
+	RangedDefence  int32         `json:"ranged_defence"`
+	MeleeStrength  int32         `json:"melee_strength"`
+	RangedStrength int32         `json:"ranged_strength"`
+	MagicDamage    int32         `json:"magic_damage"`
+	Prayer         int32         `json:"prayer"`
+	AttackSpeed    int32         `json:"attack_speed"`
+}
+
+// IsEquipable reports whether the item can be worn.
+func (item *Item) IsEquipable() bool {
+	return item.WearPositionPrimary != NoWearPosition
+}
+
+// EquipmentStats returns the item's equipment bonuses. Bonuses the item does
+// not set fall back to their param defaults.
+func (item *Item) EquipmentStats(cache *Cache) (*EquipmentStats, error) {
+	if !item.IsEquipable() {
+		return nil, fmt.Errorf("item %d is not equipable", item.ID)
+	}
+
+	stats := &EquipmentStats{
+		ID:   item.ID,
+		Name: item.Name,
+		Slot: EquipmentSlot(item.WearPositionPrimary),
+		// Two-handed weapons also occupy the shield slot.
+		TwoHanded: item.WearPositionPrimary == uint8(SlotWeapon) && item.WearPositionSecondary == uint8(SlotShield),
+	}
+
+	fields := []struct {
+		param uint32
+		value *int32
+	}{
+		{ParamStabAttack, &stats.StabAttack},
+		{ParamSlashAttack, &stats.SlashAttack},
+		{ParamCrushAttack, &stats.CrushAttack},
+		{ParamMagicAttack, &stats.MagicAttack},
+		{ParamRangedAttack, &stats.RangedAttack},
+		{ParamStabDefence, &stats.StabDefence},
+		{ParamSlashDefence, &stats.SlashDefence},
+		{ParamCrushDefence, &stats.CrushDefence},
+		{ParamMagicDefence, &stats.MagicDefence},
+		{ParamRangedDefence, &stats.RangedDefence},
+		{ParamMeleeStrength, &stats.MeleeStrength},
+		{ParamRangedStrength, &stats.RangedStrength},
+		{ParamMagicDamage, &stats.MagicDamage},
+		{ParamPrayer, &stats.Prayer},
+		{ParamAttackSpeed, &stats.AttackSpeed},
+	}
+	for _, field := range fields {
+		value, err := item.ParamInt(cache, field.param)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", item.ID, err)
+		}
+		*field.value = value
+	}
+	return stats, nil
+}
+
+// Equipment returns the equipment stats of every equipable item.
+func (c *Cache) Equipment() (map[uint16]*EquipmentStats, error) {
+	items, err := c.Items()
+	if err != nil {
+		return nil, fmt.Errorf("getting items: %w", err)
+	}
+
+	equipment := make(map[uint16]*EquipmentStats)
+	for id, item := range items {
+		if !item.IsEquipable() {
+			continue
+		}
+		stats, err := item.EquipmentStats(c)
+		if err != nil {
+			return nil, err
+		}
+		equipment[id] = stats
+	}
+	return equipment, nil
+}
+
+func (c *Cache) ExportEquipment(outputDir string, mode JSONExportMode) error {
+	equipment, err := c.Equipment()
+	if err != nil {
+		return fmt.Errorf("getting equipment: %w", err)
+	}
+	return NewJSONExporter(equipment, outputDir).ExportToJSON(mode, "equipment")
+}
+
+// ExportEquipmentCSV writes the equipment stats of every equipable item to
+// equipment.csv in outputDir, one row per item in ascending ID order.
+func (c *Cache) ExportEquipmentCSV(outputDir string) error {
+	equipment, err := c.Equipment()
+	if err != nil {
+		return fmt.Errorf("getting equipment: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(outputDir, "equipment.csv"))
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{
+		"id", "name", "slot", "two_handed",
+		"stab_attack", "slash_attack", "crush_attack", "magic_attack", "ranged_attack",
+		"stab_defence", "slash_defence", "crush_defence", "magic_defence", "ranged_defence",
+		"melee_strength", "ranged_strength", "magic_damage", "prayer", "attack_speed",
+	})
+
+	rows := slices.SortedFunc(maps.Values(equipment), func(a, b *EquipmentStats) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	for _, stats := range rows {
+		record := []string{
+			strconv.Itoa(int(stats.ID)), stats.Name, stats.Slot.String(), strconv.FormatBool(stats.TwoHanded),
+		}
+		for _, value := range []int32{
+			stats.StabAttack, stats.SlashAttack, stats.CrushAttack, stats.MagicAttack, stats.RangedAttack,
+			stats.StabDefence, stats.SlashDefence, stats.CrushDefence, stats.MagicDefence, stats.RangedDefence,
+			stats.MeleeStrength, stats.RangedStrength, stats.MagicDamage, stats.Prayer, stats.AttackSpeed,
+		} {
+			record = append(record, strconv.Itoa(int(value)))
+		}
+		w.Write(record)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,48 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned, possibly wrapped, when an archive, group, file or
+// definition does not exist in the cache.
+var ErrNotFound = errors.New("not found")
+
+// CorruptGroupError reports a group whose stored data could not be read or
+// unpacked.
+type CorruptGroupError struct {
+	Archive uint8
+	Group   uint32
+	Reason  string
+}
+
+func (e *CorruptGroupError) Error() string {
+	return fmt.Sprintf("corrupt group %d in archive %d: %s", e.Group, e.Archive, e.Reason)
+}
+
+// UnknownOpcodeError reports an opcode a definition decoder does not
+// understand, e.g. one added by a newer game update.
+type UnknownOpcodeError struct {
+	Type   string
+	ID     uint16
+	Opcode uint8
+}
+
+func (e *UnknownOpcodeError) Error() string {
+	return fmt.Sprintf("unknown opcode %d in %s %d", e.Opcode, e.Type, e.ID)
+}
+
+// DecompressionError reports a container that could not be decompressed.
+type DecompressionError struct {
+	Compression uint8
+	Err         error
+}
+
+func (e *DecompressionError) Error() string {
+	return fmt.Sprintf("decompressing data (compression %d): %v", e.Compression, e.Err)
+}
+
+func (e *DecompressionError) Unwrap() error {
+	return e.Err
+}
@@ -112,3 +112,45 @@ func (e *ImageExporter[K, V]) ExportToImage(prefix string) error {
 	}
 	return nil
 }
+
+type AudioExportable interface {
+	Audio() *Audio
+}
+
+type AudioExporter[K comparable, V AudioExportable] struct {
+	definitions map[K]V
+	outputDir   string
+}
+
+func NewAudioExporter[K comparable, V AudioExportable](definitions map[K]V, outputDir string) *AudioExporter[K, V] {
+	return &AudioExporter[K, V]{
+		definitions: definitions,
+		outputDir:   outputDir,
+	}
+}
+
+func (e *AudioExporter[K, V]) ExportToWAV(prefix string) error {
+	if err := os.MkdirAll(e.outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	for id, def := range e.definitions {
+		filename := fmt.Sprintf("%s_%v.wav", prefix, id)
+		if err := e.writeWAV(filepath.Join(e.outputDir, filename), def.Audio()); err != nil {
+			return fmt.Errorf("writing %s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
+func (e *AudioExporter[K, V]) writeWAV(path string, audio *Audio) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := audio.WriteWAV(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,470 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+)
+
+// Names of common fonts. A font's metrics in archive 13 and its glyph sprites
+// in archive 8 share the same group ID and name.
+const (
+	FontPlain11 = "p11_full"
+	FontPlain12 = "p12_full"
+	FontBold12  = "b12_full"
+	FontQuill8  = "quill8"
+
+	// ModIconsSprite is the sprite group drawn for <img=n> tags.
+	ModIconsSprite = "mod_icons"
+)
+
+// NoShadow disables the shadow when drawing text.
+const NoShadow = -1
+
+type Font struct {
+	ID         uint16     `json:"id"`
+	Advances   [256]uint8 `json:"advances"`
+	Ascent     int        `json:"ascent"`
+	MaxAscent  int        `json:"max_ascent"`
+	MaxDescent int        `json:"max_descent"`
+	// Kerning holds the offset applied between two glyphs at
+	// Kerning[first<<8|second]. Fonts without kerning data leave it nil.
+	Kerning  []int8        `json:"-"`
+	Glyphs   [256]Glyph    `json:"glyphs"`
+	ModIcons []*image.RGBA `json:"-"`
+}
+
+type Glyph struct {
+	OffsetX int          `json:"offset_x"`
+	OffsetY int          `json:"offset_y"`
+	Width   int          `json:"width"`
+	Height  int          `json:"height"`
+	Mask    *image.Alpha `json:"-"`
+}
+
+func NewFont(id uint16) *Font {
+	return &Font{ID: id}
+}
+
+// ReadMetrics decodes the glyph advances, ascent and kerning of a font from
+// archive 13. Metrics are either 256 advances followed by the ascent, or the
+// advances followed by per-glyph row profiles from which kerning is derived.
+func (f *Font) ReadMetrics(data []byte) error {
+	reader := NewReader(data)
+
+	for i := range f.Advances {
+		advance, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading advance: %w", err)
+		}
+		f.Advances[i] = advance
+	}
+
+	if len(data) == 257 {
+		ascent, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading ascent: %w", err)
+		}
+		f.Ascent = int(ascent)
+		return nil
+	}
+
+	var heights, tops [256]int
+	for i := range heights {
+		height, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading glyph height: %w", err)
+		}
+		heights[i] = int(height)
+	}
+	for i := range tops {
+		top, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading glyph top: %w", err)
+		}
+		tops[i] = int(top)
+	}
+
+	// Each glyph's left and right edges are stored per row as running sums.
+	readProfiles := func() ([256][]int, error) {
+		var profiles [256][]int
+		for i := range profiles {
+			profiles[i] = make([]int, heights[i])
+			var sum int8
+			for row := range profiles[i] {
+				delta, err := reader.ReadInt8()
+				if err != nil {
+					return profiles, err
+				}
+				sum += delta
+				profiles[i][row] = int(sum)
+			}
+		}
+		return profiles, nil
+	}
+	left, err := readProfiles()
+	if err != nil {
+		return fmt.Errorf("reading left profiles: %w", err)
+	}
+	right, err := readProfiles()
+	if err != nil {
+		return fmt.Errorf("reading right profiles: %w", err)
+	}
+
+	f.Kerning = make([]int8, 65536)
+	for first := range 256 {
+		if first == ' ' || first == 0xA0 {
+			continue
+		}
+		for second := range 256 {
+			if second == ' ' || second == 0xA0 {
+				continue
+			}
+
+			// The glyphs may move together by the smallest gap between the
+			// first's right edge and the second's left edge over the rows
+			// they share, bounded by the narrower advance.
+			gap := int(min(f.Advances[first], f.Advances[second]))
+			from := max(tops[first], tops[second])
+			to := min(tops[first]+heights[first], tops[second]+heights[second])
+			for row := from; row < to; row++ {
+				gap = min(gap, right[first][row-tops[first]]+left[second][row-tops[second]])
+			}
+			f.Kerning[first<<8|second] = int8(-gap)
+		}
+	}
+	f.Ascent = tops[' '] + heights[' ']
+	return nil
+}
+
+// SetGlyphs takes the glyph images from a font's sprite group, which holds one
+// frame per character.
+func (f *Font) SetGlyphs(sprite *Sprite) error {
+	if len(sprite.Frames) < len(f.Glyphs) {
+		return fmt.Errorf("font sprite has %d frames, want %d", len(sprite.Frames), len(f.Glyphs))
+	}
+
+	top, bottom := int(^uint(0)>>1), 0
+	for i := range f.Glyphs {
+		frame := sprite.Frames[i]
+		glyph := Glyph{
+			OffsetX: int(frame.OffsetX),
+			OffsetY: int(frame.OffsetY),
+			Width:   int(frame.MaxWidth),
+			Height:  int(frame.MaxHeight),
+			Mask:    image.NewAlpha(image.Rect(0, 0, int(frame.MaxWidth), int(frame.MaxHeight))),
+		}
+		for index, paletteIndex := range frame.Pixels {
+			if paletteIndex != 0 {
+				glyph.Mask.Pix[index] = 0xFF
+			}
+		}
+		f.Glyphs[i] = glyph
+
+		if glyph.Height != 0 {
+			top = min(top, glyph.OffsetY)
+		}
+		bottom = max(bottom, glyph.OffsetY+glyph.Height)
+	}
+
+	f.MaxAscent = f.Ascent - top
+	f.MaxDescent = bottom - f.Ascent
+	return nil
+}
+
+func (f *Font) kern(prev int, next byte) int {
+	if f.Kerning == nil || prev < 0 {
+		return 0
+	}
+	return int(f.Kerning[prev<<8|int(next)])
+}
+
+// TextWidth returns the width of a line of text in pixels. Text is encoded in
+// cp1252 like the strings read from the cache, and tags take no space apart
+// from <img=n>, <lt> and <gt>.
+func (f *Font) TextWidth(text string) int {
+	width, prev := 0, -1
+	f.walk(text, func(c byte) {
+		width += f.kern(prev, c) + int(f.Advances[c])
+		prev = int(c)
+	}, func(tag string) {
+		if icon := f.modIcon(tag); icon != nil {
+			width += icon.Bounds().Dx()
+		}
+		prev = -1
+	})
+	return width
+}
+
+// DrawText draws a line of text with its baseline at y and returns the x
+// coordinate after the last glyph. <col=rrggbb> and <shad=rrggbb> change the
+// text and shadow colors until </col> and </shad>, <shad> turns on a black
+// shadow and <img=n> draws mod icon n. Pass NoShadow to draw without a shadow.
+func (f *Font) DrawText(dst *image.RGBA, text string, x, y int, textColor, shadowColor int32) int {
+	current, shadow := textColor, shadowColor
+	prev := -1
+	f.walk(text, func(c byte) {
+		x += f.kern(prev, c)
+		glyph := f.Glyphs[c]
+		if glyph.Mask != nil {
+			at := image.Pt(x+glyph.OffsetX, y-f.Ascent+glyph.OffsetY)
+			if shadow != NoShadow {
+				drawGlyph(dst, glyph, at.Add(image.Pt(1, 1)), shadow)
+			}
+			drawGlyph(dst, glyph, at, current)
+		}
+		x += int(f.Advances[c])
+		prev = int(c)
+	}, func(tag string) {
+		prev = -1
+		switch {
+		case strings.HasPrefix(tag, "col="):
+			current = parseTagColor(tag[4:], current)
+		case tag == "/col":
+			current = textColor
+		case strings.HasPrefix(tag, "shad="):
+			shadow = parseTagColor(tag[5:], shadow)
+		case tag == "shad":
+			shadow = 0
+		case tag == "/shad":
+			shadow = shadowColor
+		default:
+			if icon := f.modIcon(tag); icon != nil {
+				at := image.Pt(x, y-icon.Bounds().Dy())
+				draw.Draw(dst, icon.Bounds().Add(at), icon, image.Point{}, draw.Over)
+				x += icon.Bounds().Dx()
+			}
+		}
+	})
+	return x
+}
+
+// DrawLines draws text wrapped to the width of bounds and aligned within it
+// the way the client aligns text components. xAlign and yAlign are 0 for
+// left/top, 1 for centre and 2 for right/bottom; a yAlign of 3 spreads the
+// lines over the height. A lineHeight of 0 uses the font's ascent.
+func (f *Font) DrawLines(dst *image.RGBA, text string, bounds image.Rectangle, textColor, shadowColor int32, xAlign, yAlign, lineHeight int) {
+	if lineHeight == 0 {
+		lineHeight = f.Ascent
+	}
+
+	lines := f.Lines(text, bounds.Dx())
+	if yAlign == 3 && len(lines) == 1 {
+		yAlign = 1
+	}
+
+	extra := lineHeight * (len(lines) - 1)
+	y := bounds.Min.Y
+	switch yAlign {
+	case 0:
+		y += f.MaxAscent
+	case 1:
+		y += (bounds.Dy()-f.MaxAscent-f.MaxDescent-extra)/2 + f.MaxAscent
+	case 2:
+		y += bounds.Dy() - f.MaxDescent - extra
+	default:
+		spacing := max((bounds.Dy()-f.MaxAscent-f.MaxDescent-extra)/(len(lines)+1), 0)
+		y += spacing + f.MaxAscent
+		lineHeight += spacing
+	}
+
+	for _, line := range lines {
+		x := bounds.Min.X
+		switch xAlign {
+		case 1:
+			x += (bounds.Dx() - f.TextWidth(line)) / 2
+		case 2:
+			x += bounds.Dx() - f.TextWidth(line)
+		}
+		f.DrawText(dst, line, x, y, textColor, shadowColor)
+		y += lineHeight
+	}
+}
+
+// Lines splits text at <br> tags and wraps it at spaces so that no line is
+// wider than width. A width of 0 or less only splits at <br>.
+func (f *Font) Lines(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "<br>") {
+		if width <= 0 {
+			lines = append(lines, paragraph)
+			continue
+		}
+
+		line := ""
+		for i, word := range strings.Split(paragraph, " ") {
+			switch {
+			case i == 0:
+				line = word
+			case f.TextWidth(line+" "+word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// walk calls glyph for every character of text and tag for every tag other
+// than <lt> and <gt>, which are passed to glyph as the characters they escape.
+func (f *Font) walk(text string, glyph func(c byte), tag func(tag string)) {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '<' {
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				switch name := text[i+1 : i+end]; name {
+				case "lt":
+					glyph('<')
+				case "gt":
+					glyph('>')
+				default:
+					tag(name)
+				}
+				i += end
+				continue
+			}
+		}
+		glyph(text[i])
+	}
+}
+
+func (f *Font) modIcon(tag string) *image.RGBA {
+	index, ok := strings.CutPrefix(tag, "img=")
+	if !ok {
+		return nil
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(f.ModIcons) {
+		return nil
+	}
+	return f.ModIcons[i]
+}
+
+func parseTagColor(value string, fallback int32) int32 {
+	c, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return fallback
+	}
+	return int32(c)
+}
+
+func drawGlyph(dst *image.RGBA, glyph Glyph, at image.Point, c int32) {
+	src := image.NewUniform(color.RGBA{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 255})
+	draw.DrawMask(dst, glyph.Mask.Bounds().Add(at), src, image.Point{}, glyph.Mask, image.Point{}, draw.Over)
+}
+
+// FontTextDrawer draws text components with the fonts in the cache.
+type FontTextDrawer struct {
+	cache *Cache
+	fonts map[int32]*Font
+}
+
+func NewFontTextDrawer(cache *Cache) *FontTextDrawer {
+	return &FontTextDrawer{cache: cache, fonts: make(map[int32]*Font)}
+}
+
+func (d *FontTextDrawer) DrawWidgetText(dst *image.RGBA, widget *Widget, bounds image.Rectangle) error {
+	if widget.FontID < 0 || widget.Text == "" {
+		return nil
+	}
+
+	font, ok := d.fonts[widget.FontID]
+	if !ok {
+		var err error
+		font, err = d.cache.Font(uint16(widget.FontID))
+		if err != nil {
+			return fmt.Errorf("getting font %d: %w", widget.FontID, err)
+		}
+		d.fonts[widget.FontID] = font
+	}
+
+	shadow := int32(NoShadow)
+	if widget.TextShadowed {
+		shadow = 0
+	}
+	font.DrawLines(dst, widget.Text, bounds, widget.TextColor, shadow,
+		int(widget.XTextAlignment), int(widget.YTextAlignment), int(widget.LineHeight))
+	return nil
+}
+
+// Font decodes font id from its metrics in archive 13 and its glyphs in
+// archive 8, along with the mod icons its text can reference.
+func (c *Cache) Font(id uint16) (*Font, error) {
+	files, err := c.Files(13, uint32(id))
+	if err != nil {
+		return nil, fmt.Errorf("getting font metrics: %w", err)
+	}
+
+	data, ok := files[0]
+	if !ok {
+		return nil, fmt.Errorf("font %d: %w", id, ErrNotFound)
+	}
+
+	font := NewFont(id)
+	if err := font.ReadMetrics(data); err != nil {
+		return nil, fmt.Errorf("reading font metrics: %w", err)
+	}
+
+	sprite, err := c.Sprite(id)
+	if err != nil {
+		return nil, fmt.Errorf("getting font sprite: %w", err)
+	}
+	if err := font.SetGlyphs(sprite); err != nil {
+		return nil, fmt.Errorf("reading font glyphs: %w", err)
+	}
+
+	font.ModIcons, err = c.modIcons()
+	if err != nil {
+		return nil, err
+	}
+	return font, nil
+}
+
+// FontByName decodes the font whose group in archive 13 is named name, such as
+// FontPlain12.
+func (c *Cache) FontByName(name string) (*Font, error) {
+	index, err := c.Index(13)
+	if err != nil {
+		return nil, fmt.Errorf("getting font index: %w", err)
+	}
+
+	group, err := index.GroupByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Font(uint16(group.ID))
+}
+
+// modIcons returns the frames of the mod icons sprite, or nil when the cache
+// has none.
+func (c *Cache) modIcons() ([]*image.RGBA, error) {
+	index, err := c.Index(8)
+	if err != nil {
+		return nil, fmt.Errorf("getting sprite index: %w", err)
+	}
+
+	group, err := index.GroupByName(ModIconsSprite)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sprite, err := c.Sprite(uint16(group.ID))
+	if err != nil {
+		return nil, fmt.Errorf("getting mod icons: %w", err)
+	}
+
+	icons := make([]*image.RGBA, len(sprite.Frames))
+	for i := range icons {
+		icons[i] = sprite.FrameImage(i)
+	}
+	return icons, nil
+}
@@ -1,3 +1,3 @@
 module github.com/joeychilson/osrscache
 
-go 1.22.4
+go 1.23.0
@@ -0,0 +1,122 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// HealthBar is the style of a bar drawn above an entity, such as the green and
+// red hitpoints bar or a boss's shield bar.
+type HealthBar struct {
+	ID           uint16 `json:"id"`
+	ShowPriority uint8  `json:"show_priority"`
+	HidePriority uint8  `json:"hide_priority"`
+	// FadeIn and StickTime are in client cycles of 20ms: the bar fades in over
+	// FadeIn cycles and stays for StickTime cycles after its last update.
+	FadeIn    uint16 `json:"fade_in"`
+	StickTime uint8  `json:"stick_time"`
+	// FadeOut is the cycle the bar starts fading out at, or -1 if it
+	// disappears at once.
+	FadeOut int32 `json:"fade_out"`
+	// FrontSpriteID and BackSpriteID are the sprites of the filled and empty
+	// parts of the bar, or -1 for the client's plain green and red.
+	FrontSpriteID int32 `json:"front_sprite_id"`
+	BackSpriteID  int32 `json:"back_sprite_id"`
+	// Width is the width of the bar in pixels, which is also the number of
+	// steps its health is shown in.
+	Width   uint8          `json:"width"`
+	Padding uint8          `json:"padding"`
+	Unknown *UnknownOpcode `json:"unknown,omitempty"`
+}
+
+func NewHealthBar(id uint16) *HealthBar {
+	return &HealthBar{
+		ID:            id,
+		ShowPriority:  255,
+		HidePriority:  255,
+		FadeIn:        1,
+		StickTime:     70,
+		FadeOut:       -1,
+		FrontSpriteID: -1,
+		BackSpriteID:  -1,
+		Width:         30,
+	}
+}
+
+func (h *HealthBar) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
+	reader := NewReader(data)
+	for {
+		opcode, err := reader.ReadUint8()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading opcode: %w", err)
+		}
+		if opcode == 0 {
+			break
+		}
+		switch opcode {
+		case 1:
+			// The client reads and ignores a short.
+			if _, err := reader.ReadUint16(); err != nil {
+				return fmt.Errorf("reading unused value: %w", err)
+			}
+		case 2:
+			h.ShowPriority, err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading show priority: %w", err)
+			}
+		case 3:
+			h.HidePriority, err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading hide priority: %w", err)
+			}
+		case 4:
+			h.FadeOut = 0
+		case 5:
+			h.FadeIn, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading fade in: %w", err)
+			}
+		case 6:
+			h.StickTime, err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading stick time: %w", err)
+			}
+		case 7:
+			h.FrontSpriteID, err = reader.ReadBigSmart2()
+			if err != nil {
+				return fmt.Errorf("reading front sprite id: %w", err)
+			}
+		case 8:
+			h.BackSpriteID, err = reader.ReadBigSmart2()
+			if err != nil {
+				return fmt.Errorf("reading back sprite id: %w", err)
+			}
+		case 11:
+			fadeOut, err := reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading fade out: %w", err)
+			}
+			h.FadeOut = int32(fadeOut)
+		case 14:
+			h.Width, err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading width: %w", err)
+			}
+		case 15:
+			h.Padding, err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading padding: %w", err)
+			}
+		default:
+			h.Unknown, err = options.unknownOpcode("healthbar", h.ID, opcode, reader)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Hitsplat is the style of a splat drawn over an entity when it is hit, such as
+// a red damage splat or a blue miss.
+type Hitsplat struct {
+	ID uint16 `json:"id"`
+	// FontID is the font the damage is written in, or -1 for no text.
+	FontID    int32  `json:"font_id"`
+	TextColor uint32 `json:"text_color"`
+	// IconSpriteID, LeftSpriteID, MiddleSpriteID and RightSpriteID make up the
+	// splat from left to right, with the middle tiled under the text. Unused
+	// sprites are -1.
+	IconSpriteID   int32 `json:"icon_sprite_id"`
+	LeftSpriteID   int32 `json:"left_sprite_id"`
+	MiddleSpriteID int32 `json:"middle_sprite_id"`
+	RightSpriteID  int32 `json:"right_sprite_id"`
+	// ScrollOffsetX and ScrollOffsetY are how far the splat drifts while it is
+	// shown.
+	ScrollOffsetX int16 `json:"scroll_offset_x"`
+	ScrollOffsetY int16 `json:"scroll_offset_y"`
+	// Format is the text drawn on the splat, with %1 replaced by the damage.
+	Format string `json:"format"`
+	// Duration is how long the splat is shown in client cycles of 20ms, and
+	// FadeStart the cycle it starts fading out at, or -1 if it never fades.
+	Duration    uint16 `json:"duration"`
+	FadeStart   int32  `json:"fade_start"`
+	ReplaceMode int32  `json:"replace_mode"`
+	TextOffsetY int16  `json:"text_offset_y"`
+	// VarbitID or VarpIndex selects the hitsplat in Configs to show instead,
+	// math.MaxUint16 where unused.
+	VarbitID  uint16         `json:"varbit_id"`
+	VarpIndex uint16         `json:"varp_index"`
+	Configs   []uint16       `json:"configs"`
+	Unknown   *UnknownOpcode `json:"unknown,omitempty"`
+}
+
+func NewHitsplat(id uint16) *Hitsplat {
+	return &Hitsplat{
+		ID:             id,
+		FontID:         -1,
+		TextColor:      0xFFFFFF,
+		IconSpriteID:   -1,
+		LeftSpriteID:   -1,
+		MiddleSpriteID: -1,
+		RightSpriteID:  -1,
+		Duration:       70,
+		FadeStart:      -1,
+		ReplaceMode:    -1,
+		VarbitID:       math.MaxUint16,
+		VarpIndex:      math.MaxUint16,
+	}
+}
+
+func (h *Hitsplat) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
+	reader := NewReader(data)
+	for {
+		opcode, err := reader.ReadUint8()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading opcode: %w", err)
+		}
+		if opcode == 0 {
+			break
+		}
+		switch opcode {
+		case 1:
+			h.FontID, err = reader.ReadBigSmart2()
+			if err != nil {
+				return fmt.Errorf("reading font id: %w", err)
+			}
+		case 2:
+			h.TextColor, err = reader.ReadUint24()
+			if err != nil {
+				return fmt.Errorf("reading text color: %w", err)
+			}
+		case 3:
+			h.IconSpriteID, err = reader.ReadBigSmart2()
+			if err != nil {
+				return fmt.Errorf("reading icon sprite id: %w", err)
+			}
+		case 4:
+			h.LeftSpriteID, err = reader.ReadBigSmart2()
+			if err != nil {
+				return fmt.Errorf("reading left sprite id: %w", err)
+			}
+		case 5:
+			h.MiddleSpriteID, err = reader.ReadBigSmart2()
+			if err != nil {
+				return fmt.Errorf("reading middle sprite id: %w", err)
+			}
+		case 6:
+			h.RightSpriteID, err = reader.ReadBigSmart2()
+			if err != nil {
+				return fmt.Errorf("reading right sprite id: %w", err)
+			}
+		case 7:
+			h.ScrollOffsetX, err = reader.ReadInt16()
+			if err != nil {
+				return fmt.Errorf("reading scroll offset x: %w", err)
+			}
+		case 8:
+			// The format is preceded by a zero byte.
+			if _, err := reader.ReadUint8(); err != nil {
+				return fmt.Errorf("reading format prefix: %w", err)
+			}
+			h.Format, err = reader.ReadString()
+			if err != nil {
+				return fmt.Errorf("reading format: %w", err)
+			}
+		case 9:
+			h.Duration, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading duration: %w", err)
+			}
+		case 10:
+			h.ScrollOffsetY, err = reader.ReadInt16()
+			if err != nil {
+				return fmt.Errorf("reading scroll offset y: %w", err)
+			}
+		case 11:
+			h.FadeStart = 0
+		case 12:
+			mode, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading replace mode: %w", err)
+			}
+			h.ReplaceMode = int32(mode)
+		case 13:
+			h.TextOffsetY, err = reader.ReadInt16()
+			if err != nil {
+				return fmt.Errorf("reading text offset y: %w", err)
+			}
+		case 14:
+			fadeStart, err := reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading fade start: %w", err)
+			}
+			h.FadeStart = int32(fadeStart)
+		case 17, 18:
+			h.VarbitID, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading varbit id: %w", err)
+			}
+			h.VarpIndex, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading varp index: %w", err)
+			}
+			fallback := uint16(math.MaxUint16)
+			if opcode == 18 {
+				fallback, err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading fallback config: %w", err)
+				}
+			}
+			length, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading config length: %w", err)
+			}
+			h.Configs = make([]uint16, int(length)+2)
+			for i := 0; i <= int(length); i++ {
+				h.Configs[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading config at index %d: %w", i, err)
+				}
+			}
+			h.Configs[length+1] = fallback
+		default:
+			h.Unknown, err = options.unknownOpcode("hitsplat", h.ID, opcode, reader)
+			return err
+		}
+	}
+	return nil
+}
+
+// Text returns the text drawn on the splat for an amount of damage.
+func (h *Hitsplat) Text(damage int) string {
+	return strings.ReplaceAll(h.Format, "%1", strconv.Itoa(damage))
+}
+
+// HitsplatRenderer draws hitsplats with the cache's sprites and fonts, caching
+// both between splats.
+type HitsplatRenderer struct {
+	cache   *Cache
+	sprites map[int32]*image.RGBA
+	fonts   map[int32]*Font
+}
+
+func NewHitsplatRenderer(cache *Cache) *HitsplatRenderer {
+	return &HitsplatRenderer{
+		cache:   cache,
+		sprites: make(map[int32]*image.RGBA),
+		fonts:   make(map[int32]*Font),
+	}
+}
+
+// Render draws hitsplat id showing damage. The sprites are centred on a
+// common line, and the text is centred over the middle sprite, which is
+// tiled to fit it. Varbit transforms are not followed, since their values
+// come from the server; pass the transformed ID instead.
+func (r *HitsplatRenderer) Render(id uint16, damage int) (*image.RGBA, error) {
+	splat, err := r.cache.Hitsplat(id)
+	if err != nil {
+		return nil, fmt.Errorf("getting hitsplat: %w", err)
+	}
+
+	var parts [4]*image.RGBA
+	for i, spriteID := range []int32{splat.IconSpriteID, splat.LeftSpriteID, splat.MiddleSpriteID, splat.RightSpriteID} {
+		if spriteID < 0 {
+			continue
+		}
+		parts[i], err = r.sprite(spriteID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	icon, left, middle, right := parts[0], parts[1], parts[2], parts[3]
+
+	var font *Font
+	text := splat.Text(damage)
+	if splat.FontID >= 0 && text != "" {
+		font, err = r.font(splat.FontID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	textWidth := 0
+	if font != nil {
+		textWidth = font.TextWidth(text)
+	}
+	middleWidth := textWidth
+	if middle != nil {
+		middleWidth = max(middleWidth, middle.Bounds().Dx())
+	}
+
+	width, height := middleWidth, 0
+	if font != nil {
+		height = font.Ascent
+	}
+	for _, part := range parts {
+		if part == nil {
+			continue
+		}
+		if part != middle {
+			width += part.Bounds().Dx()
+		}
+		height = max(height, part.Bounds().Dy())
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	x := 0
+	place := func(img *image.RGBA) {
+		if img == nil {
+			return
+		}
+		at := image.Pt(x, (height-img.Bounds().Dy())/2)
+		draw.Draw(dst, img.Bounds().Sub(img.Bounds().Min).Add(at), img, img.Bounds().Min, draw.Over)
+		x += img.Bounds().Dx()
+	}
+	place(icon)
+	place(left)
+
+	textX := x + (middleWidth-textWidth)/2
+	if middle != nil && middle.Bounds().Dx() > 0 {
+		clipped := dst.SubImage(image.Rect(x, 0, x+middleWidth, height)).(*image.RGBA)
+		y := (height - middle.Bounds().Dy()) / 2
+		for tileX := x; tileX < x+middleWidth; tileX += middle.Bounds().Dx() {
+			at := image.Pt(tileX, y)
+			draw.Draw(clipped, middle.Bounds().Sub(middle.Bounds().Min).Add(at), middle, middle.Bounds().Min, draw.Over)
+		}
+	}
+	x += middleWidth
+	place(right)
+
+	if font != nil {
+		baseline := (height+font.Ascent)/2 + int(splat.TextOffsetY)
+		font.DrawText(dst, text, textX, baseline, int32(splat.TextColor), 0)
+	}
+	return dst, nil
+}
+
+func (r *HitsplatRenderer) sprite(id int32) (*image.RGBA, error) {
+	if img, ok := r.sprites[id]; ok {
+		return img, nil
+	}
+	if id > math.MaxUint16 {
+		return nil, fmt.Errorf("sprite %d out of range", id)
+	}
+
+	sprite, err := r.cache.Sprite(uint16(id))
+	if err != nil {
+		return nil, fmt.Errorf("getting sprite %d: %w", id, err)
+	}
+	img := sprite.Image()
+	r.sprites[id] = img
+	return img, nil
+}
+
+func (r *HitsplatRenderer) font(id int32) (*Font, error) {
+	if font, ok := r.fonts[id]; ok {
+		return font, nil
+	}
+	if id > math.MaxUint16 {
+		return nil, fmt.Errorf("font %d out of range", id)
+	}
+
+	font, err := r.cache.Font(uint16(id))
+	if err != nil {
+		return nil, fmt.Errorf("getting font %d: %w", id, err)
+	}
+	r.fonts[id] = font
+	return font, nil
+}
@@ -0,0 +1,71 @@
+package osrscache
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHitsplatReadConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		varbit  uint16
+		varp    uint16
+		configs []uint16
+	}{
+		{
+			name:    "varbit",
+			data:    []byte{17, 1, 2, 0xFF, 0xFF, 1, 0, 5, 0, 6},
+			varbit:  0x102,
+			varp:    math.MaxUint16,
+			configs: []uint16{5, 6, math.MaxUint16},
+		},
+		{
+			name:    "varp with fallback",
+			data:    []byte{18, 0xFF, 0xFF, 0, 7, 0, 9, 0, 0, 4},
+			varbit:  math.MaxUint16,
+			varp:    7,
+			configs: []uint16{4, 9},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splat := NewHitsplat(1)
+			if err := splat.Read(tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if splat.VarbitID != tt.varbit || splat.VarpIndex != tt.varp {
+				t.Errorf("varbit, varp = %d, %d, want %d, %d", splat.VarbitID, splat.VarpIndex, tt.varbit, tt.varp)
+			}
+			if !slices.Equal(splat.Configs, tt.configs) {
+				t.Errorf("configs = %v, want %v", splat.Configs, tt.configs)
+			}
+
+			if err := NewHitsplat(1).Read(tt.data[:len(tt.data)-1]); err == nil {
+				t.Error("want an error for a truncated config list")
+			}
+		})
+	}
+}
+
+func TestHitsplatRenderOutOfRange(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"sprite", []byte{5, 0x80, 0, 0, 2, 0, 0}, "sprite 65536 out of range"},
+		{"font", []byte{1, 0x80, 0, 0, 2, 0, 1, 8, 0, '%', '1', 0}, "font 65537 out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(memStore{2: {33: tt.data}})
+			_, err := NewHitsplatRenderer(cache).Render(0, 10)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
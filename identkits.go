@@ -0,0 +1,371 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// BodyPart is the part of the player an identity kit dresses. Female kits use
+// the same parts offset by BodyPartFemale.
+type BodyPart uint8
+
+const (
+	BodyPartHair BodyPart = iota
+	BodyPartJaw
+	BodyPartTorso
+	BodyPartArms
+	BodyPartHands
+	BodyPartLegs
+	BodyPartFeet
+
+	BodyPartFemale BodyPart = 7
+	// NoBodyPart marks a kit without a body part.
+	NoBodyPart BodyPart = 0xFF
+)
+
+// bodyPartSlots maps body parts to the equipment slots that cover them.
+var bodyPartSlots = [...]EquipmentSlot{
+	BodyPartHair:  SlotHair,
+	BodyPartJaw:   SlotJaw,
+	BodyPartTorso: SlotBody,
+	BodyPartArms:  SlotArms,
+	BodyPartHands: SlotHands,
+	BodyPartLegs:  SlotLegs,
+	BodyPartFeet:  SlotFeet,
+}
+
+// IdentKit is a body part a player picks when designing their character,
+// such as a hairstyle or a torso.
+type IdentKit struct {
+	ID       uint16   `json:"id"`
+	BodyPart BodyPart `json:"body_part"`
+	Models   []uint16 `json:"models"`
+	// ChatHeadModels are the models of the part in chat heads, math.MaxUint16
+	// where unused.
+	ChatHeadModels [5]uint16      `json:"chat_head_models"`
+	RecolorFrom    []uint16       `json:"recolor_from"`
+	RecolorTo      []uint16       `json:"recolor_to"`
+	RetextureFrom  []uint16       `json:"retexture_from"`
+	RetextureTo    []uint16       `json:"retexture_to"`
+	NonSelectable  bool           `json:"non_selectable"`
+	Unknown        *UnknownOpcode `json:"unknown,omitempty"`
+}
+
+func NewIdentKit(id uint16) *IdentKit {
+	return &IdentKit{
+		ID:             id,
+		BodyPart:       NoBodyPart,
+		ChatHeadModels: [5]uint16{math.MaxUint16, math.MaxUint16, math.MaxUint16, math.MaxUint16, math.MaxUint16},
+	}
+}
+
+func (k *IdentKit) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
+	reader := NewReader(data)
+	for {
+		opcode, err := reader.ReadUint8()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading opcode: %w", err)
+		}
+		if opcode == 0 {
+			break
+		}
+		switch opcode {
+		case 1:
+			part, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading body part: %w", err)
+			}
+			k.BodyPart = BodyPart(part)
+		case 2:
+			length, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading model length: %w", err)
+			}
+			k.Models = make([]uint16, length)
+			for i := range k.Models {
+				k.Models[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading model at index %d: %w", i, err)
+				}
+			}
+		case 3:
+			k.NonSelectable = true
+		case 40:
+			length, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading recolor length: %w", err)
+			}
+			k.RecolorFrom = make([]uint16, length)
+			k.RecolorTo = make([]uint16, length)
+			for i := 0; i < int(length); i++ {
+				k.RecolorFrom[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading recolor from: %w", err)
+				}
+				k.RecolorTo[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading recolor to: %w", err)
+				}
+			}
+		case 41:
+			length, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading retexture length: %w", err)
+			}
+			k.RetextureFrom = make([]uint16, length)
+			k.RetextureTo = make([]uint16, length)
+			for i := 0; i < int(length); i++ {
+				k.RetextureFrom[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading retexture from: %w", err)
+				}
+				k.RetextureTo[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading retexture to: %w", err)
+				}
+			}
+		case 60, 61, 62, 63, 64:
+			k.ChatHeadModels[opcode-60], err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading chat head model: %w", err)
+			}
+		default:
+			k.Unknown, err = options.unknownOpcode("identkit", k.ID, opcode, reader)
+			return err
+		}
+	}
+	return nil
+}
+
+// BodyColor indexes the colours a player picks for their character.
+type BodyColor uint8
+
+const (
+	BodyColorHair BodyColor = iota
+	BodyColorTorso
+	BodyColorLegs
+	BodyColorFeet
+	BodyColorSkin
+)
+
+// bodyColorSources are the colours of the player models that the client
+// replaces with the chosen body colours.
+var bodyColorSources = [...]uint16{
+	BodyColorHair:  6798,
+	BodyColorTorso: 8741,
+	BodyColorLegs:  25238,
+	BodyColorFeet:  4626,
+	BodyColorSkin:  4550,
+}
+
+// appearanceSlots is the number of equipment slots drawn on a player, from
+// SlotHead up to SlotJaw.
+const appearanceSlots = 12
+
+// PlayerAppearance assembles a player from identity kits and worn items the
+// way the client does: an item replaces the kit in its slot, and hides the
+// kits in its secondary and tertiary wear positions, such as the hair under a
+// full helm.
+type PlayerAppearance struct {
+	cache  *Cache
+	female bool
+	kits   [appearanceSlots]*IdentKit
+	items  [appearanceSlots]*Item
+	colors [5]*uint16
+}
+
+func NewPlayerAppearance(cache *Cache, female bool) *PlayerAppearance {
+	return &PlayerAppearance{cache: cache, female: female}
+}
+
+// SetKit dresses the body part of kit id, replacing the previous kit of the
+// part.
+func (a *PlayerAppearance) SetKit(id uint16) error {
+	kit, err := a.cache.IdentKit(id)
+	if err != nil {
+		return fmt.Errorf("getting identkit: %w", err)
+	}
+
+	part := kit.BodyPart
+	if a.female {
+		part -= BodyPartFemale
+	}
+	if kit.BodyPart == NoBodyPart || int(part) >= len(bodyPartSlots) {
+		return fmt.Errorf("identkit %d is not a %s body part", id, a.gender())
+	}
+	a.kits[bodyPartSlots[part]] = kit
+	return nil
+}
+
+// Wear equips item id in its wear position, replacing the item worn there.
+func (a *PlayerAppearance) Wear(id uint16) error {
+	item, err := a.cache.Item(id)
+	if err != nil {
+		return fmt.Errorf("getting item: %w", err)
+	}
+
+	slot := item.WearPositionPrimary
+	if slot == NoWearPosition {
+		return fmt.Errorf("item %d is not wearable", id)
+	}
+	if int(slot) < appearanceSlots {
+		a.items[slot] = item
+	}
+	return nil
+}
+
+// Remove takes off the item worn in slot.
+func (a *PlayerAppearance) Remove(slot EquipmentSlot) {
+	if int(slot) < appearanceSlots {
+		a.items[slot] = nil
+	}
+}
+
+// SetBodyColor replaces the part's default colour with hsl, a 16-bit
+// hue-saturation-lightness colour. The palettes the character designer picks
+// from are built into the client rather than the cache.
+func (a *PlayerAppearance) SetBodyColor(part BodyColor, hsl uint16) {
+	if int(part) < len(a.colors) {
+		a.colors[part] = &hsl
+	}
+}
+
+func (a *PlayerAppearance) gender() string {
+	if a.female {
+		return "female"
+	}
+	return "male"
+}
+
+// AppearancePart is one of the models merged into a player.
+type AppearancePart struct {
+	Slot EquipmentSlot `json:"slot"`
+	// KitID or ItemID is the definition the part comes from, math.MaxUint16
+	// for the other.
+	KitID  uint16 `json:"kit_id"`
+	ItemID uint16 `json:"item_id"`
+	// Models are merged into the part in order.
+	Models []uint16 `json:"models"`
+	// OffsetY moves the part's models down, as set by the item.
+	OffsetY       uint8    `json:"offset_y"`
+	RecolorFrom   []uint16 `json:"recolor_from"`
+	RecolorTo     []uint16 `json:"recolor_to"`
+	RetextureFrom []uint16 `json:"retexture_from"`
+	RetextureTo   []uint16 `json:"retexture_to"`
+}
+
+// Parts lists the parts of the appearance in slot order. Kits in slots
+// covered by an item, or hidden by an item's secondary or tertiary wear
+// position, are left out.
+func (a *PlayerAppearance) Parts() []AppearancePart {
+	var hidden [appearanceSlots]bool
+	for slot, item := range a.items {
+		if item == nil {
+			continue
+		}
+		hidden[slot] = true
+		for _, position := range []uint8{item.WearPositionSecondary, item.WearPositionTertiary} {
+			if int(position) < appearanceSlots {
+				hidden[position] = true
+			}
+		}
+	}
+
+	var parts []AppearancePart
+	for slot := range appearanceSlots {
+		if item := a.items[slot]; item != nil {
+			if part, ok := a.itemPart(EquipmentSlot(slot), item); ok {
+				parts = append(parts, part)
+			}
+			continue
+		}
+		if kit := a.kits[slot]; kit != nil && !hidden[slot] && len(kit.Models) > 0 {
+			parts = append(parts, AppearancePart{
+				Slot:          EquipmentSlot(slot),
+				KitID:         kit.ID,
+				ItemID:        math.MaxUint16,
+				Models:        kit.Models,
+				RecolorFrom:   kit.RecolorFrom,
+				RecolorTo:     kit.RecolorTo,
+				RetextureFrom: kit.RetextureFrom,
+				RetextureTo:   kit.RetextureTo,
+			})
+		}
+	}
+	return parts
+}
+
+// Model loads the models of the parts from archive 7 and merges them into
+// the player model. Each part is recoloured and retextured, items are moved
+// down by their OffsetY, and the body colours are then recoloured over the
+// merged model.
+func (a *PlayerAppearance) Model() (*Model, error) {
+	var models []*Model
+	for _, part := range a.Parts() {
+		model, err := a.partModel(part)
+		if err != nil {
+			return nil, fmt.Errorf("building %s: %w", part.Slot, err)
+		}
+		models = append(models, model)
+	}
+
+	player := MergeModels(models...)
+	for part, color := range a.colors {
+		if color != nil {
+			player.Recolor([]uint16{bodyColorSources[part]}, []uint16{*color})
+		}
+	}
+	return player, nil
+}
+
+// partModel merges the models of a part and applies its offset, recolours
+// and retextures.
+func (a *PlayerAppearance) partModel(part AppearancePart) (*Model, error) {
+	models := make([]*Model, len(part.Models))
+	for i, id := range part.Models {
+		model, err := a.cache.Model(id)
+		if err != nil {
+			return nil, fmt.Errorf("getting model %d: %w", id, err)
+		}
+		models[i] = model
+	}
+
+	model := MergeModels(models...)
+	if part.OffsetY != 0 {
+		model.Translate(0, int32(part.OffsetY), 0)
+	}
+	model.Recolor(part.RecolorFrom, part.RecolorTo)
+	model.Retexture(part.RetextureFrom, part.RetextureTo)
+	return model, nil
+}
+
+// itemPart returns the worn models of an item, skipping unset models.
+func (a *PlayerAppearance) itemPart(slot EquipmentSlot, item *Item) (AppearancePart, bool) {
+	data := item.CharacterModelDataMale
+	if a.female {
+		data = item.CharacterModelDataFemale
+	}
+	models := data.wornModels()
+	if data.set&wornModelPrimary == 0 {
+		return AppearancePart{}, false
+	}
+
+	return AppearancePart{
+		Slot:          slot,
+		KitID:         math.MaxUint16,
+		ItemID:        item.ID,
+		Models:        models,
+		OffsetY:       data.Offset,
+		RecolorFrom:   item.InventoryModelData.RecolorFrom,
+		RecolorTo:     item.InventoryModelData.RecolorTo,
+		RetextureFrom: item.InventoryModelData.RetextureFrom,
+		RetextureTo:   item.InventoryModelData.RetextureTo,
+	}, true
+}
@@ -0,0 +1,65 @@
+package osrscache
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestItemPart(t *testing.T) {
+	item := NewItem(1)
+	if err := item.Read([]byte{23, 0, 0, 0, 0}); err != nil { // male model 0
+		t.Fatal(err)
+	}
+
+	if item.CharacterModelDataFemale.ModelPrimary != 0 {
+		t.Errorf("unset female model = %d, want the exported default of 0", item.CharacterModelDataFemale.ModelPrimary)
+	}
+
+	male := &PlayerAppearance{}
+	part, ok := male.itemPart(SlotHead, item)
+	if !ok || !slices.Equal(part.Models, []uint16{0}) {
+		t.Errorf("male part = %v, %t, want model 0", part.Models, ok)
+	}
+
+	female := &PlayerAppearance{female: true}
+	if part, ok := female.itemPart(SlotHead, item); ok {
+		t.Errorf("female part = %v, want none", part.Models)
+	}
+}
+
+func TestPlayerAppearanceModel(t *testing.T) {
+	models := map[uint32][]byte{
+		1: oldModelBytes(bodyColorSources[BodyColorTorso]),
+		2: oldModelBytes(bodyColorSources[BodyColorHair]),
+		3: newModelBytes(),
+	}
+	store := memStore{7: models}
+	appearance := NewPlayerAppearance(New(store), false)
+	appearance.kits[SlotBody] = &IdentKit{ID: 18, Models: []uint16{1}}
+	appearance.kits[SlotHair] = &IdentKit{ID: 0, Models: []uint16{2}}
+	appearance.SetBodyColor(BodyColorTorso, 500)
+
+	helm := NewItem(1163)
+	if err := helm.Read([]byte{23, 0, 3, 6, 40, 1, 0, 0x42, 0, 0x43}); err != nil { // model 3 offset 6, recolour 0x42
+		t.Fatal(err)
+	}
+	helm.WearPositionSecondary = uint8(SlotHair)
+	appearance.items[SlotHead] = helm
+
+	model, err := appearance.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The helm hides the hair, leaving the helm then the torso.
+	if !slices.Equal(model.FaceColors, []uint16{0x43, 500, 127}) {
+		t.Errorf("colors = %v, want the item recolour then the body colour", model.FaceColors)
+	}
+	if !slices.Equal(model.VertexY, []int32{7, 7, 9, 0, 0, -20}) {
+		t.Errorf("y = %v, want the helm moved down by its offset", model.VertexY)
+	}
+
+	models[1] = nil
+	if _, err := appearance.Model(); err == nil {
+		t.Error("want an error for a model that fails to load")
+	}
+}
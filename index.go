@@ -2,6 +2,7 @@ package osrscache
 
 import (
 	"fmt"
+	"strings"
 )
 
 const (
@@ -199,7 +200,28 @@ func (i *Index) Group(id uint32) (*Group, error) {
 			return group, nil
 		}
 	}
-	return nil, fmt.Errorf("group %d not found", id)
+	return nil, fmt.Errorf("group %d: %w", id, ErrNotFound)
+}
+
+// GroupByName returns the group whose name hashes to the same value as name.
+func (i *Index) GroupByName(name string) (*Group, error) {
+	hash := NameHash(name)
+	for _, group := range i.Groups {
+		if group.NameHash == hash {
+			return group, nil
+		}
+	}
+	return nil, fmt.Errorf("group %q: %w", name, ErrNotFound)
+}
+
+// NameHash hashes a group or file name the way the client does, with Java's
+// String.hashCode over the lower-cased name.
+func NameHash(name string) int32 {
+	var hash int32
+	for _, b := range []byte(strings.ToLower(name)) {
+		hash = hash*31 + int32(b)
+	}
+	return hash
 }
 
 func readSize(reader *Reader, protocol Protocol) (uint32, error) {
@@ -0,0 +1,76 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Well-known inventory IDs, as referenced by client scripts through the inv_*
+// commands.
+const (
+	InventoryBackpack   = 93
+	InventoryEquipment  = 94
+	InventoryBank       = 95
+	InventoryLootingBag = 516
+)
+
+// InventoryDefinition is an item container, such as the bank or a shop. Shops
+// list the items they start with; other containers are filled by the server.
+type InventoryDefinition struct {
+	ID           uint16         `json:"id"`
+	Size         uint16         `json:"size"`
+	StockItems   []uint16       `json:"stock_items"`
+	StockAmounts []uint16       `json:"stock_amounts"`
+	Unknown      *UnknownOpcode `json:"unknown,omitempty"`
+}
+
+func NewInventoryDefinition(id uint16) *InventoryDefinition {
+	return &InventoryDefinition{ID: id}
+}
+
+func (inv *InventoryDefinition) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
+	reader := NewReader(data)
+	for {
+		opcode, err := reader.ReadUint8()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading opcode: %w", err)
+		}
+		if opcode == 0 {
+			break
+		}
+		switch opcode {
+		case 2:
+			inv.Size, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading size: %w", err)
+			}
+		case 4:
+			length, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading stock length: %w", err)
+			}
+			inv.StockItems = make([]uint16, length)
+			inv.StockAmounts = make([]uint16, length)
+			for i := 0; i < int(length); i++ {
+				inv.StockItems[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading stock item: %w", err)
+				}
+				inv.StockAmounts[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading stock amount: %w", err)
+				}
+			}
+		default:
+			inv.Unknown, err = options.unknownOpcode("inventory", inv.ID, opcode, reader)
+			return err
+		}
+	}
+	return nil
+}
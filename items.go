@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 )
 
 type Item struct {
@@ -32,9 +34,11 @@ type Item struct {
 	InventoryModelData       InventoryModelData `json:"inventory_model_data"`
 	CharacterModelDataMale   CharacterModelData `json:"character_model_data_male"`
 	CharacterModelDataFemale CharacterModelData `json:"character_model_data_female"`
-	WearPositionPrimary      uint8              `json:"wear_position_primary"`
-	WearPositionSecondary    uint8              `json:"wear_position_secondary"`
-	WearPositionTertiary     uint8              `json:"wear_position_tertiary"`
+	// The wear positions are EquipmentSlot values, NoWearPosition where unset.
+	WearPositionPrimary   uint8          `json:"wear_position_primary"`
+	WearPositionSecondary uint8          `json:"wear_position_secondary"`
+	WearPositionTertiary  uint8          `json:"wear_position_tertiary"`
+	Unknown               *UnknownOpcode `json:"unknown,omitempty"`
 }
 
 type InventoryModelData struct {
@@ -56,6 +60,7 @@ type InventoryModelData struct {
 	Contrast      int8     `json:"contrast"`
 }
 
+// CharacterModelData holds the models an item is drawn with when worn.
 type CharacterModelData struct {
 	ModelPrimary           uint16 `json:"model_primary"`
 	ModelSecondary         uint16 `json:"model_secondary"`
@@ -63,6 +68,27 @@ type CharacterModelData struct {
 	Offset                 uint8  `json:"offset"`
 	ChatHeadModelPrimary   uint16 `json:"chat_head_model_primary"`
 	ChatHeadModelSecondary uint16 `json:"chat_head_model_secondary"`
+
+	// set has a bit per worn model the definition sets, since an unset model
+	// and model 0 both read as 0.
+	set uint8
+}
+
+const (
+	wornModelPrimary uint8 = 1 << iota
+	wornModelSecondary
+	wornModelTertiary
+)
+
+// wornModels returns the worn models the definition sets, primary first.
+func (d CharacterModelData) wornModels() []uint16 {
+	var models []uint16
+	for i, model := range []uint16{d.ModelPrimary, d.ModelSecondary, d.ModelTertiary} {
+		if d.set&(1<<i) != 0 {
+			models = append(models, model)
+		}
+	}
+	return models
 }
 
 func NewItem(id uint16) *Item {
@@ -77,10 +103,15 @@ func NewItem(id uint16) *Item {
 			ScaleY: 128,
 			ScaleZ: 128,
 		},
+		WearPositionPrimary:   NoWearPosition,
+		WearPositionSecondary: NoWearPosition,
+		WearPositionTertiary:  NoWearPosition,
 	}
 }
 
-func (item *Item) Read(data []byte) error {
+func (item *Item) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
 	reader := NewReader(data)
 	for {
 		opcode, err := reader.ReadUint8()
@@ -154,6 +185,7 @@ func (item *Item) Read(data []byte) error {
 		case 16:
 			item.MembersOnly = true
 		case 23:
+			item.CharacterModelDataMale.set |= wornModelPrimary
 			item.CharacterModelDataMale.ModelPrimary, err = reader.ReadUint16()
 			if err != nil {
 				return fmt.Errorf("reading male character model primary: %w", err)
@@ -163,11 +195,13 @@ func (item *Item) Read(data []byte) error {
 				return fmt.Errorf("reading male character model offset: %w", err)
 			}
 		case 24:
+			item.CharacterModelDataMale.set |= wornModelSecondary
 			item.CharacterModelDataMale.ModelSecondary, err = reader.ReadUint16()
 			if err != nil {
 				return fmt.Errorf("reading male character model secondary: %w", err)
 			}
 		case 25:
+			item.CharacterModelDataFemale.set |= wornModelPrimary
 			item.CharacterModelDataFemale.ModelPrimary, err = reader.ReadUint16()
 			if err != nil {
 				return fmt.Errorf("reading female character model primary: %w", err)
@@ -177,6 +211,7 @@ func (item *Item) Read(data []byte) error {
 				return fmt.Errorf("reading female character model offset: %w", err)
 			}
 		case 26:
+			item.CharacterModelDataFemale.set |= wornModelSecondary
 			item.CharacterModelDataFemale.ModelSecondary, err = reader.ReadUint16()
 			if err != nil {
 				return fmt.Errorf("reading female character model secondary: %w", err)
@@ -243,11 +278,13 @@ func (item *Item) Read(data []byte) error {
 				return fmt.Errorf("reading weight: %w", err)
 			}
 		case 78:
+			item.CharacterModelDataMale.set |= wornModelTertiary
 			item.CharacterModelDataMale.ModelTertiary, err = reader.ReadUint16()
 			if err != nil {
 				return fmt.Errorf("reading male character model chat head model secondary: %w", err)
 			}
 		case 79:
+			item.CharacterModelDataFemale.set |= wornModelTertiary
 			item.CharacterModelDataFemale.ModelTertiary, err = reader.ReadUint16()
 			if err != nil {
 				return fmt.Errorf("reading female character model chat head model secondary: %w", err)
@@ -385,8 +422,143 @@ func (item *Item) Read(data []byte) error {
 				item.Params[key] = value
 			}
 		default:
-			return fmt.Errorf("unknown opcode: %d", opcode)
+			item.Unknown, err = options.unknownOpcode("item", item.ID, opcode, reader)
+			return err
+		}
+	}
+	return nil
+}
+
+// IsNoted reports whether the item is a bank note of another item.
+func (item *Item) IsNoted() bool {
+	return item.NotedTemplate != 0
+}
+
+// IsPlaceholder reports whether the item is a bank placeholder of another
+// item.
+func (item *Item) IsPlaceholder() bool {
+	return item.PlaceholderTemplate != 0
+}
+
+// IsBought reports whether the item is the bought variant of another item.
+func (item *Item) IsBought() bool {
+	return item.BoughtTemplate != 0
+}
+
+// Noted returns the noted variant of the item, or the item itself if it is
+// already noted. It returns ErrNotFound if the item cannot be noted.
+func (item *Item) Noted(cache *Cache) (*Item, error) {
+	if item.IsNoted() {
+		return item, nil
+	}
+	if item.NotedItemID == 0 {
+		return nil, fmt.Errorf("noted item %d: %w", item.ID, ErrNotFound)
+	}
+	return cache.Item(item.NotedItemID)
+}
+
+// Unnoted returns the item a bank note represents, or the item itself if it is
+// not noted.
+func (item *Item) Unnoted(cache *Cache) (*Item, error) {
+	if !item.IsNoted() {
+		return item, nil
+	}
+	return cache.Item(item.NotedItemID)
+}
+
+// link resolves noted, bought and placeholder items the way the client does,
+// copying the model from the template item and the name, value and other
+// properties from the item they represent. lookup must return unlinked items.
+func (item *Item) link(lookup func(id uint16) (*Item, error)) error {
+	// Every lookup is resolved before the item changes, so an item that fails
+	// to link is left as decoded.
+	var links []func()
+	if item.IsNoted() {
+		template, err := lookup(item.NotedTemplate)
+		if err != nil {
+			return fmt.Errorf("getting noted template: %w", err)
 		}
+
+		unnoted, err := lookup(item.NotedItemID)
+		if err != nil {
+			return fmt.Errorf("getting unnoted item: %w", err)
+		}
+		links = append(links, func() { item.linkNote(template, unnoted) })
+	}
+
+	if item.IsBought() {
+		template, err := lookup(item.BoughtTemplate)
+		if err != nil {
+			return fmt.Errorf("getting bought template: %w", err)
+		}
+
+		unbought, err := lookup(item.BoughtLinkID)
+		if err != nil {
+			return fmt.Errorf("getting unbought item: %w", err)
+		}
+		links = append(links, func() { item.linkBought(template, unbought) })
+	}
+
+	if item.IsPlaceholder() {
+		template, err := lookup(item.PlaceholderTemplate)
+		if err != nil {
+			return fmt.Errorf("getting placeholder template: %w", err)
+		}
+
+		base, err := lookup(item.PlaceholderItemID)
+		if err != nil {
+			return fmt.Errorf("getting placeholder item: %w", err)
+		}
+		links = append(links, func() { item.linkPlaceholder(template, base) })
+	}
+
+	for _, link := range links {
+		link()
 	}
 	return nil
 }
+
+func (item *Item) linkNote(template *Item, unnoted *Item) {
+	item.copyInventoryModel(template)
+	item.Name = unnoted.Name
+	item.MembersOnly = unnoted.MembersOnly
+	item.Value = unnoted.Value
+	item.Stackable = true
+}
+
+func (item *Item) linkBought(template *Item, unbought *Item) {
+	item.copyInventoryModel(template)
+	item.Name = unbought.Name
+	item.MembersOnly = unbought.MembersOnly
+	item.Stackable = unbought.Stackable
+	item.CharacterModelDataMale = unbought.CharacterModelDataMale
+	item.CharacterModelDataFemale = unbought.CharacterModelDataFemale
+	item.Team = unbought.Team
+	item.ActionsGround = unbought.ActionsGround
+	item.ActionsInventory = unbought.ActionsInventory
+	item.ActionsInventory[4] = "Discard"
+	item.Params = maps.Clone(unbought.Params)
+	item.Value = 0
+}
+
+func (item *Item) linkPlaceholder(template *Item, base *Item) {
+	item.copyInventoryModel(template)
+	item.Name = base.Name
+	item.MembersOnly = base.MembersOnly
+	item.Stackable = base.Stackable
+	item.Value = 0
+}
+
+func (item *Item) copyInventoryModel(from *Item) {
+	item.InventoryModelData.ID = from.InventoryModelData.ID
+	item.InventoryModelData.Zoom = from.InventoryModelData.Zoom
+	item.InventoryModelData.RotationX = from.InventoryModelData.RotationX
+	item.InventoryModelData.RotationY = from.InventoryModelData.RotationY
+	item.InventoryModelData.RotationZ = from.InventoryModelData.RotationZ
+	item.InventoryModelData.OffsetX = from.InventoryModelData.OffsetX
+	item.InventoryModelData.OffsetY = from.InventoryModelData.OffsetY
+	item.InventoryModelData.RecolorFrom = slices.Clone(from.InventoryModelData.RecolorFrom)
+	item.InventoryModelData.RecolorTo = slices.Clone(from.InventoryModelData.RecolorTo)
+	item.InventoryModelData.RetextureFrom = slices.Clone(from.InventoryModelData.RetextureFrom)
+	item.InventoryModelData.RetextureTo = slices.Clone(from.InventoryModelData.RetextureTo)
+}
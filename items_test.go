@@ -0,0 +1,81 @@
+package osrscache
+
+import "testing"
+
+func TestLinkItems(t *testing.T) {
+	base := NewItem(1)
+	base.Name = "Rune platebody"
+	base.Value = 65000
+	base.InventoryModelData.ID = 100
+
+	template := NewItem(2)
+	template.Name = "Bought template"
+	template.InventoryModelData.ID = 200
+	// A template that is itself linked must still be read unlinked.
+	template.NotedTemplate = 4
+	template.NotedItemID = 1
+
+	bought := NewItem(3)
+	bought.BoughtTemplate = 2
+	bought.BoughtLinkID = 1
+
+	noteTemplate := NewItem(4)
+	noteTemplate.InventoryModelData.ID = 400
+
+	for range 20 {
+		items := map[uint16]*Item{1: clone(base), 2: clone(template), 3: clone(bought), 4: clone(noteTemplate)}
+		if errs := linkItems(items); len(errs) > 0 {
+			t.Fatalf("linkItems: %v", errs)
+		}
+
+		got := items[3]
+		if got.InventoryModelData.ID != 200 {
+			t.Errorf("bought model = %d, want the template's 200", got.InventoryModelData.ID)
+		}
+		if got.Name != "Rune platebody" || got.Value != 0 || got.ActionsInventory[4] != "Discard" {
+			t.Errorf("bought item = %q value %d actions %q, want the linked item's name, no value and Discard",
+				got.Name, got.Value, got.ActionsInventory)
+		}
+	}
+}
+
+func TestLinkItemsMissing(t *testing.T) {
+	note := NewItem(5)
+	note.NotedTemplate = 9
+	note.NotedItemID = 1
+
+	items := map[uint16]*Item{1: NewItem(1), 5: note}
+	errs := linkItems(items)
+	if len(errs) != 1 || errs[0].ID != 5 {
+		t.Fatalf("errs = %v, want item 5 to fail", errs)
+	}
+	if got := items[5]; got != note || got.Name != "null" {
+		t.Error("item 5 was not kept unlinked after failing to link")
+	}
+	if _, ok := items[1]; !ok {
+		t.Error("item 1 was removed")
+	}
+}
+
+func clone(item *Item) *Item {
+	c := *item
+	return &c
+}
+
+func TestLinkItemsPartialFailure(t *testing.T) {
+	unnoted := NewItem(1)
+	unnoted.Name = "Coins"
+	item := NewItem(5)
+	item.NotedTemplate = 1
+	item.NotedItemID = 1
+	item.PlaceholderTemplate = 9
+	item.PlaceholderItemID = 1
+
+	items := map[uint16]*Item{1: unnoted, 5: item}
+	if errs := linkItems(items); len(errs) != 1 {
+		t.Fatalf("errs = %v, want one", errs)
+	}
+	if item.Name != "null" || item.Stackable {
+		t.Errorf("item 5 was partly linked: %+v", item)
+	}
+}
@@ -1,10 +1,13 @@
 package jagex
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/joeychilson/osrscache"
 )
 
 const (
@@ -74,7 +77,7 @@ func (s *JagexStore) ArchiveList() ([]uint8, error) {
 	}
 
 	if len(archives) == 0 {
-		return nil, fmt.Errorf("archive does not exist")
+		return nil, fmt.Errorf("archives: %w", osrscache.ErrNotFound)
 	}
 	return archives, nil
 }
@@ -86,7 +89,7 @@ func (s *JagexStore) ArchiveExists(archiveID uint8) bool {
 func (s *JagexStore) GroupList(archiveID uint8) ([]uint32, error) {
 	indexFile := s.indexFiles[archiveID]
 	if indexFile == nil {
-		return nil, fmt.Errorf("archive %d does not exist", archiveID)
+		return nil, fmt.Errorf("archive %d: %w", archiveID, osrscache.ErrNotFound)
 	}
 
 	stat, err := indexFile.Stat()
@@ -138,7 +141,7 @@ func (s *JagexStore) Read(archiveID uint8, groupID uint32) ([]byte, error) {
 	}
 
 	if entry.Block == 0 {
-		return nil, fmt.Errorf("group %d does not exist in archive %d", groupID, archiveID)
+		return nil, fmt.Errorf("group %d in archive %d: %w", groupID, archiveID, osrscache.ErrNotFound)
 	}
 
 	extended := groupID >= 65536
@@ -169,13 +172,13 @@ func (s *JagexStore) Read(archiveID uint8, groupID uint32) ([]byte, error) {
 	var bytesRead int
 	for bytesRead < int(entry.Size) {
 		if currentBlock == 0 {
-			return nil, fmt.Errorf("group shorter than expected")
+			return nil, &osrscache.CorruptGroupError{Archive: archiveID, Group: groupID, Reason: "group shorter than expected"}
 		}
 
 		pos := int64(currentBlock) * int64(blockHeaderSize+blockDataSize)
 
 		if pos+int64(blockHeaderSize) > dataFileStat.Size() {
-			return nil, fmt.Errorf("next block is outside the data file")
+			return nil, &osrscache.CorruptGroupError{Archive: archiveID, Group: groupID, Reason: "next block is outside the data file"}
 		}
 
 		_, err := s.dataFile.ReadAt(blockBuffer, pos)
@@ -203,15 +206,15 @@ func (s *JagexStore) Read(archiveID uint8, groupID uint32) ([]byte, error) {
 		}
 
 		if actualGroup != int(groupID) {
-			return nil, fmt.Errorf("expected group %d, but got %d", groupID, actualGroup)
+			return nil, &osrscache.CorruptGroupError{Archive: archiveID, Group: groupID, Reason: fmt.Sprintf("expected group %d, but got %d", groupID, actualGroup)}
 		}
 
 		if actualNum != blockNum {
-			return nil, fmt.Errorf("expected block number %d, but got %d", blockNum, actualNum)
+			return nil, &osrscache.CorruptGroupError{Archive: archiveID, Group: groupID, Reason: fmt.Sprintf("expected block number %d, but got %d", blockNum, actualNum)}
 		}
 
 		if actualArchive != int(archiveID) {
-			return nil, fmt.Errorf("expected archive %d, but got %d", archiveID, actualArchive)
+			return nil, &osrscache.CorruptGroupError{Archive: archiveID, Group: groupID, Reason: fmt.Sprintf("expected archive %d, but got %d", archiveID, actualArchive)}
 		}
 
 		dataSize := int(entry.Size) - bytesRead
@@ -239,7 +242,7 @@ type IndexEntry struct {
 func (s *JagexStore) IndexEntry(archiveID uint8, groupID uint32) (*IndexEntry, error) {
 	indexFile := s.indexFiles[int(archiveID)]
 	if indexFile == nil {
-		return nil, fmt.Errorf("archive %d does not exist", archiveID)
+		return nil, fmt.Errorf("archive %d: %w", archiveID, osrscache.ErrNotFound)
 	}
 
 	stat, err := indexFile.Stat()
@@ -257,6 +260,9 @@ func (s *JagexStore) IndexEntry(archiveID uint8, groupID uint32) (*IndexEntry, e
 
 	n, err := indexFile.ReadAt(buffer, position)
 	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("group %d in archive %d: %w", groupID, archiveID, osrscache.ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to read index file at position %d: %w", position, err)
 	}
 
@@ -0,0 +1,331 @@
+package js5
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joeychilson/osrscache"
+)
+
+var ErrClosed = errors.New("js5: client closed")
+
+// Client is a Store that fetches groups from a JS5 server.
+type Client struct {
+	conn   net.Conn
+	reader *xorReader
+	key    atomic.Uint32
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[uint32][]chan result
+	err     error
+
+	indexMu     sync.Mutex
+	masterIndex *osrscache.MasterIndex
+	indexes     map[uint8]*osrscache.Index
+}
+
+type result struct {
+	data []byte
+	err  error
+}
+
+// Dial connects to the JS5 server at addr and performs the handshake for the
+// given client revision.
+func Dial(addr string, revision uint32) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing js5 server: %w", err)
+	}
+
+	client, err := NewClient(conn, revision)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewClient performs the JS5 handshake over conn and starts reading
+// responses.
+func NewClient(conn net.Conn, revision uint32) (*Client, error) {
+	var handshake [5]byte
+	handshake[0] = HandshakeOpcode
+	binary.BigEndian.PutUint32(handshake[1:], revision)
+	if _, err := conn.Write(handshake[:]); err != nil {
+		return nil, fmt.Errorf("writing handshake: %w", err)
+	}
+
+	var status [1]byte
+	if _, err := io.ReadFull(conn, status[:]); err != nil {
+		return nil, fmt.Errorf("reading handshake status: %w", err)
+	}
+	if status[0] != StatusOK {
+		return nil, fmt.Errorf("handshake rejected with status %d", status[0])
+	}
+
+	c := &Client{
+		conn:    conn,
+		pending: make(map[uint32][]chan result),
+		indexes: make(map[uint8]*osrscache.Index),
+	}
+	c.reader = &xorReader{r: bufio.NewReader(conn), key: &c.key}
+
+	if err := c.send(OpcodeConnected, 0, 3); err != nil {
+		return nil, err
+	}
+	if err := c.send(OpcodeLoggedOut, 0, 0); err != nil {
+		return nil, err
+	}
+
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *Client) Close() error {
+	c.fail(ErrClosed)
+	return c.conn.Close()
+}
+
+// SetEncryptionKey asks the server to XOR every following response byte with
+// key. It must not be called while requests are outstanding.
+func (c *Client) SetEncryptionKey(key byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) > 0 {
+		return fmt.Errorf("changing encryption key with %d requests outstanding", len(c.pending))
+	}
+	if err := c.send(OpcodeEncryptionKey, key, 0); err != nil {
+		return err
+	}
+	c.key.Store(uint32(key))
+	return nil
+}
+
+// SetLoggedIn tells the server whether the player is logged in to the game.
+func (c *Client) SetLoggedIn(loggedIn bool) error {
+	if loggedIn {
+		return c.send(OpcodeLoggedIn, 0, 0)
+	}
+	return c.send(OpcodeLoggedOut, 0, 0)
+}
+
+func (c *Client) ArchiveList() ([]uint8, error) {
+	masterIndex, err := c.MasterIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []uint8
+	for id, entry := range masterIndex.Entries {
+		if entry.Checksum != 0 || entry.Version != 0 {
+			archives = append(archives, uint8(id))
+		}
+	}
+	return append(archives, MasterIndexArchive), nil
+}
+
+func (c *Client) ArchiveExists(archiveID uint8) bool {
+	archives, err := c.ArchiveList()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(archives, archiveID)
+}
+
+func (c *Client) GroupList(archiveID uint8) ([]uint32, error) {
+	if archiveID == MasterIndexArchive {
+		archives, err := c.ArchiveList()
+		if err != nil {
+			return nil, err
+		}
+
+		groups := make([]uint32, len(archives))
+		for i, archive := range archives {
+			groups[i] = uint32(archive)
+		}
+		return groups, nil
+	}
+
+	index, err := c.index(archiveID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]uint32, len(index.Groups))
+	for i, group := range index.Groups {
+		groups[i] = group.ID
+	}
+	return groups, nil
+}
+
+func (c *Client) GroupExists(archiveID uint8, groupID uint32) bool {
+	groups, err := c.GroupList(archiveID)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(groups, groupID)
+}
+
+// Read fetches a group with an urgent request.
+func (c *Client) Read(archiveID uint8, groupID uint32) ([]byte, error) {
+	return c.request(archiveID, groupID, true)
+}
+
+// Prefetch fetches a group with a non-priority request, which the server
+// answers after any outstanding urgent requests.
+func (c *Client) Prefetch(archiveID uint8, groupID uint32) ([]byte, error) {
+	return c.request(archiveID, groupID, false)
+}
+
+// MasterIndex returns the checksum and version of every archive's reference
+// table as served by the server.
+func (c *Client) MasterIndex() (*osrscache.MasterIndex, error) {
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+
+	if c.masterIndex != nil {
+		return c.masterIndex, nil
+	}
+
+	container, err := c.Read(MasterIndexArchive, MasterIndexGroup)
+	if err != nil {
+		return nil, fmt.Errorf("reading master index: %w", err)
+	}
+
+	data, err := osrscache.DecompressData(container)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing master index: %w", err)
+	}
+
+	masterIndex, err := osrscache.ReadMasterIndex(data, osrscache.MasterIndexFormatVersioned, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading master index: %w", err)
+	}
+	c.masterIndex = masterIndex
+	return masterIndex, nil
+}
+
+func (c *Client) index(archiveID uint8) (*osrscache.Index, error) {
+	c.indexMu.Lock()
+	index, ok := c.indexes[archiveID]
+	c.indexMu.Unlock()
+	if ok {
+		return index, nil
+	}
+
+	container, err := c.Read(MasterIndexArchive, uint32(archiveID))
+	if err != nil {
+		return nil, fmt.Errorf("reading reference table: %w", err)
+	}
+
+	data, err := osrscache.DecompressData(container)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing reference table: %w", err)
+	}
+
+	index, err = osrscache.ReadIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading reference table: %w", err)
+	}
+
+	c.indexMu.Lock()
+	c.indexes[archiveID] = index
+	c.indexMu.Unlock()
+	return index, nil
+}
+
+func (c *Client) request(archiveID uint8, groupID uint32, urgent bool) ([]byte, error) {
+	if groupID > math.MaxUint16 {
+		return nil, fmt.Errorf("group %d cannot be requested over js5", groupID)
+	}
+
+	key := uint32(archiveID)<<16 | groupID
+	ch := make(chan result, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, err
+	}
+	waiters := c.pending[key]
+	c.pending[key] = append(waiters, ch)
+	c.mu.Unlock()
+
+	if len(waiters) == 0 {
+		opcode := byte(OpcodePrefetchRequest)
+		if urgent {
+			opcode = OpcodeUrgentRequest
+		}
+		if err := c.send(opcode, archiveID, uint16(groupID)); err != nil {
+			c.fail(err)
+		}
+	}
+
+	res := <-ch
+	return res.data, res.err
+}
+
+func (c *Client) send(opcode byte, archiveID uint8, value uint16) error {
+	var req [requestSize]byte
+	req[0] = opcode
+	req[1] = archiveID
+	binary.BigEndian.PutUint16(req[2:], value)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if _, err := c.conn.Write(req[:]); err != nil {
+		return fmt.Errorf("writing request: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) readLoop() {
+	for {
+		resp, err := readResponse(c.reader)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		key := uint32(resp.archiveID)<<16 | uint32(resp.groupID)
+
+		c.mu.Lock()
+		waiters := c.pending[key]
+		delete(c.pending, key)
+		c.mu.Unlock()
+
+		for _, ch := range waiters {
+			ch <- result{data: resp.container}
+		}
+	}
+}
+
+// fail records the first connection error and fails every outstanding
+// request with it.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err == nil {
+		c.err = err
+	}
+	for key, waiters := range c.pending {
+		for _, ch := range waiters {
+			ch <- result{err: c.err}
+		}
+		delete(c.pending, key)
+	}
+}
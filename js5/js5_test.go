@@ -0,0 +1,251 @@
+package js5
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+const testRevision = 220
+
+// memStore is a Store backed by a map of containers.
+type memStore map[uint8]map[uint32][]byte
+
+func (s memStore) ArchiveList() ([]uint8, error) {
+	var archives []uint8
+	for archiveID := range s {
+		archives = append(archives, archiveID)
+	}
+	slices.Sort(archives)
+	return archives, nil
+}
+
+func (s memStore) ArchiveExists(archiveID uint8) bool {
+	_, ok := s[archiveID]
+	return ok
+}
+
+func (s memStore) GroupList(archiveID uint8) ([]uint32, error) {
+	var groups []uint32
+	for groupID := range s[archiveID] {
+		groups = append(groups, groupID)
+	}
+	slices.Sort(groups)
+	return groups, nil
+}
+
+func (s memStore) GroupExists(archiveID uint8, groupID uint32) bool {
+	_, ok := s[archiveID][groupID]
+	return ok
+}
+
+func (s memStore) Read(archiveID uint8, groupID uint32) ([]byte, error) {
+	data, ok := s[archiveID][groupID]
+	if !ok {
+		return nil, fmt.Errorf("group %d in archive %d not found", groupID, archiveID)
+	}
+	return data, nil
+}
+
+// container wraps data in an uncompressed container.
+func container(data []byte) []byte {
+	out := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(out[1:], uint32(len(data)))
+	return append(out, data...)
+}
+
+// referenceTable builds a protocol 6 reference table listing groups, each
+// holding a single file.
+func referenceTable(groups []uint32) []byte {
+	buf := []byte{6, 0, 0, 0, 1, 0}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(groups)))
+	var prev uint32
+	for _, group := range groups {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(group-prev))
+		prev = group
+	}
+	buf = append(buf, make([]byte, 8*len(groups))...) // checksums and versions
+	for range groups {
+		buf = binary.BigEndian.AppendUint16(buf, 1)
+	}
+	return append(buf, make([]byte, 2*len(groups))...) // file ID deltas
+}
+
+// testStore holds groups 1 to 3 of archive 2. Group 1 spans several blocks.
+func testStore() memStore {
+	large := make([]byte, 1200)
+	for i := range large {
+		large[i] = byte(i)
+	}
+	return memStore{
+		MasterIndexArchive: {2: container(referenceTable([]uint32{1, 2, 3}))},
+		2: {
+			1: container(large),
+			2: container([]byte("two")),
+			3: container([]byte("three")),
+		},
+	}
+}
+
+// serve starts a server for store on one end of a pipe and returns the other.
+func serve(t *testing.T, store memStore) net.Conn {
+	t.Helper()
+	server, err := NewServer(store, testRevision, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		defer serverConn.Close()
+		server.ServeConn(serverConn)
+	}()
+	t.Cleanup(func() { clientConn.Close() })
+	return clientConn
+}
+
+func rawRequest(opcode byte, archiveID uint8, value uint16) []byte {
+	return binary.BigEndian.AppendUint16([]byte{opcode, archiveID}, value)
+}
+
+func TestServerPriorityAndKey(t *testing.T) {
+	store := testStore()
+	conn := serve(t, store)
+
+	handshake := binary.BigEndian.AppendUint32([]byte{HandshakeOpcode}, testRevision)
+	if _, err := conn.Write(handshake); err != nil {
+		t.Fatal(err)
+	}
+	status := make([]byte, 1)
+	if _, err := io.ReadFull(conn, status); err != nil {
+		t.Fatal(err)
+	}
+	if status[0] != StatusOK {
+		t.Fatalf("status = %d", status[0])
+	}
+
+	const key = 0x5A
+	// The server starts writing group 1 and blocks on the pipe, so groups 2
+	// and 3 queue up behind it.
+	if _, err := conn.Write(append(rawRequest(OpcodeEncryptionKey, key, 0), rawRequest(OpcodeUrgentRequest, 2, 1)...)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write(append(rawRequest(OpcodePrefetchRequest, 2, 2), rawRequest(OpcodeUrgentRequest, 2, 3)...)); err != nil {
+		t.Fatal(err)
+	}
+	// The server only reads this once both requests above are queued.
+	if _, err := conn.Write(rawRequest(OpcodeConnected, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := &recorder{r: conn}
+	var k atomic.Uint32
+	k.Store(key)
+	reader := &xorReader{r: bufio.NewReader(raw), key: &k}
+
+	want := []struct {
+		group    uint16
+		prefetch bool
+	}{{1, false}, {3, false}, {2, true}}
+	for _, w := range want {
+		resp, err := readResponse(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.archiveID != 2 || resp.groupID != w.group || resp.prefetch != w.prefetch {
+			t.Fatalf("got group %d/%d prefetch %t, want 2/%d prefetch %t", resp.archiveID, resp.groupID, resp.prefetch, w.group, w.prefetch)
+		}
+		if !bytes.Equal(resp.container, store[2][uint32(w.group)]) {
+			t.Errorf("group %d container differs", w.group)
+		}
+	}
+
+	if raw.first[0] != 2^key {
+		t.Errorf("first response byte on the wire = %#x, want %#x", raw.first[0], 2^key)
+	}
+}
+
+// recorder remembers the first byte read through it.
+type recorder struct {
+	r     io.Reader
+	first []byte
+}
+
+func (r *recorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if r.first == nil && n > 0 {
+		r.first = []byte{p[0]}
+	}
+	return n, err
+}
+
+func TestClientLoopback(t *testing.T) {
+	store := testStore()
+	client, err := NewClient(serve(t, store), testRevision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.SetEncryptionKey(0x33); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := client.GroupList(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(groups, []uint32{1, 2, 3}) {
+		t.Errorf("groups = %v", groups)
+	}
+
+	data, err := client.Read(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, store[2][1]) {
+		t.Error("urgent group differs")
+	}
+
+	data, err = client.Prefetch(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The prefetch flag is stripped from the compression byte.
+	if !bytes.Equal(data, store[2][3]) {
+		t.Error("prefetched group differs")
+	}
+}
+
+func TestSessionPriority(t *testing.T) {
+	sess := newSession()
+	sess.push(request{groupID: 1, prefetch: true})
+	sess.push(request{groupID: 2})
+	sess.setKey(7)
+	sess.push(request{groupID: 3, prefetch: true})
+	sess.push(request{groupID: 4})
+
+	var order []uint16
+	for range 4 {
+		req, key, ok := sess.next()
+		if !ok || key != 7 {
+			t.Fatalf("next = %v, %d, %t", req, key, ok)
+		}
+		order = append(order, req.groupID)
+	}
+	if !slices.Equal(order, []uint16{2, 4, 1, 3}) {
+		t.Errorf("order = %v, want urgent requests first", order)
+	}
+
+	sess.close()
+	if _, _, ok := sess.next(); ok {
+		t.Error("next returned a request after close")
+	}
+}
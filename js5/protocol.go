@@ -0,0 +1,155 @@
+package js5
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+const (
+	HandshakeOpcode = 15
+
+	StatusOK         = 0
+	StatusOutOfDate  = 6
+	StatusServerFull = 7
+
+	BlockSize      = 512
+	BlockSeparator = 0xFF
+
+	// PrefetchFlag is set on the compression byte of responses to
+	// non-priority requests.
+	PrefetchFlag = 0x80
+
+	MasterIndexArchive = 255
+	MasterIndexGroup   = 255
+)
+
+const (
+	OpcodePrefetchRequest = 0
+	OpcodeUrgentRequest   = 1
+	OpcodeLoggedIn        = 2
+	OpcodeLoggedOut       = 3
+	OpcodeEncryptionKey   = 4
+	OpcodeConnected       = 6
+	OpcodeTerminate       = 7
+)
+
+const (
+	requestSize        = 4
+	responseHeaderSize = 3
+)
+
+// xorWriter applies the connection's encryption key to outgoing bytes.
+type xorWriter struct {
+	w   io.Writer
+	key byte
+	buf []byte
+}
+
+func (x *xorWriter) Write(p []byte) (int, error) {
+	if x.key == 0 {
+		return x.w.Write(p)
+	}
+	x.buf = append(x.buf[:0], p...)
+	for i := range x.buf {
+		x.buf[i] ^= x.key
+	}
+	return x.w.Write(x.buf)
+}
+
+// xorReader reverses the connection's encryption key on incoming bytes. The
+// key may be changed by another goroutine while a read is in progress.
+type xorReader struct {
+	r   io.Reader
+	key *atomic.Uint32
+}
+
+func (x *xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	if key := byte(x.key.Load()); key != 0 {
+		for i := range p[:n] {
+			p[i] ^= key
+		}
+	}
+	return n, err
+}
+
+// writeResponse writes a group response split into 512 byte blocks, each
+// block after the first starting with a separator byte.
+func writeResponse(w io.Writer, archiveID uint8, groupID uint16, container []byte, prefetch bool) error {
+	payload := make([]byte, 0, responseHeaderSize+len(container))
+	payload = append(payload, archiveID)
+	payload = binary.BigEndian.AppendUint16(payload, groupID)
+	payload = append(payload, container...)
+	if prefetch {
+		payload[responseHeaderSize] |= PrefetchFlag
+	}
+
+	out := make([]byte, 0, len(payload)+len(payload)/(BlockSize-1)+1)
+	n := min(BlockSize, len(payload))
+	out = append(out, payload[:n]...)
+	for payload = payload[n:]; len(payload) > 0; payload = payload[n:] {
+		n = min(BlockSize-1, len(payload))
+		out = append(out, BlockSeparator)
+		out = append(out, payload[:n]...)
+	}
+
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("writing response: %w", err)
+	}
+	return nil
+}
+
+type response struct {
+	archiveID uint8
+	groupID   uint16
+	prefetch  bool
+	container []byte
+}
+
+// readResponse reads a single group response written by writeResponse.
+func readResponse(r io.Reader) (*response, error) {
+	var header [responseHeaderSize + 5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("reading response header: %w", err)
+	}
+
+	resp := &response{
+		archiveID: header[0],
+		groupID:   binary.BigEndian.Uint16(header[1:3]),
+		prefetch:  header[3]&PrefetchFlag != 0,
+	}
+
+	compression := header[3] &^ PrefetchFlag
+	length := 5 + int(binary.BigEndian.Uint32(header[4:8]))
+	if compression != 0 {
+		length += 4
+	}
+
+	resp.container = make([]byte, length)
+	resp.container[0] = compression
+	copy(resp.container[1:5], header[4:8])
+
+	offset := len(header)
+	for read := 5; read < length; {
+		if offset%BlockSize == 0 {
+			var separator [1]byte
+			if _, err := io.ReadFull(r, separator[:]); err != nil {
+				return nil, fmt.Errorf("reading block separator: %w", err)
+			}
+			if separator[0] != BlockSeparator {
+				return nil, fmt.Errorf("invalid block separator: %#x", separator[0])
+			}
+			offset++
+		}
+
+		n := min(BlockSize-offset%BlockSize, length-read)
+		if _, err := io.ReadFull(r, resp.container[read:read+n]); err != nil {
+			return nil, fmt.Errorf("reading response data: %w", err)
+		}
+		read += n
+		offset += n
+	}
+	return resp, nil
+}
@@ -0,0 +1,275 @@
+package js5
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/joeychilson/osrscache"
+)
+
+// Server serves the groups of a Store to JS5 clients.
+type Server struct {
+	store       osrscache.Store
+	revision    uint32
+	masterIndex []byte
+	logger      *slog.Logger
+}
+
+type ServerOption func(*Server)
+
+// WithLogger sets the logger used to report connection errors. It defaults to
+// slog.Default.
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// NewServer creates a server for clients of the given revision. The master
+// index is built up front from the store's reference tables, so the store is
+// expected not to change while it is being served.
+func NewServer(store osrscache.Store, revision uint32, opts ...ServerOption) (*Server, error) {
+	masterIndex, err := buildMasterIndex(store)
+	if err != nil {
+		return nil, fmt.Errorf("building master index: %w", err)
+	}
+
+	s := &Server{store: store, revision: revision, masterIndex: masterIndex, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	defer l.Close()
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves each one in its own goroutine.
+// It returns nil once l is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+		go func() {
+			defer conn.Close()
+			if err := s.ServeConn(conn); err != nil {
+				s.logger.Warn("js5 connection closed", slog.String("remote", conn.RemoteAddr().String()), slog.Any("error", err))
+			}
+		}()
+	}
+}
+
+// ServeConn performs the handshake on conn and answers its requests until the
+// client disconnects or terminates the session. Urgent requests are always
+// answered before prefetch requests.
+func (s *Server) ServeConn(conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+
+	var handshake [5]byte
+	if _, err := io.ReadFull(reader, handshake[:]); err != nil {
+		return fmt.Errorf("reading handshake: %w", err)
+	}
+	if handshake[0] != HandshakeOpcode {
+		return fmt.Errorf("unexpected handshake opcode: %d", handshake[0])
+	}
+
+	revision := binary.BigEndian.Uint32(handshake[1:])
+	if revision != s.revision {
+		conn.Write([]byte{StatusOutOfDate})
+		return fmt.Errorf("client revision %d does not match server revision %d", revision, s.revision)
+	}
+	if _, err := conn.Write([]byte{StatusOK}); err != nil {
+		return fmt.Errorf("writing handshake status: %w", err)
+	}
+
+	sess := newSession()
+	writeErr := make(chan error, 1)
+	go func() {
+		err := s.writeLoop(conn, sess)
+		sess.close()
+		conn.Close()
+		writeErr <- err
+	}()
+
+	readErr := s.readLoop(reader, sess)
+	sess.close()
+	if err := <-writeErr; err != nil && readErr == nil {
+		return err
+	}
+	return readErr
+}
+
+func (s *Server) readLoop(reader io.Reader, sess *session) error {
+	for {
+		var req [requestSize]byte
+		if _, err := io.ReadFull(reader, req[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("reading request: %w", err)
+		}
+
+		switch req[0] {
+		case OpcodePrefetchRequest, OpcodeUrgentRequest:
+			sess.push(request{
+				archiveID: req[1],
+				groupID:   binary.BigEndian.Uint16(req[2:]),
+				prefetch:  req[0] == OpcodePrefetchRequest,
+			})
+		case OpcodeEncryptionKey:
+			sess.setKey(req[1])
+		case OpcodeLoggedIn, OpcodeLoggedOut:
+			s.logger.Debug("js5 client login state changed", slog.Bool("logged_in", req[0] == OpcodeLoggedIn))
+		case OpcodeConnected:
+		case OpcodeTerminate:
+			return nil
+		default:
+			return fmt.Errorf("unknown request opcode: %d", req[0])
+		}
+	}
+}
+
+func (s *Server) writeLoop(conn net.Conn, sess *session) error {
+	writer := &xorWriter{w: conn}
+	for {
+		req, key, ok := sess.next()
+		if !ok {
+			return nil
+		}
+		writer.key = key
+
+		container, err := s.group(req.archiveID, uint32(req.groupID))
+		if err != nil {
+			return fmt.Errorf("reading group %d in archive %d: %w", req.groupID, req.archiveID, err)
+		}
+		if err := writeResponse(writer, req.archiveID, req.groupID, container, req.prefetch); err != nil {
+			return err
+		}
+	}
+}
+
+type request struct {
+	archiveID uint8
+	groupID   uint16
+	prefetch  bool
+}
+
+// session holds the request queues and state of a single connection. Requests
+// are pushed by the read loop and consumed by the write loop.
+type session struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	urgent   []request
+	prefetch []request
+	key      byte
+	closed   bool
+}
+
+func newSession() *session {
+	sess := &session{}
+	sess.cond = sync.NewCond(&sess.mu)
+	return sess
+}
+
+func (s *session) push(req request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.prefetch {
+		s.prefetch = append(s.prefetch, req)
+	} else {
+		s.urgent = append(s.urgent, req)
+	}
+	s.cond.Signal()
+}
+
+// setKey changes the encryption key for every response written after the one
+// currently in progress.
+func (s *session) setKey(key byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+}
+
+// next blocks until a request is queued and returns it with the encryption
+// key to write it with. Prefetch requests are only returned once no urgent
+// requests are waiting.
+func (s *session) next() (request, byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.closed && len(s.urgent) == 0 && len(s.prefetch) == 0 {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return request{}, 0, false
+	}
+
+	var req request
+	if len(s.urgent) > 0 {
+		req, s.urgent = s.urgent[0], s.urgent[1:]
+	} else {
+		req, s.prefetch = s.prefetch[0], s.prefetch[1:]
+	}
+	return req, s.key, true
+}
+
+func (s *session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
+
+func (s *Server) group(archiveID uint8, groupID uint32) ([]byte, error) {
+	if archiveID == MasterIndexArchive && groupID == MasterIndexGroup {
+		return s.masterIndex, nil
+	}
+
+	data, err := s.store.Read(archiveID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := osrscache.ContainerLength(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:length], nil
+}
+
+// buildMasterIndex builds an uncompressed container holding the checksum and
+// version of every archive's reference table.
+func buildMasterIndex(store osrscache.Store) ([]byte, error) {
+	masterIndex, err := osrscache.New(store).MasterIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := masterIndex.Encode(osrscache.MasterIndexFormatVersioned, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	container := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(container[1:], uint32(len(data)))
+	return append(container, data...), nil
+}
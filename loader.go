@@ -0,0 +1,138 @@
+package osrscache
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	concurrency int
+}
+
+// WithConcurrency sets the number of workers used to decode definitions.
+// Values below one fall back to the default of GOMAXPROCS.
+func WithConcurrency(n int) LoadOption {
+	return func(o *loadOptions) {
+		o.concurrency = n
+	}
+}
+
+func newLoadOptions(opts []LoadOption) *loadOptions {
+	o := &loadOptions{concurrency: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = runtime.GOMAXPROCS(0)
+	}
+	return o
+}
+
+type DecodeError struct {
+	ID  uint32
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding %d: %v", e.ID, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeErrors aggregates the per-ID failures of a bulk load, sorted by ID.
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%d definitions failed to decode, first: %v", len(e), e[0])
+}
+
+func (e DecodeErrors) sort() {
+	slices.SortFunc(e, func(a, b *DecodeError) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+func (e DecodeErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// decodeConcurrent decodes every id with a pool of workers. Definitions that
+// fail are collected into DecodeErrors and the successfully decoded ones are
+// still returned. Cancelling ctx stops the workers and returns ctx.Err().
+func decodeConcurrent[T any](ctx context.Context, ids []uint32, opts []LoadOption, decode func(id uint32) (T, error)) (map[uint16]T, error) {
+	options := newLoadOptions(opts)
+
+	jobs := make(chan uint32)
+	go func() {
+		defer close(jobs)
+		for _, id := range ids {
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[uint16]T, len(ids))
+		errs    DecodeErrors
+	)
+	for i := 0; i < min(options.concurrency, len(ids)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				def, err := decode(id)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, &DecodeError{ID: id, Err: err})
+				} else {
+					results[uint16(id)] = def
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		errs.sort()
+		return results, errs
+	}
+	return results, nil
+}
+
+func fileIDs(files map[uint32][]byte) []uint32 {
+	ids := make([]uint32, 0, len(files))
+	for id := range files {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
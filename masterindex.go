@@ -0,0 +1,281 @@
+package osrscache
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+
+	"github.com/joeychilson/osrscache/whirlpool"
+)
+
+type MasterIndexFormat uint8
+
+const (
+	// MasterIndexFormatOriginal lists the checksum of each reference table.
+	MasterIndexFormatOriginal MasterIndexFormat = iota
+	// MasterIndexFormatVersioned adds the version of each reference table.
+	MasterIndexFormatVersioned
+	// MasterIndexFormatDigests prefixes the archive count, adds a Whirlpool
+	// digest of each reference table and ends with a signature block.
+	MasterIndexFormatDigests
+	// MasterIndexFormatLengths adds the group count and total uncompressed
+	// length of each archive.
+	MasterIndexFormatLengths
+)
+
+const masterIndexArchive = 255
+
+type MasterIndexEntry struct {
+	Checksum           uint32 `json:"checksum"`
+	Version            uint32 `json:"version"`
+	GroupCount         uint32 `json:"group_count"`
+	UncompressedLength uint32 `json:"uncompressed_length"`
+	Digest             []byte `json:"digest"`
+}
+
+// MasterIndex is the archive 255 checksum table clients use to validate
+// their cache, with one entry per archive ID.
+type MasterIndex struct {
+	Format  MasterIndexFormat  `json:"format"`
+	Entries []MasterIndexEntry `json:"entries"`
+}
+
+// MasterIndex builds the master index from the reference tables in the cache.
+func (c *Cache) MasterIndex() (*MasterIndex, error) {
+	archives, err := c.Store.ArchiveList()
+	if err != nil {
+		return nil, fmt.Errorf("listing archives: %w", err)
+	}
+
+	var count int
+	for _, archiveID := range archives {
+		if archiveID != masterIndexArchive {
+			count = max(count, int(archiveID)+1)
+		}
+	}
+
+	masterIndex := &MasterIndex{
+		Format:  MasterIndexFormatLengths,
+		Entries: make([]MasterIndexEntry, count),
+	}
+	for archiveID := range masterIndex.Entries {
+		if !c.Store.GroupExists(masterIndexArchive, uint32(archiveID)) {
+			continue
+		}
+
+		entry, err := c.masterIndexEntry(uint8(archiveID))
+		if err != nil {
+			return nil, fmt.Errorf("reading reference table %d: %w", archiveID, err)
+		}
+		masterIndex.Entries[archiveID] = *entry
+	}
+	return masterIndex, nil
+}
+
+func (c *Cache) masterIndexEntry(archiveID uint8) (*MasterIndexEntry, error) {
+	data, err := c.Store.Read(masterIndexArchive, uint32(archiveID))
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := ContainerLength(data)
+	if err != nil {
+		return nil, &CorruptGroupError{Archive: masterIndexArchive, Group: uint32(archiveID), Reason: err.Error()}
+	}
+	container := data[:length]
+
+	decompressed, err := DecompressData(container)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := ReadIndex(decompressed)
+	if err != nil {
+		return nil, &CorruptGroupError{Archive: masterIndexArchive, Group: uint32(archiveID), Reason: err.Error()}
+	}
+
+	var uncompressedLength uint32
+	for _, group := range index.Groups {
+		uncompressedLength += uint32(group.UncompressedLength)
+	}
+
+	digest := whirlpool.Sum(container)
+	return &MasterIndexEntry{
+		Checksum:           crc32.ChecksumIEEE(container),
+		Version:            index.Version,
+		GroupCount:         uint32(len(index.Groups)),
+		UncompressedLength: uncompressedLength,
+		Digest:             digest[:],
+	}, nil
+}
+
+// Encode serializes the master index in the given format. For the formats with
+// digests, the trailing Whirlpool digest is RSA signed with key, or written in
+// the clear when key is nil.
+func (m *MasterIndex) Encode(format MasterIndexFormat, key *rsa.PrivateKey) ([]byte, error) {
+	var buf []byte
+	if format >= MasterIndexFormatDigests {
+		if len(m.Entries) > 0xFF {
+			return nil, fmt.Errorf("too many archives for format %d: %d", format, len(m.Entries))
+		}
+		buf = append(buf, uint8(len(m.Entries)))
+	}
+
+	for _, entry := range m.Entries {
+		buf = binary.BigEndian.AppendUint32(buf, entry.Checksum)
+		if format >= MasterIndexFormatVersioned {
+			buf = binary.BigEndian.AppendUint32(buf, entry.Version)
+		}
+		if format >= MasterIndexFormatLengths {
+			buf = binary.BigEndian.AppendUint32(buf, entry.GroupCount)
+			buf = binary.BigEndian.AppendUint32(buf, entry.UncompressedLength)
+		}
+		if format >= MasterIndexFormatDigests {
+			buf = append(buf, paddedDigest(entry.Digest)...)
+		}
+	}
+
+	if format >= MasterIndexFormatDigests {
+		digest := whirlpool.Sum(buf)
+		block := append([]byte{0}, digest[:]...)
+		if key != nil {
+			block = new(big.Int).Exp(new(big.Int).SetBytes(block), key.D, key.N).Bytes()
+		}
+		buf = append(buf, block...)
+	}
+	return buf, nil
+}
+
+// ReadMasterIndex decodes a master index in the given format. For the formats
+// with digests, the signature block is checked against the entries, decrypting
+// it with key first when key is not nil.
+func ReadMasterIndex(data []byte, format MasterIndexFormat, key *rsa.PublicKey) (*MasterIndex, error) {
+	reader := NewReader(data)
+
+	entrySize := 4
+	if format >= MasterIndexFormatVersioned {
+		entrySize += 4
+	}
+	if format >= MasterIndexFormatLengths {
+		entrySize += 8
+	}
+	if format >= MasterIndexFormatDigests {
+		entrySize += whirlpool.Size
+	}
+
+	var count int
+	if format >= MasterIndexFormatDigests {
+		n, err := reader.ReadUint8()
+		if err != nil {
+			return nil, fmt.Errorf("reading archive count: %w", err)
+		}
+		count = int(n)
+	} else {
+		count = len(data) / entrySize
+	}
+
+	masterIndex := &MasterIndex{Format: format, Entries: make([]MasterIndexEntry, count)}
+	for i := range masterIndex.Entries {
+		entry := &masterIndex.Entries[i]
+
+		var err error
+		entry.Checksum, err = reader.ReadUint32()
+		if err != nil {
+			return nil, fmt.Errorf("reading checksum: %w", err)
+		}
+		if format >= MasterIndexFormatVersioned {
+			entry.Version, err = reader.ReadUint32()
+			if err != nil {
+				return nil, fmt.Errorf("reading version: %w", err)
+			}
+		}
+		if format >= MasterIndexFormatLengths {
+			entry.GroupCount, err = reader.ReadUint32()
+			if err != nil {
+				return nil, fmt.Errorf("reading group count: %w", err)
+			}
+			entry.UncompressedLength, err = reader.ReadUint32()
+			if err != nil {
+				return nil, fmt.Errorf("reading uncompressed length: %w", err)
+			}
+		}
+		if format >= MasterIndexFormatDigests {
+			entry.Digest, err = reader.ReadBytes(whirlpool.Size)
+			if err != nil {
+				return nil, fmt.Errorf("reading digest: %w", err)
+			}
+		}
+	}
+
+	if format >= MasterIndexFormatDigests {
+		signed := data[:len(data)-reader.Len()]
+		block, err := reader.ReadBytes(reader.Len())
+		if err != nil {
+			return nil, fmt.Errorf("reading signature: %w", err)
+		}
+		if key != nil {
+			block = new(big.Int).Exp(new(big.Int).SetBytes(block), big.NewInt(int64(key.E)), key.N).Bytes()
+		}
+		if len(block) < whirlpool.Size {
+			block = append(make([]byte, whirlpool.Size-len(block)), block...)
+		}
+
+		digest := whirlpool.Sum(signed)
+		if !bytes.Equal(block[len(block)-whirlpool.Size:], digest[:]) {
+			return nil, errors.New("master index digest does not match signature")
+		}
+	}
+	return masterIndex, nil
+}
+
+// VerifyMasterIndex compares a master index, e.g. one received from a server,
+// against the reference tables in the local cache. Only the fields present in
+// the master index's format are compared.
+func (c *Cache) VerifyMasterIndex(m *MasterIndex) error {
+	local, err := c.MasterIndex()
+	if err != nil {
+		return fmt.Errorf("building local master index: %w", err)
+	}
+
+	var errs []error
+	for archiveID := 0; archiveID < max(len(m.Entries), len(local.Entries)); archiveID++ {
+		var want, got MasterIndexEntry
+		if archiveID < len(m.Entries) {
+			want = m.Entries[archiveID]
+		}
+		if archiveID < len(local.Entries) {
+			got = local.Entries[archiveID]
+		}
+
+		if want.Checksum != got.Checksum {
+			errs = append(errs, fmt.Errorf("archive %d: checksum %d, expected %d", archiveID, got.Checksum, want.Checksum))
+		}
+		if m.Format >= MasterIndexFormatVersioned && want.Version != got.Version {
+			errs = append(errs, fmt.Errorf("archive %d: version %d, expected %d", archiveID, got.Version, want.Version))
+		}
+		if m.Format >= MasterIndexFormatLengths {
+			if want.GroupCount != got.GroupCount {
+				errs = append(errs, fmt.Errorf("archive %d: group count %d, expected %d", archiveID, got.GroupCount, want.GroupCount))
+			}
+			if want.UncompressedLength != got.UncompressedLength {
+				errs = append(errs, fmt.Errorf("archive %d: uncompressed length %d, expected %d", archiveID, got.UncompressedLength, want.UncompressedLength))
+			}
+		}
+		if m.Format >= MasterIndexFormatDigests && !bytes.Equal(paddedDigest(want.Digest), paddedDigest(got.Digest)) {
+			errs = append(errs, fmt.Errorf("archive %d: digest mismatch", archiveID))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// paddedDigest treats missing digests as all zeroes, which is how absent
+// archives are encoded.
+func paddedDigest(digest []byte) []byte {
+	padded := make([]byte, whirlpool.Size)
+	copy(padded, digest)
+	return padded
+}
@@ -0,0 +1,143 @@
+package osrscache
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testMasterIndex() *MasterIndex {
+	entries := make([]MasterIndexEntry, 3)
+	for i := range entries {
+		digest := make([]byte, 64)
+		digest[0], digest[63] = byte(i), 0xA0
+		entries[i] = MasterIndexEntry{
+			Checksum:           0xDEADBEEF + uint32(i),
+			Version:            uint32(100 + i),
+			GroupCount:         uint32(10 * i),
+			UncompressedLength: uint32(5000 * i),
+			Digest:             digest,
+		}
+	}
+	// An absent archive has no reference table and so no digest.
+	entries[1] = MasterIndexEntry{}
+	return &MasterIndex{Format: MasterIndexFormatLengths, Entries: entries}
+}
+
+func TestMasterIndexRoundTrip(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formats := []MasterIndexFormat{
+		MasterIndexFormatOriginal,
+		MasterIndexFormatVersioned,
+		MasterIndexFormatDigests,
+		MasterIndexFormatLengths,
+	}
+	for _, format := range formats {
+		for _, signed := range []bool{false, true} {
+			var private *rsa.PrivateKey
+			var public *rsa.PublicKey
+			if signed {
+				private, public = key, &key.PublicKey
+			}
+
+			data, err := testMasterIndex().Encode(format, private)
+			if err != nil {
+				t.Fatalf("format %d: %v", format, err)
+			}
+			got, err := ReadMasterIndex(data, format, public)
+			if err != nil {
+				t.Fatalf("format %d, signed %t: %v", format, signed, err)
+			}
+
+			// Only the fields of the format survive, and absent digests
+			// come back as zeroes.
+			want := testMasterIndex()
+			want.Format = format
+			for i := range want.Entries {
+				entry := &want.Entries[i]
+				if format < MasterIndexFormatVersioned {
+					entry.Version = 0
+				}
+				if format < MasterIndexFormatLengths {
+					entry.GroupCount, entry.UncompressedLength = 0, 0
+				}
+				if format < MasterIndexFormatDigests {
+					entry.Digest = nil
+				} else {
+					entry.Digest = paddedDigest(entry.Digest)
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("format %d, signed %t = %+v, want %+v", format, signed, got, want)
+			}
+
+			if format < MasterIndexFormatDigests {
+				continue
+			}
+			data[1] ^= 1
+			if _, err := ReadMasterIndex(data, format, public); err == nil {
+				t.Errorf("format %d, signed %t: want an error for a tampered entry", format, signed)
+			}
+			data[1] ^= 1
+			if signed {
+				if _, err := ReadMasterIndex(data, format, nil); err == nil {
+					t.Errorf("format %d: want an error reading a signed index without the key", format)
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyMasterIndex(t *testing.T) {
+	cache := New(memStore{
+		2: {0: {1}, 1: {2}},
+		5: {3: {3}},
+	})
+	masterIndex, err := cache.MasterIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(masterIndex.Entries) != 6 || masterIndex.Entries[5].GroupCount != 1 || masterIndex.Entries[3].Checksum != 0 {
+		t.Fatalf("master index = %+v, want entries for archives 2 and 5 of 6", masterIndex.Entries)
+	}
+
+	// A server's copy arrives encoded.
+	data, err := masterIndex.Encode(MasterIndexFormatDigests, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := ReadMasterIndex(data, MasterIndexFormatDigests, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.VerifyMasterIndex(remote); err != nil {
+		t.Fatalf("verifying an untampered index: %v", err)
+	}
+
+	remote.Entries[2].Checksum++
+	remote.Entries[5].Digest[0] ^= 1
+	err = cache.VerifyMasterIndex(remote)
+	if err == nil {
+		t.Fatal("want an error for tampered entries")
+	}
+	for _, want := range []string{"archive 2: checksum", "archive 5: digest mismatch"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	// Fields outside the format are not compared.
+	remote = &MasterIndex{Format: MasterIndexFormatOriginal, Entries: make([]MasterIndexEntry, 6)}
+	for i, entry := range masterIndex.Entries {
+		remote.Entries[i].Checksum = entry.Checksum
+	}
+	if err := cache.VerifyMasterIndex(remote); err != nil {
+		t.Errorf("verifying checksums only: %v", err)
+	}
+}
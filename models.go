@@ -0,0 +1,596 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Model is a 3D model from archive 7: triangular faces over a shared set of
+// vertices. Optional per-face arrays are nil when the model does not use
+// them.
+type Model struct {
+	ID      uint16  `json:"id"`
+	VertexX []int32 `json:"vertex_x"`
+	VertexY []int32 `json:"vertex_y"`
+	VertexZ []int32 `json:"vertex_z"`
+	// VertexSkins group the vertices moved together by animations.
+	VertexSkins []uint8 `json:"vertex_skins,omitempty"`
+
+	// FaceA, FaceB and FaceC index the vertices of each face.
+	FaceA      []int32  `json:"face_a"`
+	FaceB      []int32  `json:"face_b"`
+	FaceC      []int32  `json:"face_c"`
+	FaceColors []uint16 `json:"face_colors"`
+	// Priority orders the faces when drawing, unless FacePriorities gives
+	// each face its own.
+	Priority           uint8   `json:"priority"`
+	FacePriorities     []uint8 `json:"face_priorities,omitempty"`
+	FaceRenderTypes    []uint8 `json:"face_render_types,omitempty"`
+	FaceTransparencies []uint8 `json:"face_transparencies,omitempty"`
+	FaceSkins          []uint8 `json:"face_skins,omitempty"`
+	// FaceTextures are the texture of each face, -1 where untextured.
+	FaceTextures []int16 `json:"face_textures,omitempty"`
+	// FaceTextureCoords index the texture triangle mapping each textured
+	// face, -1 to map the texture over the face's own vertices.
+	FaceTextureCoords []int16 `json:"face_texture_coords,omitempty"`
+
+	// TextureA, TextureB and TextureC index the vertices of the triangles
+	// that textures are mapped over.
+	TextureA []int32 `json:"texture_a,omitempty"`
+	TextureB []int32 `json:"texture_b,omitempty"`
+	TextureC []int32 `json:"texture_c,omitempty"`
+}
+
+func NewModel(id uint16) *Model {
+	return &Model{ID: id}
+}
+
+// modelHeader is the footer of a model, giving the counts and flags that lay
+// out its sections.
+type modelHeader struct {
+	vertices, faces, textures int
+	renderTypes               bool
+	priority                  uint8
+	transparencies            bool
+	faceSkins                 bool
+	faceTextures              bool
+	vertexSkins               bool
+	// skinLength is the length of the vertex skin section, which animaya
+	// models extend with their skeletal groups.
+	skinLength                             int
+	xLength, yLength, zLength, indexLength int
+	coordLength                            int
+}
+
+// Read decodes a model in any of the formats the client loads, told apart by
+// the last two bytes: FF FF for the new format, FF FE and FF FD for the old
+// and new formats with animaya groups, and anything else for the old format.
+func (m *Model) Read(data []byte) error {
+	if len(data) < 2 {
+		return fmt.Errorf("model is %d bytes", len(data))
+	}
+	switch magic := binary.BigEndian.Uint16(data[len(data)-2:]); magic {
+	case 0xFFFF:
+		return m.readNew(data, false)
+	case 0xFFFD:
+		return m.readNew(data, true)
+	case 0xFFFE:
+		return m.readOld(data, true)
+	default:
+		return m.readOld(data, false)
+	}
+}
+
+// modelFooter returns the last n bytes of data.
+func modelFooter(data []byte, n int) ([]byte, error) {
+	if len(data) < n {
+		return nil, fmt.Errorf("model is %d bytes, shorter than its %d byte footer", len(data), n)
+	}
+	return data[len(data)-n:], nil
+}
+
+func (m *Model) readNew(data []byte, animaya bool) error {
+	size := 23
+	if animaya {
+		size = 26
+	}
+	footer, err := modelFooter(data, size)
+	if err != nil {
+		return err
+	}
+
+	h := modelHeader{
+		vertices:       int(binary.BigEndian.Uint16(footer[0:])),
+		faces:          int(binary.BigEndian.Uint16(footer[2:])),
+		textures:       int(footer[4]),
+		renderTypes:    footer[5]&1 != 0,
+		priority:       footer[6],
+		transparencies: footer[7] == 1,
+		faceSkins:      footer[8] == 1,
+		faceTextures:   footer[9] == 1,
+		vertexSkins:    footer[10] == 1,
+	}
+	lengths := footer[11:]
+	if animaya {
+		lengths = footer[12:]
+	}
+	h.xLength = int(binary.BigEndian.Uint16(lengths[0:]))
+	h.yLength = int(binary.BigEndian.Uint16(lengths[2:]))
+	h.zLength = int(binary.BigEndian.Uint16(lengths[4:]))
+	h.indexLength = int(binary.BigEndian.Uint16(lengths[6:]))
+	h.coordLength = int(binary.BigEndian.Uint16(lengths[8:]))
+	if animaya {
+		h.skinLength = int(binary.BigEndian.Uint16(lengths[10:]))
+	} else if h.vertexSkins {
+		h.skinLength = h.vertices
+	}
+
+	sections, err := modelSections(data[:len(data)-size],
+		h.textures,
+		h.vertices,
+		optional(h.renderTypes, h.faces),
+		h.faces,
+		optional(h.priority == 255, h.faces),
+		optional(h.faceSkins, h.faces),
+		h.skinLength,
+		optional(h.transparencies, h.faces),
+		h.indexLength,
+		optional(h.faceTextures, 2*h.faces),
+		h.coordLength,
+		2*h.faces,
+		h.xLength,
+		h.yLength,
+		h.zLength,
+		6*h.textures,
+	)
+	if err != nil {
+		return err
+	}
+	textureTypes, vertexFlags, renderTypes, faceTypes := sections[0], sections[1], sections[2], sections[3]
+	priorities, faceSkins, vertexSkins, transparencies := sections[4], sections[5], sections[6], sections[7]
+	indices, faceTextures, coords, colors := sections[8], sections[9], sections[10], sections[11]
+	x, y, z, textures := sections[12], sections[13], sections[14], sections[15]
+
+	// Only simple texture triangles, mapped over three vertices, are used.
+	for i := range h.textures {
+		renderType, err := textureTypes.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading texture render type: %w", err)
+		}
+		if renderType != 0 {
+			return fmt.Errorf("texture %d has unsupported render type %d", i, renderType)
+		}
+	}
+
+	if err := m.readVertices(h, vertexFlags, x, y, z, vertexSkins); err != nil {
+		return err
+	}
+
+	m.Priority = h.priority
+	m.FaceColors = make([]uint16, h.faces)
+	if h.renderTypes {
+		m.FaceRenderTypes = make([]uint8, h.faces)
+	}
+	if h.priority == 255 {
+		m.FacePriorities = make([]uint8, h.faces)
+	}
+	if h.transparencies {
+		m.FaceTransparencies = make([]uint8, h.faces)
+	}
+	if h.faceSkins {
+		m.FaceSkins = make([]uint8, h.faces)
+	}
+	if h.faceTextures {
+		m.FaceTextures = make([]int16, h.faces)
+		m.FaceTextureCoords = make([]int16, h.faces)
+	}
+	for i := range h.faces {
+		if m.FaceColors[i], err = colors.ReadUint16(); err != nil {
+			return fmt.Errorf("reading face color: %w", err)
+		}
+		if h.renderTypes {
+			if m.FaceRenderTypes[i], err = renderTypes.ReadUint8(); err != nil {
+				return fmt.Errorf("reading face render type: %w", err)
+			}
+		}
+		if h.priority == 255 {
+			if m.FacePriorities[i], err = priorities.ReadUint8(); err != nil {
+				return fmt.Errorf("reading face priority: %w", err)
+			}
+		}
+		if h.transparencies {
+			if m.FaceTransparencies[i], err = transparencies.ReadUint8(); err != nil {
+				return fmt.Errorf("reading face transparency: %w", err)
+			}
+		}
+		if h.faceSkins {
+			if m.FaceSkins[i], err = faceSkins.ReadUint8(); err != nil {
+				return fmt.Errorf("reading face skin: %w", err)
+			}
+		}
+		if h.faceTextures {
+			texture, err := faceTextures.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading face texture: %w", err)
+			}
+			m.FaceTextures[i] = int16(int32(texture) - 1)
+			m.FaceTextureCoords[i] = -1
+			if m.FaceTextures[i] != -1 {
+				coord, err := coords.ReadUint8()
+				if err != nil {
+					return fmt.Errorf("reading face texture coordinate: %w", err)
+				}
+				m.FaceTextureCoords[i] = int16(coord) - 1
+			}
+		}
+	}
+
+	if err := m.readFaces(h, faceTypes, indices); err != nil {
+		return err
+	}
+	return m.readTextures(h, textures)
+}
+
+func (m *Model) readOld(data []byte, animaya bool) error {
+	size := 18
+	if animaya {
+		size = 23
+	}
+	footer, err := modelFooter(data, size)
+	if err != nil {
+		return err
+	}
+
+	h := modelHeader{
+		vertices:       int(binary.BigEndian.Uint16(footer[0:])),
+		faces:          int(binary.BigEndian.Uint16(footer[2:])),
+		textures:       int(footer[4]),
+		renderTypes:    footer[5] == 1,
+		priority:       footer[6],
+		transparencies: footer[7] == 1,
+		faceSkins:      footer[8] == 1,
+		vertexSkins:    footer[9] == 1,
+	}
+	lengths := footer[10:]
+	if animaya {
+		lengths = footer[11:]
+	}
+	h.xLength = int(binary.BigEndian.Uint16(lengths[0:]))
+	h.yLength = int(binary.BigEndian.Uint16(lengths[2:]))
+	h.zLength = int(binary.BigEndian.Uint16(lengths[4:]))
+	h.indexLength = int(binary.BigEndian.Uint16(lengths[6:]))
+	if animaya {
+		h.skinLength = int(binary.BigEndian.Uint16(lengths[8:]))
+	} else if h.vertexSkins {
+		h.skinLength = h.vertices
+	}
+
+	sections, err := modelSections(data[:len(data)-size],
+		h.vertices,
+		h.faces,
+		optional(h.priority == 255, h.faces),
+		optional(h.faceSkins, h.faces),
+		optional(h.renderTypes, h.faces),
+		h.skinLength,
+		optional(h.transparencies, h.faces),
+		h.indexLength,
+		2*h.faces,
+		6*h.textures,
+		h.xLength,
+		h.yLength,
+		h.zLength,
+	)
+	if err != nil {
+		return err
+	}
+	vertexFlags, faceTypes, priorities, faceSkins := sections[0], sections[1], sections[2], sections[3]
+	renderInfo, vertexSkins, transparencies, indices := sections[4], sections[5], sections[6], sections[7]
+	colors, textures := sections[8], sections[9]
+	x, y, z := sections[10], sections[11], sections[12]
+
+	if err := m.readVertices(h, vertexFlags, x, y, z, vertexSkins); err != nil {
+		return err
+	}
+
+	m.Priority = h.priority
+	m.FaceColors = make([]uint16, h.faces)
+	if h.renderTypes {
+		m.FaceRenderTypes = make([]uint8, h.faces)
+		m.FaceTextures = make([]int16, h.faces)
+		m.FaceTextureCoords = make([]int16, h.faces)
+	}
+	if h.priority == 255 {
+		m.FacePriorities = make([]uint8, h.faces)
+	}
+	if h.transparencies {
+		m.FaceTransparencies = make([]uint8, h.faces)
+	}
+	if h.faceSkins {
+		m.FaceSkins = make([]uint8, h.faces)
+	}
+	for i := range h.faces {
+		if m.FaceColors[i], err = colors.ReadUint16(); err != nil {
+			return fmt.Errorf("reading face color: %w", err)
+		}
+		// The old format packs the render type and texture of a face into
+		// one byte, storing the texture in place of the colour.
+		if h.renderTypes {
+			info, err := renderInfo.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading face render info: %w", err)
+			}
+			m.FaceRenderTypes[i] = info & 1
+			m.FaceTextures[i] = -1
+			m.FaceTextureCoords[i] = -1
+			if info&2 != 0 {
+				m.FaceTextureCoords[i] = int16(info >> 2)
+				m.FaceTextures[i] = int16(m.FaceColors[i])
+				m.FaceColors[i] = 127
+			}
+		}
+		if h.priority == 255 {
+			if m.FacePriorities[i], err = priorities.ReadUint8(); err != nil {
+				return fmt.Errorf("reading face priority: %w", err)
+			}
+		}
+		if h.transparencies {
+			if m.FaceTransparencies[i], err = transparencies.ReadUint8(); err != nil {
+				return fmt.Errorf("reading face transparency: %w", err)
+			}
+		}
+		if h.faceSkins {
+			if m.FaceSkins[i], err = faceSkins.ReadUint8(); err != nil {
+				return fmt.Errorf("reading face skin: %w", err)
+			}
+		}
+	}
+
+	if err := m.readFaces(h, faceTypes, indices); err != nil {
+		return err
+	}
+	return m.readTextures(h, textures)
+}
+
+// optional returns length if present is set and 0 otherwise.
+func optional(present bool, length int) int {
+	if present {
+		return length
+	}
+	return 0
+}
+
+// modelSections splits data into consecutive sections of the given lengths.
+func modelSections(data []byte, lengths ...int) ([]*Reader, error) {
+	sections := make([]*Reader, len(lengths))
+	offset := 0
+	for i, length := range lengths {
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("model section %d ends at %d, past the end of %d bytes of data", i, offset+length, len(data))
+		}
+		sections[i] = NewReader(data[offset : offset+length])
+		offset += length
+	}
+	return sections, nil
+}
+
+// readVertices reads the vertex positions, each stored as a delta from the
+// previous vertex on the axes its flag byte marks.
+func (m *Model) readVertices(h modelHeader, flags, x, y, z, skins *Reader) error {
+	m.VertexX = make([]int32, h.vertices)
+	m.VertexY = make([]int32, h.vertices)
+	m.VertexZ = make([]int32, h.vertices)
+	if h.vertexSkins {
+		m.VertexSkins = make([]uint8, h.vertices)
+	}
+
+	axes := []struct {
+		bit    uint8
+		reader *Reader
+		prev   int32
+		out    []int32
+	}{{1, x, 0, m.VertexX}, {2, y, 0, m.VertexY}, {4, z, 0, m.VertexZ}}
+	for i := range h.vertices {
+		flag, err := flags.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading vertex flags: %w", err)
+		}
+		for j := range axes {
+			axis := &axes[j]
+			if flag&axis.bit != 0 {
+				delta, err := axis.reader.ReadInt16Smart()
+				if err != nil {
+					return fmt.Errorf("reading vertex %d: %w", i, err)
+				}
+				axis.prev += int32(delta)
+			}
+			axis.out[i] = axis.prev
+		}
+		if h.vertexSkins {
+			if m.VertexSkins[i], err = skins.ReadUint8(); err != nil {
+				return fmt.Errorf("reading vertex skin: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// readFaces reads the vertex indices of the faces. Each face either gives all
+// three indices or reuses two from the previous face, and every new index is
+// a delta from the last index read.
+func (m *Model) readFaces(h modelHeader, types, indices *Reader) error {
+	m.FaceA = make([]int32, h.faces)
+	m.FaceB = make([]int32, h.faces)
+	m.FaceC = make([]int32, h.faces)
+
+	var a, b, c, prev int32
+	next := func() (int32, error) {
+		delta, err := indices.ReadInt16Smart()
+		if err != nil {
+			return 0, fmt.Errorf("reading face index: %w", err)
+		}
+		prev += int32(delta)
+		return prev, nil
+	}
+	for i := range h.faces {
+		faceType, err := types.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading face type: %w", err)
+		}
+		switch faceType {
+		case 1:
+			if a, err = next(); err != nil {
+				return err
+			}
+			if b, err = next(); err != nil {
+				return err
+			}
+		case 2:
+			b = c
+		case 3:
+			a = c
+		case 4:
+			a, b = b, a
+		default:
+			return fmt.Errorf("face %d has unknown type %d", i, faceType)
+		}
+		if c, err = next(); err != nil {
+			return err
+		}
+		m.FaceA[i], m.FaceB[i], m.FaceC[i] = a, b, c
+	}
+	return nil
+}
+
+// readTextures reads the three vertex indices of each texture triangle.
+func (m *Model) readTextures(h modelHeader, reader *Reader) error {
+	m.TextureA = make([]int32, h.textures)
+	m.TextureB = make([]int32, h.textures)
+	m.TextureC = make([]int32, h.textures)
+	for i := range h.textures {
+		for _, out := range [][]int32{m.TextureA, m.TextureB, m.TextureC} {
+			index, err := reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading texture triangle: %w", err)
+			}
+			out[i] = int32(index)
+		}
+	}
+	return nil
+}
+
+// Translate moves every vertex of the model by x, y and z.
+func (m *Model) Translate(x, y, z int32) {
+	for i := range m.VertexX {
+		m.VertexX[i] += x
+		m.VertexY[i] += y
+		m.VertexZ[i] += z
+	}
+}
+
+// Recolor replaces each colour in from with the colour at the same index in
+// to, in order, so a later pair can recolour the result of an earlier one.
+func (m *Model) Recolor(from, to []uint16) {
+	for i := range min(len(from), len(to)) {
+		for face, color := range m.FaceColors {
+			if color == from[i] {
+				m.FaceColors[face] = to[i]
+			}
+		}
+	}
+}
+
+// Retexture replaces each texture in from with the texture at the same index
+// in to, in order.
+func (m *Model) Retexture(from, to []uint16) {
+	for i := range min(len(from), len(to)) {
+		for face, texture := range m.FaceTextures {
+			if texture == int16(from[i]) {
+				m.FaceTextures[face] = int16(to[i])
+			}
+		}
+	}
+}
+
+// MergeModels joins models into one the way the client does, offsetting the
+// indices of each model past those of the models before it. Optional per-face
+// arrays are kept when any model has them, with defaults for the faces of the
+// models that do not.
+func MergeModels(models ...*Model) *Model {
+	merged := &Model{}
+	var skins, priorities, renderTypes, transparencies, faceSkins, textures bool
+	for i, m := range models {
+		skins = skins || m.VertexSkins != nil
+		priorities = priorities || m.FacePriorities != nil || m.Priority != models[0].Priority
+		renderTypes = renderTypes || m.FaceRenderTypes != nil
+		transparencies = transparencies || m.FaceTransparencies != nil
+		faceSkins = faceSkins || m.FaceSkins != nil
+		textures = textures || m.FaceTextures != nil
+		if i == 0 {
+			merged.Priority = m.Priority
+		}
+	}
+
+	for _, m := range models {
+		vertexBase := int32(len(merged.VertexX))
+		textureBase := int16(len(merged.TextureA))
+		faces := len(m.FaceA)
+
+		merged.VertexX = append(merged.VertexX, m.VertexX...)
+		merged.VertexY = append(merged.VertexY, m.VertexY...)
+		merged.VertexZ = append(merged.VertexZ, m.VertexZ...)
+		if skins {
+			merged.VertexSkins = appendOrFill(merged.VertexSkins, m.VertexSkins, len(m.VertexX), 0)
+		}
+
+		merged.FaceA = appendOffset(merged.FaceA, m.FaceA, vertexBase)
+		merged.FaceB = appendOffset(merged.FaceB, m.FaceB, vertexBase)
+		merged.FaceC = appendOffset(merged.FaceC, m.FaceC, vertexBase)
+		merged.FaceColors = append(merged.FaceColors, m.FaceColors...)
+		if priorities {
+			merged.FacePriorities = appendOrFill(merged.FacePriorities, m.FacePriorities, faces, m.Priority)
+		}
+		if renderTypes {
+			merged.FaceRenderTypes = appendOrFill(merged.FaceRenderTypes, m.FaceRenderTypes, faces, 0)
+		}
+		if transparencies {
+			merged.FaceTransparencies = appendOrFill(merged.FaceTransparencies, m.FaceTransparencies, faces, 0)
+		}
+		if faceSkins {
+			merged.FaceSkins = appendOrFill(merged.FaceSkins, m.FaceSkins, faces, 0)
+		}
+		if textures {
+			merged.FaceTextures = appendOrFill(merged.FaceTextures, m.FaceTextures, faces, -1)
+			for face := range faces {
+				coord := int16(-1)
+				if m.FaceTextureCoords != nil && m.FaceTextureCoords[face] != -1 {
+					coord = m.FaceTextureCoords[face] + textureBase
+				}
+				merged.FaceTextureCoords = append(merged.FaceTextureCoords, coord)
+			}
+		}
+
+		merged.TextureA = appendOffset(merged.TextureA, m.TextureA, vertexBase)
+		merged.TextureB = appendOffset(merged.TextureB, m.TextureB, vertexBase)
+		merged.TextureC = appendOffset(merged.TextureC, m.TextureC, vertexBase)
+	}
+	return merged
+}
+
+// appendOffset appends indices to dst, moved along by base.
+func appendOffset(dst, indices []int32, base int32) []int32 {
+	for _, index := range indices {
+		dst = append(dst, index+base)
+	}
+	return dst
+}
+
+// appendOrFill appends values to dst, or n copies of fill when values is nil.
+func appendOrFill[T any](dst, values []T, n int, fill T) []T {
+	if values != nil {
+		return append(dst, values...)
+	}
+	for range n {
+		dst = append(dst, fill)
+	}
+	return dst
+}
@@ -0,0 +1,166 @@
+package osrscache
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// oldModelBytes encodes a model in the old format with two faces, the second
+// textured through its render info byte, and one texture triangle.
+func oldModelBytes(color uint16) []byte {
+	return slices.Concat(
+		[]byte{0, 1, 6},        // vertex flags
+		[]byte{1, 3},           // face types
+		[]byte{3, 4},           // face priorities
+		[]byte{0x01, 0x02},     // render info
+		[]byte{64, 65, 65, 62}, // face indices
+		[]byte{byte(color >> 8), byte(color), 0, 5}, // face colours
+		[]byte{0, 0, 0, 1, 0, 2},                    // texture triangle
+		[]byte{74}, []byte{44}, []byte{69},          // x, y and z
+		[]byte{0, 3, 0, 2, 1, 1, 255, 0, 0, 0, 0, 1, 0, 1, 0, 1, 0, 4},
+	)
+}
+
+// newModelBytes encodes a model in the new format with one textured,
+// transparent face and vertex skins.
+func newModelBytes() []byte {
+	return slices.Concat(
+		[]byte{7, 0, 2},                        // vertex flags
+		[]byte{1},                              // face types
+		[]byte{1, 2, 3},                        // vertex skins
+		[]byte{100},                            // transparencies
+		[]byte{64, 65, 65},                     // face indices
+		[]byte{0, 8},                           // face textures
+		[]byte{0},                              // texture coordinates
+		[]byte{0, 0x42},                        // face colours
+		[]byte{63}, []byte{65, 66}, []byte{67}, // x, y and z
+		[]byte{0, 3, 0, 1, 0, 0, 2, 1, 0, 1, 1, 0, 1, 0, 2, 0, 1, 0, 3, 0, 1, 0xFF, 0xFF},
+	)
+}
+
+func readModel(t *testing.T, data []byte) *Model {
+	t.Helper()
+	model := NewModel(1)
+	if err := model.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+func TestModelRead(t *testing.T) {
+	oldModel := &Model{
+		ID:                1,
+		VertexX:           []int32{0, 10, 10},
+		VertexY:           []int32{0, 0, -20},
+		VertexZ:           []int32{0, 0, 5},
+		FaceA:             []int32{0, 2},
+		FaceB:             []int32{1, 1},
+		FaceC:             []int32{2, 0},
+		FaceColors:        []uint16{0x1234, 127},
+		Priority:          255,
+		FacePriorities:    []uint8{3, 4},
+		FaceRenderTypes:   []uint8{1, 0},
+		FaceTextures:      []int16{-1, 5},
+		FaceTextureCoords: []int16{-1, 0},
+		TextureA:          []int32{0},
+		TextureB:          []int32{1},
+		TextureC:          []int32{2},
+	}
+	newModel := &Model{
+		ID:                 1,
+		VertexX:            []int32{-1, -1, -1},
+		VertexY:            []int32{1, 1, 3},
+		VertexZ:            []int32{3, 3, 3},
+		VertexSkins:        []uint8{1, 2, 3},
+		FaceA:              []int32{0},
+		FaceB:              []int32{1},
+		FaceC:              []int32{2},
+		FaceColors:         []uint16{0x42},
+		Priority:           2,
+		FaceTransparencies: []uint8{100},
+		FaceTextures:       []int16{7},
+		FaceTextureCoords:  []int16{-1},
+		TextureA:           []int32{},
+		TextureB:           []int32{},
+		TextureC:           []int32{},
+	}
+
+	// The animaya formats extend the vertex skin section and the footer.
+	old := oldModelBytes(0x1234)
+	body, footer := old[:len(old)-18], old[len(old)-18:]
+	oldAnimaya := slices.Concat(body[:9], []byte{9, 9}, body[9:], footer[:10], []byte{1}, footer[10:], []byte{0, 2, 0xFF, 0xFE})
+
+	modern := newModelBytes()
+	body, footer = modern[:len(modern)-23], modern[len(modern)-23:]
+	newAnimaya := slices.Concat(body[:7], []byte{9, 9}, body[7:], footer[:11], []byte{1}, footer[11:21], []byte{0, 5, 0xFF, 0xFD})
+
+	tests := []struct {
+		name string
+		data []byte
+		want *Model
+	}{
+		{"old", old, oldModel},
+		{"old animaya", oldAnimaya, oldModel},
+		{"new", modern, newModel},
+		{"new animaya", newAnimaya, newModel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readModel(t, tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelReadTruncated(t *testing.T) {
+	data := oldModelBytes(0)
+	if err := NewModel(1).Read(data[4:]); err == nil {
+		t.Error("want an error for sections past the end of the data")
+	}
+}
+
+func TestMergeModels(t *testing.T) {
+	old := readModel(t, oldModelBytes(0x1234))
+	merged := MergeModels(old, readModel(t, newModelBytes()), old)
+
+	if !slices.Equal(merged.FaceA, []int32{0, 2, 3, 6, 8}) || !slices.Equal(merged.FaceC, []int32{2, 0, 5, 8, 6}) {
+		t.Errorf("faces = %v %v, want indices past the earlier models", merged.FaceA, merged.FaceC)
+	}
+	if !slices.Equal(merged.FacePriorities, []uint8{3, 4, 2, 3, 4}) {
+		t.Errorf("priorities = %v, want the model priority where unset", merged.FacePriorities)
+	}
+	if !slices.Equal(merged.FaceTransparencies, []uint8{0, 0, 100, 0, 0}) {
+		t.Errorf("transparencies = %v", merged.FaceTransparencies)
+	}
+	if !slices.Equal(merged.FaceTextures, []int16{-1, 5, 7, -1, 5}) {
+		t.Errorf("textures = %v", merged.FaceTextures)
+	}
+	if !slices.Equal(merged.FaceTextureCoords, []int16{-1, 0, -1, -1, 1}) {
+		t.Errorf("texture coordinates = %v, want indices past the earlier texture triangles", merged.FaceTextureCoords)
+	}
+	if !slices.Equal(merged.TextureA, []int32{0, 6}) {
+		t.Errorf("texture triangles = %v", merged.TextureA)
+	}
+	if !slices.Equal(merged.VertexSkins, []uint8{0, 0, 0, 1, 2, 3, 0, 0, 0}) {
+		t.Errorf("vertex skins = %v", merged.VertexSkins)
+	}
+}
+
+func TestModelRecolorRetexture(t *testing.T) {
+	model := readModel(t, oldModelBytes(0x1234))
+	model.Recolor([]uint16{0x1234, 0x2000}, []uint16{0x2000, 0x3000})
+	model.Retexture([]uint16{5}, []uint16{9})
+	model.Translate(0, 6, 0)
+
+	if !slices.Equal(model.FaceColors, []uint16{0x3000, 127}) {
+		t.Errorf("colors = %v, want recolours applied in order", model.FaceColors)
+	}
+	if !slices.Equal(model.FaceTextures, []int16{-1, 9}) {
+		t.Errorf("textures = %v", model.FaceTextures)
+	}
+	if !slices.Equal(model.VertexY, []int32{6, 6, -14}) {
+		t.Errorf("y = %v", model.VertexY)
+	}
+}
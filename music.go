@@ -0,0 +1,320 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Events of the opcode stream of a music track. The low nibble of other
+// opcodes is the kind of channel event, and the high nibble is XORed into the
+// current channel.
+const (
+	musicEventEnd   = 0x07
+	musicEventTempo = 0x17
+)
+
+const (
+	musicNoteOn = iota
+	musicNoteOff
+	musicController
+	musicPitchBend
+	musicChannelPressure
+	musicKeyPressure
+	musicProgramChange
+)
+
+// Streams of event values, in the order they follow the controller numbers.
+const (
+	streamSwitches = iota
+	streamKeyPressure
+	streamChannelPressure
+	streamPitchBendHigh
+	streamModulation
+	streamVolume
+	streamPan
+	streamNotes
+	streamNoteOnVelocity
+	streamOtherControllers
+	streamNoteOffVelocity
+	streamModulationLSB
+	streamVolumeLSB
+	streamPanLSB
+	streamPrograms
+	streamPitchBendLow
+	streamNRPNMSB
+	streamNRPNLSB
+	streamRPNMSB
+	streamRPNLSB
+	streamTempo
+	streamCount
+)
+
+// controllerStream returns the stream holding the values of a controller.
+// Bank selects share the stream of program changes.
+func controllerStream(controller int32) int {
+	switch controller {
+	case 0, 32:
+		return streamPrograms
+	case 1:
+		return streamModulation
+	case 33:
+		return streamModulationLSB
+	case 7:
+		return streamVolume
+	case 39:
+		return streamVolumeLSB
+	case 10:
+		return streamPan
+	case 42:
+		return streamPanLSB
+	case 99:
+		return streamNRPNMSB
+	case 98:
+		return streamNRPNLSB
+	case 101:
+		return streamRPNMSB
+	case 100:
+		return streamRPNLSB
+	case 64, 65, 120, 121, 123:
+		return streamSwitches
+	default:
+		return streamOtherControllers
+	}
+}
+
+// MusicTrack is a song from archive 6 or a jingle from archive 11. The cache
+// splits a MIDI file into separate streams of opcodes, delta times and each
+// kind of event value, with values stored as deltas from the previous one.
+type MusicTrack struct {
+	ID       uint32 `json:"id"`
+	Tracks   int    `json:"tracks"`
+	Division uint16 `json:"division"`
+	// MIDI is the track as a standard MIDI file, format 1 when it has more
+	// than one track.
+	MIDI []byte `json:"-"`
+}
+
+func NewMusicTrack(id uint32) *MusicTrack {
+	return &MusicTrack{ID: id}
+}
+
+func (t *MusicTrack) Read(data []byte) error {
+	if len(data) < 3 {
+		return fmt.Errorf("track of %d bytes is too short", len(data))
+	}
+	t.Tracks = int(data[len(data)-3])
+	t.Division = binary.BigEndian.Uint16(data[len(data)-2:])
+
+	reader := NewReader(data[:len(data)-3])
+
+	var events [7]int
+	tempos := 0
+	for range t.Tracks {
+		for {
+			opcode, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading opcode: %w", err)
+			}
+			if opcode == musicEventEnd {
+				break
+			}
+			if opcode == musicEventTempo {
+				tempos++
+				continue
+			}
+			kind := opcode & 0xF
+			if int(kind) >= len(events) {
+				return fmt.Errorf("unknown event %#x", opcode)
+			}
+			events[kind]++
+		}
+	}
+
+	deltaStart, _ := reader.Seek(0, io.SeekCurrent)
+	deltaCount := t.Tracks + tempos
+	for _, count := range events {
+		deltaCount += count
+	}
+	for range deltaCount {
+		if _, err := readMusicVarInt(reader); err != nil {
+			return fmt.Errorf("reading delta time: %w", err)
+		}
+	}
+
+	controllerStart, _ := reader.Seek(0, io.SeekCurrent)
+	var sizes [streamCount]int
+	var controller int32
+	for range events[musicController] {
+		b, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading controller: %w", err)
+		}
+		controller = (controller + int32(b)) & 0x7F
+		sizes[controllerStream(controller)]++
+	}
+	sizes[streamKeyPressure] = events[musicKeyPressure]
+	sizes[streamChannelPressure] = events[musicChannelPressure]
+	sizes[streamPitchBendHigh] = events[musicPitchBend]
+	sizes[streamPitchBendLow] = events[musicPitchBend]
+	sizes[streamNotes] = events[musicNoteOn] + events[musicNoteOff] + events[musicKeyPressure]
+	sizes[streamNoteOnVelocity] = events[musicNoteOn]
+	sizes[streamNoteOffVelocity] = events[musicNoteOff]
+	sizes[streamPrograms] += events[musicProgramChange]
+	sizes[streamTempo] = tempos * 3
+
+	var cursors [streamCount]int
+	offset, _ := reader.Seek(0, io.SeekCurrent)
+	for stream, size := range sizes {
+		cursors[stream] = int(offset)
+		offset += int64(size)
+	}
+	if offset > reader.Size() {
+		return fmt.Errorf("event streams end at %d past %d bytes", offset, reader.Size())
+	}
+
+	// The streams were bounds checked above, and are read exactly as often as
+	// the events counted from the same opcodes.
+	next := func(stream int) int32 {
+		value := int32(int8(data[cursors[stream]]))
+		cursors[stream]++
+		return value
+	}
+
+	format := uint16(0)
+	if t.Tracks > 1 {
+		format = 1
+	}
+	out := make([]byte, 0, len(data)*2)
+	out = append(out, "MThd"...)
+	out = binary.BigEndian.AppendUint32(out, 6)
+	out = binary.BigEndian.AppendUint16(out, format)
+	out = binary.BigEndian.AppendUint16(out, uint16(t.Tracks))
+	out = binary.BigEndian.AppendUint16(out, t.Division)
+
+	reader.Seek(deltaStart, io.SeekStart)
+	opcodes, controllers := data, data[controllerStart:]
+	var channel, note, velocity, offVelocity, bend, channelPressure, keyPressure int32
+	var controllerValues [128]int32
+	controller = 0
+
+	for range t.Tracks {
+		out = append(out, "MTrk"...)
+		lengthOffset := len(out)
+		out = append(out, 0, 0, 0, 0)
+
+		previous := -1
+		for {
+			delta, _ := readMusicVarInt(reader)
+			out = appendMusicVarInt(out, delta)
+
+			opcode := int(opcodes[0])
+			opcodes = opcodes[1:]
+			// Consecutive events of the same kind on the same channel
+			// use running status.
+			status := opcode != previous
+			previous = opcode & 0xF
+
+			// The client drops the status of an end of track following a
+			// tempo change, which is restored to keep the file valid.
+			if opcode == musicEventEnd {
+				out = append(out, 0xFF, 0x2F, 0x00)
+				break
+			}
+			if opcode == musicEventTempo {
+				out = append(out, 0xFF, 0x51, 0x03)
+				for range 3 {
+					out = append(out, byte(next(streamTempo)))
+				}
+				continue
+			}
+
+			channel ^= int32(opcode >> 4)
+			kind := opcode & 0xF
+			if status {
+				statuses := [...]int32{0x90, 0x80, 0xB0, 0xE0, 0xD0, 0xA0, 0xC0}
+				out = append(out, byte(statuses[kind]+channel))
+			}
+			switch kind {
+			case musicNoteOn:
+				note += next(streamNotes)
+				velocity += next(streamNoteOnVelocity)
+				out = append(out, byte(note&0x7F), byte(velocity&0x7F))
+			case musicNoteOff:
+				note += next(streamNotes)
+				offVelocity += next(streamNoteOffVelocity)
+				out = append(out, byte(note&0x7F), byte(offVelocity&0x7F))
+			case musicController:
+				controller = (controller + int32(controllers[0])) & 0x7F
+				controllers = controllers[1:]
+				value := controllerValues[controller] + next(controllerStream(controller))
+				controllerValues[controller] = value
+				out = append(out, byte(controller), byte(value&0x7F))
+			case musicPitchBend:
+				bend += next(streamPitchBendLow)
+				bend += next(streamPitchBendHigh) << 7
+				out = append(out, byte(bend&0x7F), byte(bend>>7&0x7F))
+			case musicChannelPressure:
+				channelPressure += next(streamChannelPressure)
+				out = append(out, byte(channelPressure&0x7F))
+			case musicKeyPressure:
+				note += next(streamNotes)
+				keyPressure += next(streamKeyPressure)
+				out = append(out, byte(note&0x7F), byte(keyPressure&0x7F))
+			case musicProgramChange:
+				out = append(out, byte(next(streamPrograms)))
+			}
+		}
+
+		binary.BigEndian.PutUint32(out[lengthOffset:], uint32(len(out)-lengthOffset-4))
+	}
+
+	t.MIDI = out
+	return nil
+}
+
+// WriteMIDI writes the track as a standard MIDI file.
+func (t *MusicTrack) WriteMIDI(w io.Writer) error {
+	_, err := w.Write(t.MIDI)
+	return err
+}
+
+// readMusicVarInt reads a MIDI variable length quantity.
+func readMusicVarInt(reader *Reader) (uint32, error) {
+	var value uint32
+	for {
+		b, err := reader.ReadUint8()
+		if err != nil {
+			return 0, err
+		}
+		value = value<<7 | uint32(b&0x7F)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+}
+
+func appendMusicVarInt(b []byte, value uint32) []byte {
+	for shift := 28; shift > 0; shift -= 7 {
+		if value>>shift != 0 {
+			b = append(b, byte(value>>shift)|0x80)
+		}
+	}
+	return append(b, byte(value&0x7F))
+}
+
+func exportMIDI(seq *Seq[uint32, *MusicTrack], outputDir, prefix string) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	for id, track := range seq.All() {
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s_%d.mid", prefix, id))
+		if err := os.WriteFile(filename, track.MIDI, 0644); err != nil {
+			return fmt.Errorf("writing file %s: %w", filename, err)
+		}
+	}
+	return seq.Err()
+}
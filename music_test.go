@@ -0,0 +1,43 @@
+package osrscache
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestMusicTrackRead(t *testing.T) {
+	data := slices.Concat(
+		[]byte{0x17, 0x06, 0x00, 0x00, 0x01, 0x07}, // tempo, program, two notes on, note off, end
+		[]byte{0, 0, 0, 10, 86, 0},                 // delta times
+		[]byte{60, 4, 0xFC},                        // note deltas
+		[]byte{100, 0},                             // note on velocity deltas
+		[]byte{64},                                 // note off velocity
+		[]byte{5},                                  // program
+		[]byte{0x07, 0xA1, 0x20},                   // tempo of 500000us per beat
+		[]byte{1, 0, 96},                           // one track, 96 ticks per beat
+	)
+
+	track := NewMusicTrack(1)
+	if err := track.Read(data); err != nil {
+		t.Fatal(err)
+	}
+
+	want := slices.Concat(
+		[]byte("MThd"), []byte{0, 0, 0, 6, 0, 0, 0, 1, 0, 96},
+		[]byte("MTrk"), []byte{0, 0, 0, 25},
+		[]byte{0, 0xFF, 0x51, 3, 0x07, 0xA1, 0x20},
+		[]byte{0, 0xC0, 5},
+		[]byte{0, 0x90, 60, 100},
+		[]byte{10, 64, 100}, // running status
+		[]byte{86, 0x80, 60, 64},
+		[]byte{0, 0xFF, 0x2F, 0},
+	)
+	if !bytes.Equal(track.MIDI, want) {
+		t.Errorf("MIDI =\n% x\nwant\n% x", track.MIDI, want)
+	}
+
+	if err := NewMusicTrack(1).Read(data[:len(data)-5]); err == nil {
+		t.Error("want an error for streams past the end of the data")
+	}
+}
@@ -27,6 +27,11 @@ type NPC struct {
 	LowPriority      bool             `json:"low_priority"`
 	Visible          bool             `json:"visible"`
 	VisibleOnMinimap bool             `json:"visible_on_minimap"`
+	IdleAnimRestart  bool             `json:"idle_anim_restart"`
+	HideForOverlap   bool             `json:"hide_for_overlap"`
+	OverlapTint      uint16           `json:"overlap_tint"`
+	FootprintSize    uint16           `json:"footprint_size"`
+	ZBuffer          bool             `json:"z_buffer"`
 	Configs          []uint16         `json:"configs"`
 	VarbitID         uint16           `json:"varbit_id"`
 	VarpIndex        uint16           `json:"varp_index"`
@@ -34,6 +39,7 @@ type NPC struct {
 	Params           map[uint32]any   `json:"params"`
 	ModelData        NPCModelData     `json:"model_data"`
 	AnimationData    NPCAnimationData `json:"animation_data"`
+	Unknown          *UnknownOpcode   `json:"unknown,omitempty"`
 }
 
 type NPCModelData struct {
@@ -79,6 +85,7 @@ func NewNPC(id uint16) *NPC {
 		Name:             "null",
 		Interactable:     true,
 		VisibleOnMinimap: true,
+		ZBuffer:          true,
 		ModelData: NPCModelData{
 			ScaleHeight: 128,
 			ScaleWidth:  128,
@@ -88,7 +95,9 @@ func NewNPC(id uint16) *NPC {
 	}
 }
 
-func (npc *NPC) Read(data []byte) error {
+func (npc *NPC) Read(data []byte, opts ...DecodeOption) error {
+	options := newLenientDecodeOptions(opts)
+
 	reader := NewReader(data)
 	for {
 		opcode, err := reader.ReadUint8()
@@ -434,6 +443,25 @@ func (npc *NPC) Read(data []byte) error {
 			if err != nil {
 				return fmt.Errorf("reading height: %w", err)
 			}
+		case 126:
+			npc.FootprintSize, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading footprint size: %w", err)
+			}
+		case 129:
+			// Set by a handful of NPCs without any data; the client does not
+			// use it.
+		case 130:
+			npc.IdleAnimRestart = true
+		case 145:
+			npc.HideForOverlap = true
+		case 146:
+			npc.OverlapTint, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading overlap tint: %w", err)
+			}
+		case 147:
+			npc.ZBuffer = false
 		case 249:
 			length, err := reader.ReadUint8()
 			if err != nil {
@@ -467,6 +495,9 @@ func (npc *NPC) Read(data []byte) error {
 				}
 				npc.Params[key] = value
 			}
+		default:
+			npc.Unknown, err = options.unknownOpcode("npc", npc.ID, opcode, reader)
+			return err
 		}
 	}
 	return nil
@@ -31,9 +31,13 @@ type Object struct {
 	BlocksProjectile           bool            `json:"blocks_projectile"`
 	WallOrDoor                 uint8           `json:"wall_or_door"`
 	ContouredGround            uint8           `json:"contoured_ground"`
+	ModelClipped               bool            `json:"model_clipped"`
+	RandomizeAnimStart         bool            `json:"randomize_anim_start"`
+	DeferAnimChange            bool            `json:"defer_anim_change"`
 	ConfigChangeDest           []uint16        `json:"config_change_dest"`
 	Params                     map[uint32]any  `json:"params"`
 	ModelData                  ObjectModelData `json:"model_data"`
+	Unknown                    *UnknownOpcode  `json:"unknown,omitempty"`
 }
 
 type ObjectModelData struct {
@@ -61,10 +65,11 @@ type ObjectModelData struct {
 
 func NewObject(id uint16) *Object {
 	return &Object{
-		ID:               id,
-		InteractType:     3,
-		BlocksProjectile: true,
-		Solid:            true,
+		ID:                 id,
+		InteractType:       3,
+		BlocksProjectile:   true,
+		Solid:              true,
+		RandomizeAnimStart: true,
 		ModelData: ObjectModelData{
 			DecordDisplacement: 16,
 			SizeX:              1,
@@ -76,7 +81,9 @@ func NewObject(id uint16) *Object {
 	}
 }
 
-func (obj *Object) Read(data []byte) error {
+func (obj *Object) Read(data []byte, opts ...DecodeOption) error {
+	options := newLenientDecodeOptions(opts)
+
 	reader := NewReader(data)
 	for {
 		opcode, err := reader.ReadUint8()
@@ -149,6 +156,8 @@ func (obj *Object) Read(data []byte) error {
 			obj.ContouredGround = 0
 		case 22:
 			obj.ModelData.MergeNormals = true
+		case 23:
+			obj.ModelClipped = true
 		case 24:
 			obj.AnimationID, err = reader.ReadUint16()
 			if err != nil {
@@ -210,6 +219,11 @@ func (obj *Object) Read(data []byte) error {
 					return fmt.Errorf("reading retexture to: %w", err)
 				}
 			}
+		case 60:
+			obj.MapAreaID, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading map area id: %w", err)
+			}
 		case 61:
 			obj.Category, err = reader.ReadUint16()
 			if err != nil {
@@ -349,6 +363,10 @@ func (obj *Object) Read(data []byte) error {
 			if err != nil {
 				return fmt.Errorf("reading map area id: %w", err)
 			}
+		case 89:
+			obj.RandomizeAnimStart = false
+		case 90:
+			obj.DeferAnimChange = true
 		case 92:
 			obj.ModelData.VarpID, err = reader.ReadUint16()
 			if err != nil {
@@ -410,6 +428,9 @@ func (obj *Object) Read(data []byte) error {
 				}
 				obj.Params[key] = value
 			}
+		default:
+			obj.Unknown, err = options.unknownOpcode("object", obj.ID, opcode, reader)
+			return err
 		}
 	}
 	return nil
@@ -7,6 +7,8 @@ import (
 	"regexp"
 	"slices"
 	"strconv"
+
+	"github.com/joeychilson/osrscache"
 )
 
 const (
@@ -58,7 +60,7 @@ func (s *OpenRS2Store) GroupList(archiveID uint8) ([]uint32, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("archive does not exist")
+			return nil, fmt.Errorf("archive %d: %w", archiveID, osrscache.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to read archive directory: %w", err)
 	}
@@ -90,7 +92,7 @@ func (s *OpenRS2Store) Read(archiveID uint8, groupID uint32) ([]byte, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("group does not exist: %w", err)
+			return nil, fmt.Errorf("group %d in archive %d: %w", groupID, archiveID, osrscache.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to read group file: %w", err)
 	}
@@ -0,0 +1,177 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Well-known param IDs found on item definitions. An item's weapon category is
+// not among them: the server sends it to the client through varbit 357 when
+// the weapon is wielded.
+const (
+	ParamStabAttack     = 0
+	ParamSlashAttack    = 1
+	ParamCrushAttack    = 2
+	ParamMagicAttack    = 3
+	ParamRangedAttack   = 4
+	ParamStabDefence    = 5
+	ParamSlashDefence   = 6
+	ParamCrushDefence   = 7
+	ParamMagicDefence   = 8
+	ParamRangedDefence  = 9
+	ParamPrayer         = 11
+	ParamAttackSpeed    = 14
+	ParamRangedStrength = 189
+	// ParamMagicDamage is the magic damage bonus in tenths of a percent.
+	ParamMagicDamage   = 299
+	ParamMeleeStrength = 641
+)
+
+type ParamDefinition struct {
+	ID            uint16         `json:"id"`
+	Type          ScriptVarType  `json:"type"`
+	DefaultInt    int32          `json:"default_int"`
+	DefaultString string         `json:"default_string"`
+	AutoDisable   bool           `json:"auto_disable"`
+	Unknown       *UnknownOpcode `json:"unknown,omitempty"`
+}
+
+func NewParamDefinition(id uint16) *ParamDefinition {
+	return &ParamDefinition{ID: id, AutoDisable: true}
+}
+
+// IsString reports whether the param holds string values.
+func (p *ParamDefinition) IsString() bool {
+	return p.Type == TypeString
+}
+
+func (p *ParamDefinition) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
+	reader := NewReader(data)
+	for {
+		opcode, err := reader.ReadUint8()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading opcode: %w", err)
+		}
+		if opcode == 0 {
+			break
+		}
+		switch opcode {
+		case 1:
+			paramType, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading type: %w", err)
+			}
+			p.Type = ScriptVarType(paramType)
+		case 2:
+			p.DefaultInt, err = reader.ReadInt32()
+			if err != nil {
+				return fmt.Errorf("reading default int: %w", err)
+			}
+		case 4:
+			p.AutoDisable = false
+		case 5:
+			p.DefaultString, err = reader.ReadString()
+			if err != nil {
+				return fmt.Errorf("reading default string: %w", err)
+			}
+		default:
+			p.Unknown, err = options.unknownOpcode("param", p.ID, opcode, reader)
+			return err
+		}
+	}
+	return nil
+}
+
+// paramInt returns the int value of a param, falling back to the param
+// definition's default when params does not hold it.
+func paramInt(cache *Cache, params map[uint32]any, id uint32) (int32, error) {
+	if value, ok := params[id]; ok {
+		intValue, ok := value.(uint32)
+		if !ok {
+			return 0, fmt.Errorf("param %d is not an int", id)
+		}
+		return int32(intValue), nil
+	}
+
+	def, err := cache.cachedParam(uint16(id))
+	if err != nil {
+		return 0, err
+	}
+	if def.IsString() {
+		return 0, fmt.Errorf("param %d is not an int", id)
+	}
+	return def.DefaultInt, nil
+}
+
+// paramString returns the string value of a param, falling back to the param
+// definition's default when params does not hold it.
+func paramString(cache *Cache, params map[uint32]any, id uint32) (string, error) {
+	if value, ok := params[id]; ok {
+		strValue, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("param %d is not a string", id)
+		}
+		return strValue, nil
+	}
+
+	def, err := cache.cachedParam(uint16(id))
+	if err != nil {
+		return "", err
+	}
+	if !def.IsString() {
+		return "", fmt.Errorf("param %d is not a string", id)
+	}
+	return def.DefaultString, nil
+}
+
+// ParamInt returns the item's value for an int param, or the param's default.
+func (item *Item) ParamInt(cache *Cache, id uint32) (int32, error) {
+	return paramInt(cache, item.Params, id)
+}
+
+// ParamString returns the item's value for a string param, or the param's
+// default.
+func (item *Item) ParamString(cache *Cache, id uint32) (string, error) {
+	return paramString(cache, item.Params, id)
+}
+
+// ParamInt returns the npc's value for an int param, or the param's default.
+func (npc *NPC) ParamInt(cache *Cache, id uint32) (int32, error) {
+	return paramInt(cache, npc.Params, id)
+}
+
+// ParamString returns the npc's value for a string param, or the param's
+// default.
+func (npc *NPC) ParamString(cache *Cache, id uint32) (string, error) {
+	return paramString(cache, npc.Params, id)
+}
+
+// ParamInt returns the object's value for an int param, or the param's
+// default.
+func (obj *Object) ParamInt(cache *Cache, id uint32) (int32, error) {
+	return paramInt(cache, obj.Params, id)
+}
+
+// ParamString returns the object's value for a string param, or the param's
+// default.
+func (obj *Object) ParamString(cache *Cache, id uint32) (string, error) {
+	return paramString(cache, obj.Params, id)
+}
+
+// ParamInt returns the struct's value for an int param, or the param's
+// default.
+func (s *Struct) ParamInt(cache *Cache, id uint32) (int32, error) {
+	return paramInt(cache, s.Params, id)
+}
+
+// ParamString returns the struct's value for a string param, or the param's
+// default.
+func (s *Struct) ParamString(cache *Cache, id uint32) (string, error) {
+	return paramString(cache, s.Params, id)
+}
@@ -0,0 +1,444 @@
+package osrscache
+
+import (
+	"fmt"
+	"io"
+)
+
+// PCMSample is signed 8-bit mono audio played by a music patch. While a looped
+// note is held, the samples from LoopStart to LoopEnd repeat.
+type PCMSample struct {
+	SampleRate int
+	Samples    []int8
+	LoopStart  int
+	LoopEnd    int
+}
+
+// MusicPatch is an instrument from archive 15. Its group ID is the MIDI
+// program plus the bank, with bank select MSB in bits 14-20 and LSB in bits
+// 7-13. Percussion on channel 10 uses patches 128 and up.
+type MusicPatch struct {
+	ID uint32 `json:"id"`
+	// Volume scales every key, with 128 the loudest.
+	Volume    int             `json:"volume"`
+	Keys      [128]PatchKey   `json:"keys"`
+	Envelopes []PatchEnvelope `json:"envelopes"`
+}
+
+// PatchKey is how a patch plays one MIDI key. Keys are split into ranges
+// sharing a sample, envelope, volume and pan.
+type PatchKey struct {
+	// Sample references the sample played, 0 when the key is silent.
+	Sample uint32 `json:"sample"`
+	// Root is the pitch the sample plays at unchanged, in 1/256 semitones.
+	Root int `json:"root"`
+	// Looped repeats the loop of the sample while the note is held.
+	Looped bool  `json:"looped"`
+	Volume uint8 `json:"volume"`
+	// Pan runs from 0 at the left to 128 at the right.
+	Pan uint8 `json:"pan"`
+	// ExclusiveClass cuts off any other note of the same class on the channel,
+	// such as an open hi-hat by a closed one. It is -1 for no class.
+	ExclusiveClass int8 `json:"exclusive_class"`
+	// Envelope indexes the envelopes of the patch.
+	Envelope int `json:"envelope"`
+}
+
+// SampleGroup returns where the key's sample is stored: a sound effect in
+// archive 4, synthesized, or a Vorbis sample in archive 14.
+func (k PatchKey) SampleGroup() (archiveID uint8, group uint32, ok bool) {
+	if k.Sample == 0 {
+		return 0, 0, false
+	}
+	ref := k.Sample - 1
+	if ref&1 == 0 {
+		return 4, ref >> 2, true
+	}
+	return 14, ref >> 2, true
+}
+
+// EnvelopePoint is a point of a patch envelope. Time is in units of 20ms,
+// scaled by the envelope's key tracking, and Level is out of 64.
+type EnvelopePoint struct {
+	Time  int `json:"time"`
+	Level int `json:"level"`
+}
+
+// PatchEnvelope shapes the notes of a range of keys. The key tracking fields
+// speed up or slow down the envelope by 2^(scale*(key-60)/768).
+type PatchEnvelope struct {
+	// Volume is applied from the start of the note, which ends once it
+	// reaches a last point of level 0.
+	Volume         []EnvelopePoint `json:"volume,omitempty"`
+	VolumeKeyScale int             `json:"volume_key_scale"`
+	// Release is applied from the end of the note. The note stops at its
+	// last point, which always has level 0.
+	Release         []EnvelopePoint `json:"release,omitempty"`
+	ReleaseKeyScale int             `json:"release_key_scale"`
+	// Decay halves the volume every 4/Decay seconds.
+	Decay         int `json:"decay"`
+	DecayKeyScale int `json:"decay_key_scale"`
+	// VibratoRate advances the vibrato by VibratoRate/512 of a cycle per
+	// tick. VibratoDepth is in 1/64 semitones, reached after VibratoDelay*2
+	// ticks.
+	VibratoRate  int `json:"vibrato_rate"`
+	VibratoDepth int `json:"vibrato_depth"`
+	VibratoDelay int `json:"vibrato_delay"`
+}
+
+func NewMusicPatch(id uint32) *MusicPatch {
+	return &MusicPatch{ID: id}
+}
+
+// Read decodes a patch. Most per-key values are run-length encoded over key
+// ranges, and the data is read in the order of the client, which interleaves
+// the fields of the key ranges and envelopes.
+func (p *MusicPatch) Read(data []byte) error {
+	var err error
+	pos := 0
+	fail := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+	u8 := func() int {
+		if pos >= len(data) {
+			fail(io.ErrUnexpectedEOF)
+			return 0
+		}
+		pos++
+		return int(data[pos-1])
+	}
+	s8 := func() int8 {
+		return int8(u8())
+	}
+	at := func(i int) int {
+		if i >= len(data) {
+			fail(io.ErrUnexpectedEOF)
+			return 0
+		}
+		return int(int8(data[i]))
+	}
+	varInt := func() int {
+		value := 0
+		for {
+			b := u8()
+			if b < 0x80 || err != nil {
+				return value | b
+			}
+			value = (value | b&0x7F) << 7
+		}
+	}
+	// runs reads the zero terminated lengths of the key ranges of a field.
+	runs := func() []int8 {
+		start := pos
+		for pos < len(data) && data[pos] != 0 {
+			pos++
+		}
+		out := make([]int8, pos-start)
+		for i := range out {
+			out[i] = int8(data[start+i])
+		}
+		u8()
+		return out
+	}
+	// next starts the following key range, which spans the rest of the keys
+	// once the lengths run out.
+	next := func(lengths []int8, run *int) int {
+		if *run < len(lengths) {
+			*run++
+			return int(lengths[*run-1])
+		}
+		return -1
+	}
+
+	classRuns := runs()
+	classValues := pos
+	pos += len(classRuns) + 1
+
+	panRuns := runs()
+	panValues := pos
+	pos += len(panRuns) + 1
+
+	envelopeRuns := runs()
+	envelopeIndices := make([]int, len(envelopeRuns)+1)
+	envelopeCount := len(envelopeIndices)
+	if len(envelopeIndices) > 1 {
+		envelopeIndices[1] = 1
+		last := 1
+		envelopeCount = 2
+		for i := 2; i < len(envelopeIndices); i++ {
+			v := u8()
+			if v == 0 {
+				last = envelopeCount
+				envelopeCount++
+			} else {
+				if v <= last {
+					v--
+				}
+				last = v
+			}
+			envelopeIndices[i] = last
+		}
+	}
+	for _, index := range envelopeIndices {
+		if index >= envelopeCount {
+			return fmt.Errorf("envelope %d out of range", index)
+		}
+	}
+
+	type rawEnvelope struct {
+		volume, release []int8
+	}
+	envelopes := make([]rawEnvelope, envelopeCount)
+	for i := range envelopes {
+		if n := u8(); n > 0 {
+			envelopes[i].volume = make([]int8, n*2)
+		}
+		if n := u8(); n > 0 {
+			envelopes[i].release = make([]int8, n*2+2)
+			envelopes[i].release[1] = 64
+		}
+	}
+
+	var volumePoints, panPoints []int8
+	if n := u8(); n > 0 {
+		volumePoints = make([]int8, n*2)
+	}
+	if n := u8(); n > 0 {
+		panPoints = make([]int8, n*2)
+	}
+
+	keyRuns := runs()
+	if err != nil {
+		return fmt.Errorf("reading key ranges: %w", err)
+	}
+
+	var pitches [128]int16
+	var samples [128]int
+	var volumes, pans, classes [128]int8
+	var keyEnvelopes [128]int
+
+	sum := 0
+	for key := range pitches {
+		sum += u8()
+		pitches[key] = int16(sum)
+	}
+	sum = 0
+	for key := range pitches {
+		sum += u8()
+		pitches[key] += int16(sum << 8)
+	}
+
+	remaining, run, value := 0, 0, 0
+	for key := range samples {
+		if remaining == 0 {
+			remaining = next(keyRuns, &run)
+			value = varInt()
+		}
+		pitches[key] += int16((value - 1) & 2 << 14)
+		samples[key] = value
+		remaining--
+	}
+
+	remaining, run, value = 0, 0, 0
+	for key := range classes {
+		if samples[key] == 0 {
+			continue
+		}
+		if remaining == 0 {
+			remaining = next(classRuns, &run)
+			value = at(classValues) - 1
+			classValues++
+		}
+		classes[key] = int8(value)
+		remaining--
+	}
+
+	remaining, run, value = 0, 0, 0
+	for key := range pans {
+		if samples[key] == 0 {
+			continue
+		}
+		if remaining == 0 {
+			remaining = next(panRuns, &run)
+			value = (at(panValues) + 16) << 2
+			panValues++
+		}
+		pans[key] = int8(value)
+		remaining--
+	}
+
+	remaining, run, value = 0, 0, 0
+	for key := range keyEnvelopes {
+		if samples[key] == 0 {
+			continue
+		}
+		if remaining == 0 {
+			value = envelopeIndices[run]
+			remaining = next(envelopeRuns, &run)
+		}
+		keyEnvelopes[key] = value
+		remaining--
+	}
+
+	remaining, run, value = 0, 0, 0
+	for key := range volumes {
+		if remaining == 0 {
+			remaining = next(keyRuns, &run)
+			if samples[key] > 0 {
+				value = u8() + 1
+			}
+		}
+		volumes[key] = int8(value)
+		remaining--
+	}
+
+	p.Volume = u8() + 1
+
+	for _, e := range envelopes {
+		for i := 1; i < len(e.volume); i += 2 {
+			e.volume[i] = s8()
+		}
+		for i := 3; i < len(e.release)-2; i += 2 {
+			e.release[i] = s8()
+		}
+	}
+	for i := 1; i < len(volumePoints); i += 2 {
+		volumePoints[i] = s8()
+	}
+	for i := 1; i < len(panPoints); i += 2 {
+		panPoints[i] = s8()
+	}
+
+	readTimes := func(points []int8, sum int) {
+		for i := 2; i < len(points); i += 2 {
+			sum += 1 + u8()
+			points[i] = int8(sum)
+		}
+	}
+	for _, e := range envelopes {
+		readTimes(e.release, 0)
+	}
+	for _, e := range envelopes {
+		readTimes(e.volume, 0)
+	}
+
+	if volumePoints != nil {
+		volumePoints[0] = s8()
+		readTimes(volumePoints, int(volumePoints[0]))
+		applyKeyCurve(volumePoints, 1, volumes[:], &err, func(level, key int) int8 {
+			return int8((level*int(volumes[key]) + 32) >> 6)
+		})
+	}
+	if panPoints != nil {
+		panPoints[0] = s8()
+		readTimes(panPoints, int(panPoints[0]))
+		applyKeyCurve(panPoints, 2, pans[:], &err, func(offset, key int) int8 {
+			return int8(min(max(offset+int(uint8(pans[key])), 0), 128))
+		})
+	}
+
+	decays := make([]int, len(envelopes))
+	for i := range envelopes {
+		decays[i] = u8()
+	}
+	p.Envelopes = make([]PatchEnvelope, len(envelopes))
+	for i, e := range envelopes {
+		envelope := &p.Envelopes[i]
+		envelope.Decay = decays[i]
+		if e.volume != nil {
+			envelope.VolumeKeyScale = u8()
+		}
+		if e.release != nil {
+			envelope.ReleaseKeyScale = u8()
+		}
+		if envelope.Decay > 0 {
+			envelope.DecayKeyScale = u8()
+		}
+	}
+	for i := range p.Envelopes {
+		p.Envelopes[i].VibratoRate = u8()
+	}
+	for i := range p.Envelopes {
+		if p.Envelopes[i].VibratoRate > 0 {
+			p.Envelopes[i].VibratoDepth = u8()
+		}
+	}
+	for i := range p.Envelopes {
+		if p.Envelopes[i].VibratoDepth > 0 {
+			p.Envelopes[i].VibratoDelay = u8()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("reading patch: %w", err)
+	}
+
+	for i, e := range envelopes {
+		p.Envelopes[i].Volume = envelopePoints(e.volume)
+		p.Envelopes[i].Release = envelopePoints(e.release)
+	}
+	for key := range p.Keys {
+		p.Keys[key] = PatchKey{
+			Sample:         uint32(samples[key]),
+			Root:           int(pitches[key]) & 0x7FFF,
+			Looped:         pitches[key] < 0,
+			Volume:         uint8(volumes[key]),
+			Pan:            uint8(pans[key]),
+			ExclusiveClass: classes[key],
+			Envelope:       keyEnvelopes[key],
+		}
+	}
+	return nil
+}
+
+// applyKeyCurve scales values by a curve over the keys, interpolating
+// linearly between its points. Point levels are multiplied by levelScale
+// before being passed to apply.
+func applyKeyCurve(points []int8, levelScale int, values []int8, err *error, apply func(level, key int) int8) {
+	set := func(key, level int) {
+		if key < 0 || key >= len(values) {
+			if *err == nil {
+				*err = fmt.Errorf("key %d out of range", key)
+			}
+			return
+		}
+		values[key] = apply(level, key)
+	}
+
+	start := int(points[0])
+	level := int(points[1]) * levelScale
+	for key := 0; key < start; key++ {
+		set(key, level)
+	}
+	for i := 2; i < len(points); i += 2 {
+		end := int(points[i])
+		nextLevel := int(points[i+1]) * levelScale
+		span := end - start
+		acc := level*span + span/2
+		for key := start; key < end; key++ {
+			// Rounds toward negative infinity like the client.
+			negative := 0
+			if acc < 0 {
+				negative = 1
+			}
+			set(key, (acc+negative)/span-negative)
+			acc += nextLevel - level
+		}
+		start, level = end, nextLevel
+	}
+	for key := start; key < len(values); key++ {
+		set(key, level)
+	}
+}
+
+func envelopePoints(raw []int8) []EnvelopePoint {
+	if raw == nil {
+		return nil
+	}
+	points := make([]EnvelopePoint, len(raw)/2)
+	for i := range points {
+		points[i] = EnvelopePoint{Time: int(uint8(raw[i*2])), Level: int(raw[i*2+1])}
+	}
+	return points
+}
@@ -0,0 +1,89 @@
+package osrscache
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// patchBytes encodes a patch by hand with three key ranges: keys 0-39 are
+// silent, keys 40-59 play looped sample 3 with envelope 0, and keys 60-127
+// play sample 6 with envelope 1. A volume curve falls from full at key 40 to
+// half at key 80 and a pan curve moves every key 16 to the left.
+func patchBytes() []byte {
+	pitches := make([]byte, 256)
+	pitches[128] = 60 // the high byte of every root
+	return slices.Concat(
+		[]byte{0, 0},         // class runs and value
+		[]byte{20, 0, 0, 16}, // pan runs and values
+		[]byte{20, 0},        // envelope runs
+		[]byte{2, 1, 0, 0},   // envelope volume and release point counts
+		[]byte{2, 1},         // volume and pan curve point counts
+		[]byte{40, 20, 0},    // key runs
+		pitches,
+		[]byte{0, 3, 6},      // samples
+		[]byte{99, 119},      // key volumes
+		[]byte{127},          // patch volume
+		[]byte{64, 0},        // envelope 0 volume levels
+		[]byte{64, 32, 0xF8}, // volume and pan curve levels
+		[]byte{9, 19},        // envelope 0 release and volume times
+		[]byte{40, 39},       // volume curve start and time
+		[]byte{0},            // pan curve start
+		[]byte{4, 0},         // decays
+		[]byte{3, 5, 7},      // envelope 0 key scales
+		[]byte{10, 0, 12, 2}, // vibrato rates, depth and delay
+	)
+}
+
+func TestMusicPatchRead(t *testing.T) {
+	patch := NewMusicPatch(0)
+	if err := patch.Read(patchBytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if patch.Volume != 128 {
+		t.Errorf("volume = %d, want 128", patch.Volume)
+	}
+	keys := []struct {
+		key  int
+		want PatchKey
+	}{
+		{40, PatchKey{Sample: 3, Root: 60 << 8, Looped: true, Volume: 100, Pan: 48, ExclusiveClass: -1}},
+		{50, PatchKey{Sample: 3, Root: 60 << 8, Looped: true, Volume: 88, Pan: 48, ExclusiveClass: -1}},
+		{100, PatchKey{Sample: 6, Root: 60 << 8, Volume: 60, Pan: 112, ExclusiveClass: -1, Envelope: 1}},
+	}
+	for _, k := range keys {
+		if got := patch.Keys[k.key]; got != k.want {
+			t.Errorf("key %d = %+v, want %+v", k.key, got, k.want)
+		}
+	}
+	if _, _, ok := patch.Keys[0].SampleGroup(); ok {
+		t.Error("key 0 has a sample, want it silent")
+	}
+	if archiveID, group, ok := patch.Keys[100].SampleGroup(); archiveID != 14 || group != 1 || !ok {
+		t.Errorf("key 100 sample group = %d/%d %t, want 14/1", archiveID, group, ok)
+	}
+
+	want := []PatchEnvelope{
+		{
+			Volume:          []EnvelopePoint{{0, 64}, {20, 0}},
+			VolumeKeyScale:  3,
+			Release:         []EnvelopePoint{{0, 64}, {10, 0}},
+			ReleaseKeyScale: 5,
+			Decay:           4,
+			DecayKeyScale:   7,
+			VibratoRate:     10,
+			VibratoDepth:    12,
+			VibratoDelay:    2,
+		},
+		{},
+	}
+	if !reflect.DeepEqual(patch.Envelopes, want) {
+		t.Errorf("envelopes = %+v, want %+v", patch.Envelopes, want)
+	}
+
+	data := patchBytes()
+	if err := NewMusicPatch(0).Read(data[:len(data)-1]); err == nil {
+		t.Error("want an error for a truncated patch")
+	}
+}
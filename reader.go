@@ -171,6 +171,44 @@ func (r *Reader) ReadUint16SmartMinus1() (uint16, error) {
 	return value + 1, nil
 }
 
+// PeekUint8 returns the next byte without advancing.
+func (r *Reader) PeekUint8() (uint8, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+	return r.data[r.pos], nil
+}
+
+// ReadUint16Smart reads a value below 128 as one byte and larger values, up
+// to 32767, as two.
+func (r *Reader) ReadUint16Smart() (uint16, error) {
+	peek, err := r.PeekUint8()
+	if err != nil {
+		return 0, err
+	}
+	if peek < 128 {
+		value, err := r.ReadUint8()
+		return uint16(value), err
+	}
+	value, err := r.ReadUint16()
+	return value - 0x8000, err
+}
+
+// ReadInt16Smart reads a value in [-64, 63] as one byte and other values, in
+// [-16384, 16383], as two.
+func (r *Reader) ReadInt16Smart() (int16, error) {
+	peek, err := r.PeekUint8()
+	if err != nil {
+		return 0, err
+	}
+	if peek < 128 {
+		value, err := r.ReadUint8()
+		return int16(value) - 64, err
+	}
+	value, err := r.ReadUint16()
+	return int16(int32(value) - 0xC000), err
+}
+
 func (r *Reader) Seek(offset int64, whence int) (int64, error) {
 	var abs int64
 	switch whence {
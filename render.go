@@ -0,0 +1,450 @@
+package osrscache
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"maps"
+	"slices"
+)
+
+// TextDrawer draws the text of a text component within its laid out bounds.
+type TextDrawer interface {
+	DrawWidgetText(dst *image.RGBA, widget *Widget, bounds image.Rectangle) error
+}
+
+// ItemIconFunc returns the icon of an item stack drawn in an inventory
+// component.
+type ItemIconFunc func(itemID, quantity int32) (image.Image, error)
+
+// ItemStack is an item shown in an inventory component.
+type ItemStack struct {
+	ID       int32
+	Quantity int32
+}
+
+type RenderOption func(*InterfaceRenderer)
+
+// WithTextDrawer sets how text components are drawn. By default they are drawn
+// with the cache's fonts.
+func WithTextDrawer(drawer TextDrawer) RenderOption {
+	return func(r *InterfaceRenderer) {
+		r.text = drawer
+	}
+}
+
+// WithItemIcons sets how item icons are produced for inventory components.
+func WithItemIcons(icons ItemIconFunc) RenderOption {
+	return func(r *InterfaceRenderer) {
+		r.icons = icons
+	}
+}
+
+// WithItems fills the inventory component widgetID with items. Inventories are
+// populated by the server, so the cache itself never holds their contents.
+func WithItems(widgetID uint32, items []ItemStack) RenderOption {
+	return func(r *InterfaceRenderer) {
+		r.items[widgetID] = items
+	}
+}
+
+// NineSlice is the border of a sprite drawn as a 9-slice: the corners keep
+// their size, the edges stretch along their length and the centre stretches
+// to fill the rest.
+type NineSlice struct {
+	Left, Top, Right, Bottom int
+}
+
+// WithNineSlice draws sprite spriteID as a 9-slice with the given border when
+// a graphic component is sized differently from the sprite. Components carry
+// no 9-slice flag, so sprites are registered by the caller, e.g. for panel
+// backgrounds that scripts stretch over resizable interfaces.
+func WithNineSlice(spriteID int32, border NineSlice) RenderOption {
+	return func(r *InterfaceRenderer) {
+		r.slices[spriteID] = border
+	}
+}
+
+// InterfaceRenderer lays out interfaces from archive 3 and draws them with the
+// cache's sprites. Model components are not drawn.
+type InterfaceRenderer struct {
+	cache   *Cache
+	text    TextDrawer
+	icons   ItemIconFunc
+	items   map[uint32][]ItemStack
+	slices  map[int32]NineSlice
+	sprites map[int32]*image.RGBA
+}
+
+func NewInterfaceRenderer(cache *Cache, opts ...RenderOption) *InterfaceRenderer {
+	r := &InterfaceRenderer{
+		cache:   cache,
+		text:    NewFontTextDrawer(cache),
+		items:   make(map[uint32][]ItemStack),
+		slices:  make(map[int32]NineSlice),
+		sprites: make(map[int32]*image.RGBA),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Render draws interface id into a width by height viewport, as if it were
+// opened as the top level interface of a client of that size.
+func (r *InterfaceRenderer) Render(id uint16, width, height int) (*image.RGBA, error) {
+	iface, err := r.cache.Interface(id)
+	if err != nil {
+		return nil, fmt.Errorf("getting interface: %w", err)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, componentID := range sortedComponents(iface) {
+		widget := iface.Components[componentID]
+		if widget.ParentID >= 0 && uint16(widget.ParentID>>16) == id {
+			continue
+		}
+		if err := r.drawWidget(dst, iface, widget, dst.Bounds()); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+func sortedComponents(iface *Interface) []uint16 {
+	return slices.Sorted(maps.Keys(iface.Components))
+}
+
+// Layout returns the bounds of widget inside its parent's bounds, resolving
+// its size and position modes the way the client does.
+func (w *Widget) Layout(parent image.Rectangle) image.Rectangle {
+	parentWidth, parentHeight := parent.Dx(), parent.Dy()
+
+	width := resolveSize(w.WidthMode, int(w.Width), parentWidth)
+	height := resolveSize(w.HeightMode, int(w.Height), parentHeight)
+	// Mode 4 keeps the aspect ratio of the raw size against the other axis.
+	if w.WidthMode == 4 && w.Height != 0 {
+		width = height * int(w.Width) / int(w.Height)
+	}
+	if w.HeightMode == 4 && w.Width != 0 {
+		height = width * int(w.Height) / int(w.Width)
+	}
+
+	x := resolvePosition(w.XPositionMode, int(w.X), width, parentWidth)
+	y := resolvePosition(w.YPositionMode, int(w.Y), height, parentHeight)
+	return image.Rect(0, 0, width, height).Add(parent.Min.Add(image.Pt(x, y)))
+}
+
+func resolveSize(mode int8, raw, parent int) int {
+	switch mode {
+	case 1:
+		return parent - raw
+	case 2:
+		return raw * parent >> 14
+	default:
+		return raw
+	}
+}
+
+func resolvePosition(mode int8, raw, size, parent int) int {
+	switch mode {
+	case 1:
+		return (parent-size)/2 + raw
+	case 2:
+		return parent - size - raw
+	case 3:
+		return raw * parent >> 14
+	case 4:
+		return (parent-size)/2 + raw*parent>>14
+	case 5:
+		return parent - size - raw*parent>>14
+	default:
+		return raw
+	}
+}
+
+func (r *InterfaceRenderer) drawWidget(dst *image.RGBA, iface *Interface, widget *Widget, parent image.Rectangle) error {
+	if widget.IsHidden {
+		return nil
+	}
+
+	bounds := widget.Layout(parent)
+	alpha := 255 - widget.Opacity
+
+	switch widget.Type {
+	case WidgetTypeLayer:
+		clipped, ok := dst.SubImage(bounds).(*image.RGBA)
+		if !ok || clipped.Bounds().Empty() {
+			return nil
+		}
+		for _, childID := range widget.Children {
+			child := iface.Components[uint16(childID)]
+			if err := r.drawWidget(clipped, iface, child, bounds); err != nil {
+				return err
+			}
+		}
+	case WidgetTypeRectangle:
+		drawRectangle(dst, bounds, rgb(widget.TextColor, alpha), widget.Filled)
+	case WidgetTypeGraphic:
+		return r.drawGraphic(dst, widget, bounds, alpha)
+	case WidgetTypeText:
+		if r.text != nil {
+			return r.text.DrawWidgetText(dst, widget, bounds)
+		}
+	case WidgetTypeInventory:
+		return r.drawInventory(dst, widget, bounds)
+	case WidgetTypeLine:
+		drawLine(dst, bounds.Min, bounds.Min.Add(image.Pt(int(widget.Width), int(widget.Height))),
+			int(widget.LineWidth), rgb(widget.TextColor, alpha))
+	}
+	return nil
+}
+
+func (r *InterfaceRenderer) drawGraphic(dst *image.RGBA, widget *Widget, bounds image.Rectangle, alpha uint8) error {
+	if widget.SpriteID < 0 {
+		return nil
+	}
+
+	img, err := r.sprite(widget.SpriteID)
+	if err != nil {
+		return err
+	}
+	if widget.FlippedHorizontally || widget.FlippedVertically {
+		img = flipImage(img, widget.FlippedHorizontally, widget.FlippedVertically)
+	}
+	if widget.BorderType > 0 {
+		img = outlineImage(img, color.RGBA{0, 0, 1, 255})
+		if widget.BorderType > 1 {
+			img = outlineImage(img, color.RGBA{255, 255, 255, 255})
+		}
+	}
+
+	if border, ok := r.slices[widget.SpriteID]; ok && bounds.Size() != img.Bounds().Size() {
+		r.drawSprite(dst, nineSliceImage(img, bounds.Dx(), bounds.Dy(), border), bounds.Min, widget.ShadowColor, alpha)
+		return nil
+	}
+
+	if widget.SpriteTiling {
+		size := img.Bounds().Size()
+		if size.X == 0 || size.Y == 0 {
+			return nil
+		}
+		clipped, ok := dst.SubImage(bounds).(*image.RGBA)
+		if !ok {
+			return nil
+		}
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += size.Y {
+			for x := bounds.Min.X; x < bounds.Max.X; x += size.X {
+				r.drawSprite(clipped, img, image.Pt(x, y), widget.ShadowColor, alpha)
+			}
+		}
+		return nil
+	}
+
+	if widget.IsIf3 && bounds.Size() != img.Bounds().Size() {
+		img = scaleImage(img, bounds.Dx(), bounds.Dy())
+	}
+	r.drawSprite(dst, img, bounds.Min, widget.ShadowColor, alpha)
+	return nil
+}
+
+func (r *InterfaceRenderer) drawSprite(dst *image.RGBA, img *image.RGBA, at image.Point, shadowColor int32, alpha uint8) {
+	mask := image.NewUniform(color.Alpha{A: alpha})
+	rect := img.Bounds().Sub(img.Bounds().Min).Add(at)
+	if shadowColor != 0 {
+		shadow := image.NewUniform(rgb(shadowColor, 255))
+		draw.DrawMask(dst, rect.Add(image.Pt(1, 1)), shadow, image.Point{}, img, img.Bounds().Min, draw.Over)
+	}
+	draw.DrawMask(dst, rect, img, img.Bounds().Min, mask, image.Point{}, draw.Over)
+}
+
+// drawInventory draws the items of a legacy inventory component, whose width
+// and height count 32x32 slots separated by its pitch.
+func (r *InterfaceRenderer) drawInventory(dst *image.RGBA, widget *Widget, bounds image.Rectangle) error {
+	if r.icons == nil || widget.Width <= 0 {
+		return nil
+	}
+
+	for slot, item := range r.items[widget.ID] {
+		if item.ID < 0 || slot >= int(widget.Width*widget.Height) {
+			continue
+		}
+
+		icon, err := r.icons(item.ID, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("getting icon of item %d: %w", item.ID, err)
+		}
+
+		column, row := slot%int(widget.Width), slot/int(widget.Width)
+		at := bounds.Min.Add(image.Pt(column*(32+int(widget.XPitch)), row*(32+int(widget.YPitch))))
+		if slot < len(widget.XOffsets) {
+			at = at.Add(image.Pt(int(widget.XOffsets[slot]), int(widget.YOffsets[slot])))
+		}
+		rect := icon.Bounds().Sub(icon.Bounds().Min).Add(at)
+		draw.Draw(dst, rect, icon, icon.Bounds().Min, draw.Over)
+	}
+	return nil
+}
+
+func (r *InterfaceRenderer) sprite(id int32) (*image.RGBA, error) {
+	if img, ok := r.sprites[id]; ok {
+		return img, nil
+	}
+
+	sprite, err := r.cache.Sprite(uint16(id))
+	if err != nil {
+		return nil, fmt.Errorf("getting sprite %d: %w", id, err)
+	}
+	img := sprite.Image()
+	r.sprites[id] = img
+	return img, nil
+}
+
+func rgb(value int32, alpha uint8) color.RGBA {
+	// Premultiplied, as image.RGBA expects.
+	scale := func(c int32) uint8 {
+		return uint8(int(c&0xFF) * int(alpha) / 255)
+	}
+	return color.RGBA{R: scale(value >> 16), G: scale(value >> 8), B: scale(value), A: alpha}
+}
+
+func drawRectangle(dst *image.RGBA, bounds image.Rectangle, c color.RGBA, filled bool) {
+	src := image.NewUniform(c)
+	if filled {
+		draw.Draw(dst, bounds, src, image.Point{}, draw.Over)
+		return
+	}
+
+	edges := []image.Rectangle{
+		image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y+1),
+		image.Rect(bounds.Min.X, bounds.Max.Y-1, bounds.Max.X, bounds.Max.Y),
+		image.Rect(bounds.Min.X, bounds.Min.Y+1, bounds.Min.X+1, bounds.Max.Y-1),
+		image.Rect(bounds.Max.X-1, bounds.Min.Y+1, bounds.Max.X, bounds.Max.Y-1),
+	}
+	for _, edge := range edges {
+		draw.Draw(dst, edge.Intersect(bounds), src, image.Point{}, draw.Over)
+	}
+}
+
+// drawLine draws a line of the given width with Bresenham's algorithm.
+func drawLine(dst *image.RGBA, from, to image.Point, width int, c color.RGBA) {
+	src := image.NewUniform(c)
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := sign(to.X-from.X), sign(to.Y-from.Y)
+	e := dx + dy
+
+	p := from
+	for {
+		draw.Draw(dst, image.Rect(p.X, p.Y, p.X+width, p.Y+width), src, image.Point{}, draw.Over)
+		if p == to {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p.Y += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func flipImage(src *image.RGBA, horizontal, vertical bool) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			sx, sy := x, y
+			if horizontal {
+				sx = b.Dx() - 1 - x
+			}
+			if vertical {
+				sy = b.Dy() - 1 - y
+			}
+			dst.SetRGBA(x, y, src.RGBAAt(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// scaleImage resizes src to width by height with nearest neighbour sampling.
+func scaleImage(src *image.RGBA, width, height int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, max(width, 0), max(height, 0)))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dst.SetRGBA(x, y, src.RGBAAt(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	return dst
+}
+
+// nineSliceImage resizes src to width by height, keeping the corners of
+// border at their size and stretching the edges and centre between them.
+func nineSliceImage(src *image.RGBA, width, height int, border NineSlice) *image.RGBA {
+	b := src.Bounds()
+	// Borders wider than the source or the destination are clipped.
+	cuts := func(size, dstSize, start, end int) ([4]int, [4]int) {
+		start = min(max(start, 0), size, dstSize)
+		end = min(max(end, 0), size-start, dstSize-start)
+		return [4]int{0, start, size - end, size}, [4]int{0, start, dstSize - end, dstSize}
+	}
+	srcX, dstX := cuts(b.Dx(), width, border.Left, border.Right)
+	srcY, dstY := cuts(b.Dy(), height, border.Top, border.Bottom)
+
+	dst := image.NewRGBA(image.Rect(0, 0, max(width, 0), max(height, 0)))
+	for row := range 3 {
+		for column := range 3 {
+			from := image.Rect(srcX[column], srcY[row], srcX[column+1], srcY[row+1]).Add(b.Min)
+			to := image.Rect(dstX[column], dstY[row], dstX[column+1], dstY[row+1])
+			if from.Empty() || to.Empty() {
+				continue
+			}
+			part := scaleImage(src.SubImage(from).(*image.RGBA), to.Dx(), to.Dy())
+			draw.Draw(dst, to, part, image.Point{}, draw.Src)
+		}
+	}
+	return dst
+}
+
+// outlineImage colors the transparent pixels of src that touch an opaque
+// pixel.
+func outlineImage(src *image.RGBA, c color.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	opaque := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < b.Dx() && y < b.Dy() && src.RGBAAt(b.Min.X+x, b.Min.Y+y).A != 0
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			switch {
+			case opaque(x, y):
+				dst.SetRGBA(x, y, src.RGBAAt(b.Min.X+x, b.Min.Y+y))
+			case opaque(x-1, y) || opaque(x+1, y) || opaque(x, y-1) || opaque(x, y+1):
+				dst.SetRGBA(x, y, c)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,51 @@
+package osrscache
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestNineSliceImage(t *testing.T) {
+	// A 3x3 sprite with a one pixel border, each pixel a distinct colour.
+	src := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	for y := range 3 {
+		for x := range 3 {
+			src.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+
+	dst := nineSliceImage(src, 7, 5, NineSlice{Left: 1, Top: 1, Right: 1, Bottom: 1})
+	if got := dst.Bounds().Size(); got != image.Pt(7, 5) {
+		t.Fatalf("size = %v, want 7x5", got)
+	}
+	// Each destination pixel samples the source pixel of its slice.
+	for y := range 5 {
+		for x := range 7 {
+			want := color.RGBA{R: sliceIndex(x, 7), G: sliceIndex(y, 5), A: 255}
+			if got := dst.RGBAAt(x, y); got != want {
+				t.Errorf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestNineSliceImageClipsBorder(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	dst := nineSliceImage(src, 2, 2, NineSlice{Left: 3, Top: 3, Right: 3, Bottom: 3})
+	if got := dst.Bounds().Size(); got != image.Pt(2, 2) {
+		t.Fatalf("size = %v, want 2x2", got)
+	}
+}
+
+// sliceIndex returns the source column or row of position i in a 9-slice of size
+// n with a one pixel border around a one pixel centre.
+func sliceIndex(i, n int) uint8 {
+	switch i {
+	case 0:
+		return 0
+	case n - 1:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,790 @@
+package osrscache
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Opcodes of the instructions the script format treats specially. Opcodes of
+// 100 and above are commands implemented by the client.
+const (
+	OpIconst           = 0
+	OpGetVarp          = 1
+	OpSetVarp          = 2
+	OpSconst           = 3
+	OpJump             = 6
+	OpIfIcmpne         = 7
+	OpIfIcmpeq         = 8
+	OpIfIcmplt         = 9
+	OpIfIcmpgt         = 10
+	OpReturn           = 21
+	OpGetVarbit        = 25
+	OpSetVarbit        = 27
+	OpIfIcmple         = 31
+	OpIfIcmpge         = 32
+	OpIload            = 33
+	OpIstore           = 34
+	OpSload            = 35
+	OpSstore           = 36
+	OpJoinString       = 37
+	OpPopInt           = 38
+	OpPopString        = 39
+	OpInvoke           = 40
+	OpGetVarcInt       = 42
+	OpSetVarcInt       = 43
+	OpDefineArray      = 44
+	OpGetArrayInt      = 45
+	OpSetArrayInt      = 46
+	OpGetVarcStringOld = 47
+	OpSetVarcStringOld = 48
+	OpGetVarcString    = 49
+	OpSetVarcString    = 50
+	OpSwitch           = 60
+)
+
+var opcodeNames = map[uint16]string{
+	OpIconst:           "iconst",
+	OpGetVarp:          "get_varp",
+	OpSetVarp:          "set_varp",
+	OpSconst:           "sconst",
+	OpJump:             "jump",
+	OpIfIcmpne:         "if_icmpne",
+	OpIfIcmpeq:         "if_icmpeq",
+	OpIfIcmplt:         "if_icmplt",
+	OpIfIcmpgt:         "if_icmpgt",
+	OpReturn:           "return",
+	OpGetVarbit:        "get_varbit",
+	OpSetVarbit:        "set_varbit",
+	OpIfIcmple:         "if_icmple",
+	OpIfIcmpge:         "if_icmpge",
+	OpIload:            "iload",
+	OpIstore:           "istore",
+	OpSload:            "sload",
+	OpSstore:           "sstore",
+	OpJoinString:       "join_string",
+	OpPopInt:           "pop_int",
+	OpPopString:        "pop_string",
+	OpInvoke:           "invoke",
+	OpGetVarcInt:       "get_varc_int",
+	OpSetVarcInt:       "set_varc_int",
+	OpDefineArray:      "define_array",
+	OpGetArrayInt:      "get_array_int",
+	OpSetArrayInt:      "set_array_int",
+	OpGetVarcStringOld: "get_varc_string_old",
+	OpSetVarcStringOld: "set_varc_string_old",
+	OpGetVarcString:    "get_varc_string",
+	OpSetVarcString:    "set_varc_string",
+	OpSwitch:           "switch",
+
+	100: "cc_create",
+	101: "cc_delete",
+	102: "cc_deleteall",
+	200: "cc_find",
+	201: "if_find",
+
+	1000: "cc_setposition",
+	1001: "cc_setsize",
+	1003: "cc_sethide",
+	1005: "cc_setnoclickthrough",
+	1006: "cc_setnoscrollthrough",
+
+	1100: "cc_setscrollpos",
+	1101: "cc_setcolour",
+	1102: "cc_setfill",
+	1103: "cc_settrans",
+	1104: "cc_setlinewid",
+	1105: "cc_setgraphic",
+	1106: "cc_set2dangle",
+	1107: "cc_settiling",
+	1108: "cc_setmodel",
+	1109: "cc_setmodelangle",
+	1110: "cc_setmodelanim",
+	1111: "cc_setmodelorthog",
+	1112: "cc_settext",
+	1113: "cc_settextfont",
+	1114: "cc_settextalign",
+	1115: "cc_settextshadow",
+	1116: "cc_setoutline",
+	1117: "cc_setgraphicshadow",
+	1118: "cc_setvflip",
+	1119: "cc_sethflip",
+	1120: "cc_setscrollsize",
+	1121: "cc_resume_pausebutton",
+
+	1200: "cc_setobject",
+	1201: "cc_setnpchead",
+	1202: "cc_setplayerhead_self",
+	1205: "cc_setobject_nonum",
+	1212: "cc_setobject_always_num",
+
+	1300: "cc_setop",
+	1301: "cc_setdraggable",
+	1302: "cc_setdraggablebehavior",
+	1303: "cc_setdragdeadzone",
+	1304: "cc_setdragdeadtime",
+	1305: "cc_setopbase",
+	1306: "cc_settargetverb",
+	1307: "cc_clearops",
+
+	1400: "cc_setonclick",
+	1401: "cc_setonhold",
+	1402: "cc_setonrelease",
+	1403: "cc_setonmouseover",
+	1404: "cc_setonmouseleave",
+	1405: "cc_setondrag",
+	1406: "cc_setontargetleave",
+	1407: "cc_setonvartransmit",
+	1408: "cc_setontimer",
+	1409: "cc_setonop",
+	1410: "cc_setondragcomplete",
+	1411: "cc_setonclickrepeat",
+	1412: "cc_setonmouserepeat",
+	1414: "cc_setoninvtransmit",
+	1415: "cc_setonstattransmit",
+	1416: "cc_setontargetenter",
+	1417: "cc_setonscrollwheel",
+	1418: "cc_setonchattransmit",
+	1419: "cc_setonkey",
+	1420: "cc_setonfriendtransmit",
+	1421: "cc_setonclantransmit",
+	1422: "cc_setonmisctransmit",
+	1423: "cc_setondialogabort",
+	1424: "cc_setonsubchange",
+	1425: "cc_setonstocktransmit",
+	1427: "cc_setonresize",
+	1428: "cc_setonclansettingstransmit",
+	1429: "cc_setonclanchanneltransmit",
+
+	1500: "cc_getx",
+	1501: "cc_gety",
+	1502: "cc_getwidth",
+	1503: "cc_getheight",
+	1504: "cc_gethide",
+	1505: "cc_getlayer",
+
+	1600: "cc_getscrollx",
+	1601: "cc_getscrolly",
+	1602: "cc_gettext",
+	1603: "cc_getscrollwidth",
+	1604: "cc_getscrollheight",
+	1605: "cc_getmodelzoom",
+	1606: "cc_getmodelangle_x",
+	1607: "cc_getmodelangle_z",
+	1608: "cc_getmodelangle_y",
+	1609: "cc_gettrans",
+	1611: "cc_getcolour",
+	1612: "cc_getfillcolour",
+
+	1700: "cc_getinvobject",
+	1701: "cc_getinvcount",
+	1702: "cc_getid",
+
+	1800: "cc_gettargetmask",
+	1801: "cc_getop",
+	1802: "cc_getopbase",
+
+	1927: "cc_callonresize",
+
+	2000: "if_setposition",
+	2001: "if_setsize",
+	2003: "if_sethide",
+	2005: "if_setnoclickthrough",
+	2006: "if_setnoscrollthrough",
+
+	2100: "if_setscrollpos",
+	2101: "if_setcolour",
+	2102: "if_setfill",
+	2103: "if_settrans",
+	2104: "if_setlinewid",
+	2105: "if_setgraphic",
+	2106: "if_set2dangle",
+	2107: "if_settiling",
+	2108: "if_setmodel",
+	2109: "if_setmodelangle",
+	2110: "if_setmodelanim",
+	2111: "if_setmodelorthog",
+	2112: "if_settext",
+	2113: "if_settextfont",
+	2114: "if_settextalign",
+	2115: "if_settextshadow",
+	2116: "if_setoutline",
+	2117: "if_setgraphicshadow",
+	2118: "if_setvflip",
+	2119: "if_sethflip",
+	2120: "if_setscrollsize",
+	2121: "if_resume_pausebutton",
+
+	2200: "if_setobject",
+	2201: "if_setnpchead",
+	2202: "if_setplayerhead_self",
+	2205: "if_setobject_nonum",
+	2212: "if_setobject_always_num",
+
+	2300: "if_setop",
+	2301: "if_setdraggable",
+	2302: "if_setdraggablebehavior",
+	2303: "if_setdragdeadzone",
+	2304: "if_setdragdeadtime",
+	2305: "if_setopbase",
+	2306: "if_settargetverb",
+	2307: "if_clearops",
+
+	2400: "if_setonclick",
+	2401: "if_setonhold",
+	2402: "if_setonrelease",
+	2403: "if_setonmouseover",
+	2404: "if_setonmouseleave",
+	2405: "if_setondrag",
+	2406: "if_setontargetleave",
+	2407: "if_setonvartransmit",
+	2408: "if_setontimer",
+	2409: "if_setonop",
+	2410: "if_setondragcomplete",
+	2411: "if_setonclickrepeat",
+	2412: "if_setonmouserepeat",
+	2414: "if_setoninvtransmit",
+	2415: "if_setonstattransmit",
+	2416: "if_setontargetenter",
+	2417: "if_setonscrollwheel",
+	2418: "if_setonchattransmit",
+	2419: "if_setonkey",
+	2420: "if_setonfriendtransmit",
+	2421: "if_setonclantransmit",
+	2422: "if_setonmisctransmit",
+	2423: "if_setondialogabort",
+	2424: "if_setonsubchange",
+	2425: "if_setonstocktransmit",
+	2427: "if_setonresize",
+	2428: "if_setonclansettingstransmit",
+	2429: "if_setonclanchanneltransmit",
+
+	2500: "if_getx",
+	2501: "if_gety",
+	2502: "if_getwidth",
+	2503: "if_getheight",
+	2504: "if_gethide",
+	2505: "if_getlayer",
+
+	2600: "if_getscrollx",
+	2601: "if_getscrolly",
+	2602: "if_gettext",
+	2603: "if_getscrollwidth",
+	2604: "if_getscrollheight",
+	2605: "if_getmodelzoom",
+	2606: "if_getmodelangle_x",
+	2607: "if_getmodelangle_z",
+	2608: "if_getmodelangle_y",
+	2609: "if_gettrans",
+	2611: "if_getcolour",
+	2612: "if_getfillcolour",
+
+	2700: "if_getinvobject",
+	2701: "if_getinvcount",
+	2702: "if_hassub",
+	2706: "if_gettop",
+
+	2800: "if_gettargetmask",
+	2801: "if_getop",
+	2802: "if_getopbase",
+
+	3100: "mes",
+	3101: "anim",
+	3103: "if_close",
+	3104: "resume_countdialog",
+	3105: "resume_namedialog",
+	3106: "resume_stringdialog",
+	3107: "opplayer",
+	3108: "if_dragpickup",
+	3109: "cc_dragpickup",
+	3110: "mousecam",
+	3111: "getremoveroofs",
+	3112: "setremoveroofs",
+	3113: "openurl",
+	3115: "resume_objdialog",
+	3116: "bug_report",
+	3117: "setshiftclickdrop",
+	3118: "setshowmouseovertext",
+	3119: "renderself",
+	3125: "setshowmousecross",
+	3126: "setshowloadingmessages",
+	3127: "settaptodrop",
+	3128: "gettaptodrop",
+	3132: "getcanvassize",
+	3141: "sethideusername",
+	3142: "gethideusername",
+	3143: "setrememberusername",
+	3144: "getrememberusername",
+
+	3200: "sound_synth",
+	3201: "sound_song",
+	3202: "sound_jingle",
+
+	3300: "clientclock",
+	3301: "inv_getobj",
+	3302: "inv_getnum",
+	3303: "inv_total",
+	3304: "inv_size",
+	3305: "stat",
+	3306: "stat_base",
+	3307: "stat_xp",
+	3308: "coord",
+	3309: "coordx",
+	3310: "coordz",
+	3311: "coordy",
+	3312: "map_members",
+	3313: "invother_getobj",
+	3314: "invother_getnum",
+	3315: "invother_total",
+	3316: "staffmodlevel",
+	3317: "reboottimer",
+	3318: "map_world",
+	3321: "runenergy_visible",
+	3322: "runweight_visible",
+	3323: "playermod",
+	3324: "worldflags",
+	3325: "movecoord",
+
+	3400: "enum_string",
+	3408: "enum",
+	3411: "enum_getoutputcount",
+
+	3600: "friend_count",
+	3601: "friend_getname",
+	3602: "friend_getworld",
+	3603: "friend_getrank",
+	3604: "friend_setrank",
+	3605: "friend_add",
+	3606: "friend_del",
+	3607: "ignore_add",
+	3608: "ignore_del",
+	3609: "friend_test",
+	3611: "clan_getchatdisplayname",
+	3612: "clan_getchatcount",
+	3613: "clan_getchatusername",
+	3614: "clan_getchatuserworld",
+	3615: "clan_getchatuserrank",
+	3616: "clan_getchatminkick",
+	3617: "clan_kickuser",
+	3618: "clan_getchatrank",
+	3619: "clan_joinchat",
+	3620: "clan_leavechat",
+	3621: "ignore_count",
+	3622: "ignore_getname",
+	3623: "ignore_test",
+	3624: "clan_isself",
+	3625: "clan_getchatownername",
+	3626: "clan_isfriend",
+	3627: "clan_isignore",
+
+	3903: "stockmarket_getoffertype",
+	3904: "stockmarket_getofferitem",
+	3905: "stockmarket_getofferprice",
+	3906: "stockmarket_getoffercount",
+	3907: "stockmarket_getoffercompletedcount",
+	3908: "stockmarket_getoffercompletedgold",
+	3910: "stockmarket_isofferempty",
+	3911: "stockmarket_isofferstable",
+	3912: "stockmarket_isofferfinished",
+	3913: "stockmarket_isofferadding",
+	3914: "tradingpost_sortby_name",
+	3915: "tradingpost_sortby_price",
+	3916: "tradingpost_sortfilterby_world",
+	3917: "tradingpost_sortby_age",
+	3918: "tradingpost_sortby_count",
+	3919: "tradingpost_gettotaloffers",
+	3920: "tradingpost_getofferworld",
+	3921: "tradingpost_getoffername",
+	3922: "tradingpost_getofferpreviousname",
+	3923: "tradingpost_getofferage",
+	3924: "tradingpost_getoffercount",
+	3925: "tradingpost_getofferprice",
+	3926: "tradingpost_getofferitem",
+
+	4000: "add",
+	4001: "sub",
+	4002: "multiply",
+	4003: "div",
+	4004: "random",
+	4005: "randominc",
+	4006: "interpolate",
+	4007: "addpercent",
+	4008: "setbit",
+	4009: "clearbit",
+	4010: "testbit",
+	4011: "mod",
+	4012: "pow",
+	4013: "invpow",
+	4014: "and",
+	4015: "or",
+	4016: "min",
+	4017: "max",
+	4018: "scale",
+
+	4100: "append_num",
+	4101: "append",
+	4102: "append_signnum",
+	4103: "lowercase",
+	4104: "fromdate",
+	4105: "text_gender",
+	4106: "tostring",
+	4107: "compare",
+	4108: "paraheight",
+	4109: "parawidth",
+	4110: "text_switch",
+	4111: "escape",
+	4112: "append_char",
+	4113: "char_isprintable",
+	4114: "char_isalphanumeric",
+	4115: "char_isalpha",
+	4116: "char_isnumeric",
+	4117: "string_length",
+	4118: "substring",
+	4119: "removetags",
+	4120: "string_indexof_char",
+	4121: "string_indexof_string",
+
+	4200: "oc_name",
+	4201: "oc_op",
+	4202: "oc_iop",
+	4203: "oc_cost",
+	4204: "oc_stackable",
+	4205: "oc_cert",
+	4206: "oc_uncert",
+	4207: "oc_members",
+	4208: "oc_param",
+	4210: "oc_find",
+	4211: "oc_findnext",
+	4212: "oc_findreset",
+
+	5000: "chat_getfilter_public",
+	5001: "chat_setfilter",
+	5002: "chat_sendabusereport",
+	5003: "chat_gethistory_bytypeandline",
+	5004: "chat_gethistory_byuid",
+	5005: "chat_getfilter_private",
+	5008: "chat_sendpublic",
+	5009: "chat_sendprivate",
+	5015: "chat_playername",
+	5016: "chat_getfilter_trade",
+	5017: "chat_gethistorylength",
+	5018: "chat_getnextuid",
+	5019: "chat_getprevuid",
+	5020: "docheat",
+	5021: "chat_setmessagefilter",
+	5022: "chat_getmessagefilter",
+
+	5306: "getwindowmode",
+	5307: "setwindowmode",
+	5308: "getdefaultwindowmode",
+	5309: "setdefaultwindowmode",
+
+	5504: "cam_forceangle",
+	5505: "cam_getangle_xa",
+	5506: "cam_getangle_ya",
+	5530: "cam_setfollowheight",
+	5531: "cam_getfollowheight",
+
+	5630: "logout",
+
+	6200: "viewport_setfov",
+	6201: "viewport_setzoom",
+	6202: "viewport_clampfov",
+	6203: "viewport_geteffectivesize",
+	6204: "viewport_getzoom",
+	6205: "viewport_getfov",
+
+	6500: "worldlist_fetch",
+	6501: "worldlist_start",
+	6502: "worldlist_next",
+	6506: "worldlist_specific",
+	6507: "worldlist_sort",
+	6512: "setfolloweropslowpriority",
+	6513: "nc_param",
+	6514: "lc_param",
+	6515: "struct_param",
+	6518: "on_mobile",
+	6519: "clienttype",
+
+	6601: "worldmap_getmapname",
+	6602: "worldmap_setmap",
+	6603: "worldmap_getzoom",
+	6604: "worldmap_setzoom",
+	6605: "worldmap_isloaded",
+	6606: "worldmap_jumptodisplaycoord",
+	6607: "worldmap_jumptodisplaycoord_instant",
+	6608: "worldmap_jumptosourcecoord",
+	6609: "worldmap_jumptosourcecoord_instant",
+	6610: "worldmap_getdisplayposition",
+	6611: "worldmap_getconfigorigin",
+	6612: "worldmap_getconfigsize",
+	6613: "worldmap_getconfigbounds",
+	6614: "worldmap_getconfigzoom",
+	6616: "worldmap_getcurrentmap",
+	6617: "worldmap_getdisplaycoord",
+	6621: "worldmap_coordinmap",
+	6622: "worldmap_getsize",
+	6628: "worldmap_perpetualflash",
+	6629: "worldmap_flashelement",
+	6630: "worldmap_flashelementcategory",
+	6631: "worldmap_stopcurrentflashes",
+	6632: "worldmap_disableelements",
+	6633: "worldmap_disableelement",
+	6634: "worldmap_disableelementcategory",
+	6635: "worldmap_getdisableelements",
+	6636: "worldmap_getdisableelement",
+	6637: "worldmap_getdisableelementcategory",
+	6639: "worldmap_listelement_start",
+	6640: "worldmap_listelement_next",
+	6693: "mec_text",
+	6694: "mec_textsize",
+	6695: "mec_category",
+	6696: "mec_sprite",
+	6697: "worldmap_element",
+	6699: "worldmap_elementcoord",
+}
+
+// OpcodeName returns the symbolic name of an opcode, or the opcode as a
+// zero-padded number when it has no known name.
+func OpcodeName(opcode uint16) string {
+	if name, ok := opcodeNames[opcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("%03d", opcode)
+}
+
+// IsJump reports whether opcode branches by its int operand.
+func IsJump(opcode uint16) bool {
+	switch opcode {
+	case OpJump, OpIfIcmpne, OpIfIcmpeq, OpIfIcmplt, OpIfIcmpgt, OpIfIcmple, OpIfIcmpge:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasIntOperand reports whether opcode is followed by a four byte operand.
+// Other opcodes besides sconst carry a single byte.
+func hasIntOperand(opcode uint16) bool {
+	return opcode < 100 && opcode != OpReturn && opcode != OpPopInt && opcode != OpPopString
+}
+
+type Instruction struct {
+	Opcode        uint16 `json:"opcode"`
+	IntOperand    int32  `json:"int_operand"`
+	StringOperand string `json:"string_operand,omitempty"`
+}
+
+// ClientScript is a compiled client script (CS2) from archive 12. Jump and
+// switch targets are relative: an offset o at instruction pc targets pc+o+1.
+type ClientScript struct {
+	ID                  uint16 `json:"id"`
+	Name                string `json:"name,omitempty"`
+	LocalIntCount       uint16 `json:"local_int_count"`
+	LocalStringCount    uint16 `json:"local_string_count"`
+	IntArgumentCount    uint16 `json:"int_argument_count"`
+	StringArgumentCount uint16 `json:"string_argument_count"`
+	// LocalLongCount and LongArgumentCount are set by scripts whose trailer
+	// also counts long locals, and are otherwise zero.
+	LocalLongCount    uint16         `json:"local_long_count,omitempty"`
+	LongArgumentCount uint16         `json:"long_argument_count,omitempty"`
+	Instructions      []Instruction  `json:"instructions"`
+	Switches          [][]SwitchCase `json:"switches,omitempty"`
+}
+
+// SwitchCase is a case of a switch table. Cases keep their encoded order so
+// that reassembled scripts are byte for byte identical.
+type SwitchCase struct {
+	Key    int32 `json:"key"`
+	Offset int32 `json:"offset"`
+}
+
+func NewClientScript(id uint16) *ClientScript {
+	return &ClientScript{ID: id}
+}
+
+// Read decodes a script. The instructions are followed by a trailer holding
+// the instruction count, local and argument counts and the switch tables, and
+// the data ends with the length of the switch tables. Trailers that also count
+// long locals and arguments are recognised by their instruction count.
+func (s *ClientScript) Read(data []byte) error {
+	err := s.read(data, false)
+	if err != nil && s.read(data, true) == nil {
+		return nil
+	}
+	return err
+}
+
+// read decodes a script whose trailer counts long locals if longs is set.
+func (s *ClientScript) read(data []byte, longs bool) error {
+	reader := NewReader(data)
+
+	if _, err := reader.Seek(reader.Size()-2, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking to switch length: %w", err)
+	}
+	switchLength, err := reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading switch length: %w", err)
+	}
+
+	counts := []*uint16{&s.LocalIntCount, &s.LocalStringCount, &s.IntArgumentCount, &s.StringArgumentCount}
+	s.LocalLongCount, s.LongArgumentCount = 0, 0
+	if longs {
+		counts = []*uint16{&s.LocalIntCount, &s.LocalStringCount, &s.LocalLongCount, &s.IntArgumentCount, &s.StringArgumentCount, &s.LongArgumentCount}
+	}
+	trailerLength := 4 + 2*int64(len(counts))
+
+	end := reader.Size() - 2 - int64(switchLength) - trailerLength
+	if end < 0 {
+		return fmt.Errorf("trailer of %d bytes does not fit in %d bytes", int64(switchLength)+trailerLength+2, reader.Size())
+	}
+	if _, err := reader.Seek(end, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking to trailer: %w", err)
+	}
+
+	instructionCount, err := reader.ReadUint32()
+	if err != nil {
+		return fmt.Errorf("reading instruction count: %w", err)
+	}
+	for _, count := range counts {
+		*count, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading local counts: %w", err)
+		}
+	}
+
+	switchCount, err := reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading switch count: %w", err)
+	}
+	s.Switches = nil
+	for range switchCount {
+		caseCount, err := reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading switch case count: %w", err)
+		}
+		table := make([]SwitchCase, caseCount)
+		for i := range table {
+			key, err := reader.ReadInt32()
+			if err != nil {
+				return fmt.Errorf("reading switch key: %w", err)
+			}
+			offset, err := reader.ReadInt32()
+			if err != nil {
+				return fmt.Errorf("reading switch offset: %w", err)
+			}
+			table[i] = SwitchCase{Key: key, Offset: offset}
+		}
+		s.Switches = append(s.Switches, table)
+	}
+
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking to start: %w", err)
+	}
+
+	// A name precedes the instructions, which compiled scripts leave empty.
+	s.Name, err = reader.ReadString()
+	if err != nil {
+		return fmt.Errorf("reading name: %w", err)
+	}
+
+	s.Instructions = make([]Instruction, 0, min(instructionCount, uint32(len(data))))
+	for {
+		offset, err := reader.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("getting offset: %w", err)
+		}
+		if offset >= end {
+			break
+		}
+
+		var ins Instruction
+		ins.Opcode, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading opcode: %w", err)
+		}
+		switch {
+		case ins.Opcode == OpSconst:
+			ins.StringOperand, err = reader.ReadString()
+		case hasIntOperand(ins.Opcode):
+			ins.IntOperand, err = reader.ReadInt32()
+		default:
+			var operand uint8
+			operand, err = reader.ReadUint8()
+			ins.IntOperand = int32(operand)
+		}
+		if err != nil {
+			return fmt.Errorf("reading operand of instruction %d: %w", len(s.Instructions), err)
+		}
+		s.Instructions = append(s.Instructions, ins)
+	}
+
+	if len(s.Instructions) != int(instructionCount) {
+		return fmt.Errorf("decoded %d instructions, want %d", len(s.Instructions), instructionCount)
+	}
+	return nil
+}
+
+// Disassemble writes the script as RuneLite compatible .rs2asm text, with
+// jump and switch targets replaced by labels.
+func (s *ClientScript) Disassemble(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".id                 %d\n", s.ID)
+	if s.Name != "" {
+		fmt.Fprintf(&b, ".name               %s\n", strconv.Quote(s.Name))
+	}
+	fmt.Fprintf(&b, ".int_stack_count    %d\n", s.IntArgumentCount)
+	fmt.Fprintf(&b, ".string_stack_count %d\n", s.StringArgumentCount)
+	fmt.Fprintf(&b, ".int_var_count      %d\n", s.LocalIntCount)
+	fmt.Fprintf(&b, ".string_var_count   %d\n", s.LocalStringCount)
+	if s.LongArgumentCount != 0 || s.LocalLongCount != 0 {
+		fmt.Fprintf(&b, ".long_stack_count   %d\n", s.LongArgumentCount)
+		fmt.Fprintf(&b, ".long_var_count     %d\n", s.LocalLongCount)
+	}
+
+	labels := s.jumpTargets()
+	for pc, ins := range s.Instructions {
+		if labels[pc] {
+			fmt.Fprintf(&b, "LABEL%d:\n", pc)
+		}
+
+		line := fmt.Sprintf("   %-22s", OpcodeName(ins.Opcode))
+		switch {
+		case IsJump(ins.Opcode):
+			line += fmt.Sprintf(" LABEL%d", pc+int(ins.IntOperand)+1)
+		case ins.Opcode == OpSconst:
+			line += " " + strconv.Quote(ins.StringOperand)
+		case ins.Opcode == OpSwitch:
+		case hasIntOperand(ins.Opcode) || ins.IntOperand != 0:
+			line += fmt.Sprintf(" %d", ins.IntOperand)
+		}
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteByte('\n')
+
+		if ins.Opcode == OpSwitch && int(ins.IntOperand) < len(s.Switches) {
+			for _, c := range s.Switches[ins.IntOperand] {
+				fmt.Fprintf(&b, "      %d: LABEL%d\n", c.Key, pc+int(c.Offset)+1)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// jumpTargets returns the instructions that jumps or switch cases branch to.
+func (s *ClientScript) jumpTargets() map[int]bool {
+	targets := make(map[int]bool)
+	for pc, ins := range s.Instructions {
+		switch {
+		case IsJump(ins.Opcode):
+			targets[pc+int(ins.IntOperand)+1] = true
+		case ins.Opcode == OpSwitch && int(ins.IntOperand) < len(s.Switches):
+			for _, c := range s.Switches[ins.IntOperand] {
+				targets[pc+int(c.Offset)+1] = true
+			}
+		}
+	}
+	return targets
+}
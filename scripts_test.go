@@ -0,0 +1,61 @@
+package osrscache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOpcodeNamesUnique(t *testing.T) {
+	seen := make(map[string]uint16, len(opcodeNames))
+	for opcode, name := range opcodeNames {
+		if other, ok := seen[name]; ok {
+			t.Errorf("opcodes %d and %d are both named %s", other, opcode, name)
+		}
+		seen[name] = opcode
+	}
+}
+
+func TestClientScriptLongLocals(t *testing.T) {
+	script := &ClientScript{
+		ID:                1,
+		LocalIntCount:     1,
+		LocalLongCount:    2,
+		LongArgumentCount: 1,
+		Instructions: []Instruction{
+			{Opcode: OpIconst, IntOperand: 5},
+			{Opcode: OpReturn},
+		},
+	}
+	data, err := script.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := NewClientScript(1)
+	if err := decoded.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.LocalLongCount != 2 || decoded.LongArgumentCount != 1 || decoded.LocalIntCount != 1 {
+		t.Errorf("counts = %+v", decoded)
+	}
+
+	var asm bytes.Buffer
+	if err := decoded.Disassemble(&asm); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(asm.String(), ".long_var_count     2") {
+		t.Errorf("disassembly is missing the long counts:\n%s", asm.String())
+	}
+	reassembled, err := Assemble(&asm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := reassembled.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("reassembled script differs:\n got %x\nwant %x", again, data)
+	}
+}
@@ -0,0 +1,113 @@
+package osrscache
+
+import "fmt"
+
+// ScriptVarType is the type of a script variable, enum key or value, or param,
+// stored in the cache as a single cp1252 character.
+type ScriptVarType byte
+
+const (
+	TypeInteger      ScriptVarType = 'i'
+	TypeBoolean      ScriptVarType = '1'
+	TypeSeq          ScriptVarType = 'A'
+	TypeColour       ScriptVarType = 'C'
+	TypeComponent    ScriptVarType = 'I'
+	TypeStruct       ScriptVarType = 'J'
+	TypeIDKit        ScriptVarType = 'K'
+	TypeMidi         ScriptVarType = 'M'
+	TypeNPCMode      ScriptVarType = 'N'
+	TypeNamedObj     ScriptVarType = 'O'
+	TypeSynth        ScriptVarType = 'P'
+	TypeArea         ScriptVarType = 'R'
+	TypeStat         ScriptVarType = 'S'
+	TypeNPCStat      ScriptVarType = 'T'
+	TypeMapArea      ScriptVarType = '`'
+	TypeInterface    ScriptVarType = 'a'
+	TypeCoord        ScriptVarType = 'c'
+	TypeGraphic      ScriptVarType = 'd'
+	TypeFontMetrics  ScriptVarType = 'f'
+	TypeEnum         ScriptVarType = 'g'
+	TypeJingle       ScriptVarType = 'j'
+	TypeLoc          ScriptVarType = 'l'
+	TypeModel        ScriptVarType = 'm'
+	TypeNPC          ScriptVarType = 'n'
+	TypeObj          ScriptVarType = 'o'
+	TypeString       ScriptVarType = 's'
+	TypeSpotAnim     ScriptVarType = 't'
+	TypeInv          ScriptVarType = 'v'
+	TypeTexture      ScriptVarType = 'x'
+	TypeCategory     ScriptVarType = 'y'
+	TypeChar         ScriptVarType = 'z'
+	TypeMapSceneIcon ScriptVarType = 0xA3 // '£'
+	TypeMapElement   ScriptVarType = 0xB5 // 'µ'
+	TypeDBRow        ScriptVarType = 0xD0 // 'Ð'
+	TypeHitmark      ScriptVarType = 0xD7 // '×'
+)
+
+var scriptVarTypeNames = map[ScriptVarType]string{
+	TypeInteger:      "int",
+	TypeBoolean:      "boolean",
+	TypeSeq:          "seq",
+	TypeColour:       "colour",
+	TypeComponent:    "component",
+	TypeStruct:       "struct",
+	TypeIDKit:        "idkit",
+	TypeMidi:         "midi",
+	TypeNPCMode:      "npc_mode",
+	TypeNamedObj:     "namedobj",
+	TypeSynth:        "synth",
+	TypeArea:         "area",
+	TypeStat:         "stat",
+	TypeNPCStat:      "npc_stat",
+	TypeMapArea:      "maparea",
+	TypeInterface:    "interface",
+	TypeCoord:        "coord",
+	TypeGraphic:      "graphic",
+	TypeFontMetrics:  "fontmetrics",
+	TypeEnum:         "enum",
+	TypeJingle:       "jingle",
+	TypeLoc:          "loc",
+	TypeModel:        "model",
+	TypeNPC:          "npc",
+	TypeObj:          "obj",
+	TypeString:       "string",
+	TypeSpotAnim:     "spotanim",
+	TypeInv:          "inv",
+	TypeTexture:      "texture",
+	TypeCategory:     "category",
+	TypeChar:         "char",
+	TypeMapSceneIcon: "mapsceneicon",
+	TypeMapElement:   "mapelement",
+	TypeDBRow:        "dbrow",
+	TypeHitmark:      "hitmark",
+}
+
+func (t ScriptVarType) String() string {
+	if name, ok := scriptVarTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("type(%q)", rune(t))
+}
+
+// Coord is a tile position packed into a single int as plane<<28 | x<<14 | y.
+type Coord struct {
+	X     uint16 `json:"x"`
+	Y     uint16 `json:"y"`
+	Plane uint8  `json:"plane"`
+}
+
+func NewCoord(packed int32) Coord {
+	return Coord{
+		X:     uint16(packed >> 14 & 0x3FFF),
+		Y:     uint16(packed & 0x3FFF),
+		Plane: uint8(packed >> 28 & 0x3),
+	}
+}
+
+func (c Coord) Packed() int32 {
+	return int32(c.Plane)<<28 | int32(c.X)<<14 | int32(c.Y)
+}
+
+func (c Coord) String() string {
+	return fmt.Sprintf("%d,%d,%d", c.X, c.Y, c.Plane)
+}
@@ -0,0 +1,86 @@
+package osrscache
+
+import (
+	"iter"
+	"slices"
+)
+
+// Seq streams definitions in ascending ID order, decoding each one only when
+// it is reached. Iteration stops at the first error, which is reported by Err.
+type Seq[K ~uint16 | ~uint32, V any] struct {
+	load   func() ([]K, error)
+	decode func(id K) (V, error)
+	err    error
+}
+
+func (s *Seq[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s.err = nil
+
+		ids, err := s.load()
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		for _, id := range ids {
+			def, err := s.decode(id)
+			if err != nil {
+				s.err = &DecodeError{ID: uint32(id), Err: err}
+				return
+			}
+			if !yield(id, def) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error that stopped the last iteration, if any.
+func (s *Seq[K, V]) Err() error {
+	return s.err
+}
+
+// newFileSeq streams the files of a group. decode is given every file in the
+// group so that definitions can resolve references to each other.
+func newFileSeq[V any](c *Cache, archiveID uint8, groupID uint32, decode func(id uint16, files map[uint32][]byte) (V, error)) *Seq[uint16, V] {
+	var files map[uint32][]byte
+	return &Seq[uint16, V]{
+		load: func() ([]uint16, error) {
+			var err error
+			files, err = c.Files(archiveID, groupID)
+			if err != nil {
+				return nil, err
+			}
+
+			ids := make([]uint16, 0, len(files))
+			for id := range files {
+				ids = append(ids, uint16(id))
+			}
+			slices.Sort(ids)
+			return ids, nil
+		},
+		decode: func(id uint16) (V, error) {
+			return decode(id, files)
+		},
+	}
+}
+
+func newGroupSeq[K ~uint16 | ~uint32, V any](c *Cache, archiveID uint8, decode func(id K) (V, error)) *Seq[K, V] {
+	return &Seq[K, V]{
+		load: func() ([]K, error) {
+			groups, err := c.Store.GroupList(archiveID)
+			if err != nil {
+				return nil, err
+			}
+
+			ids := make([]K, len(groups))
+			for i, group := range groups {
+				ids[i] = K(group)
+			}
+			slices.Sort(ids)
+			return ids, nil
+		},
+		decode: decode,
+	}
+}
@@ -0,0 +1,643 @@
+package osrscache
+
+import (
+	"fmt"
+	"math"
+)
+
+// SoundEffectSampleRate is the rate sound effects are synthesized at.
+const SoundEffectSampleRate = 22050
+
+// Waveforms of an envelope's oscillators.
+const (
+	WaveformOff    = 0
+	WaveformSquare = 1
+	WaveformSine   = 2
+	WaveformSaw    = 3
+	WaveformNoise  = 4
+)
+
+var (
+	sineTable  [0x8000]int32
+	noiseTable [0x8000]int32
+)
+
+func init() {
+	for i := range sineTable {
+		sineTable[i] = int32(math.Sin(float64(i)/5215.1903) * 16384)
+	}
+
+	// The client fills the noise table from java.util.Random seeded with 0.
+	seed := int64(0x5DEECE66D)
+	for i := range noiseTable {
+		seed = (seed*0x5DEECE66D + 0xB) & (1<<48 - 1)
+		noiseTable[i] = (int32(seed>>16) & 2) - 1
+	}
+}
+
+// SoundEffect is a synthesized sound from archive 4, mixed from up to ten
+// instruments.
+type SoundEffect struct {
+	ID          uint16          `json:"id"`
+	Instruments [10]*Instrument `json:"instruments"`
+	// LoopStart and LoopEnd are the looped part of the sound in
+	// milliseconds.
+	LoopStart uint16 `json:"loop_start"`
+	LoopEnd   uint16 `json:"loop_end"`
+}
+
+// Instrument is a set of oscillators shaped by envelopes, an echo and a
+// filter, played for Duration milliseconds after Offset.
+type Instrument struct {
+	Pitch                     *SoundEnvelope `json:"pitch"`
+	Volume                    *SoundEnvelope `json:"volume"`
+	PitchModifier             *SoundEnvelope `json:"pitch_modifier,omitempty"`
+	PitchModifierAmplitude    *SoundEnvelope `json:"pitch_modifier_amplitude,omitempty"`
+	VolumeMultiplier          *SoundEnvelope `json:"volume_multiplier,omitempty"`
+	VolumeMultiplierAmplitude *SoundEnvelope `json:"volume_multiplier_amplitude,omitempty"`
+	Release                   *SoundEnvelope `json:"release,omitempty"`
+	Attack                    *SoundEnvelope `json:"attack,omitempty"`
+	Oscillators               []Oscillator   `json:"oscillators"`
+	DelayTime                 uint16         `json:"delay_time"`
+	DelayDecay                uint16         `json:"delay_decay"`
+	Duration                  uint16         `json:"duration"`
+	Offset                    uint16         `json:"offset"`
+	Filter                    *SoundFilter   `json:"filter"`
+	FilterEnvelope            *SoundEnvelope `json:"filter_envelope"`
+}
+
+// Oscillator is a voice of an instrument. Volume is a percentage, Pitch is in
+// tenths of a semitone relative to the pitch envelope, and Delay is in
+// milliseconds.
+type Oscillator struct {
+	Volume uint16 `json:"volume"`
+	Pitch  int16  `json:"pitch"`
+	Delay  uint16 `json:"delay"`
+}
+
+// SoundEnvelope interpolates between segments over the length of an
+// instrument. Durations are fractions of the length out of 65536.
+type SoundEnvelope struct {
+	Form     uint8             `json:"form"`
+	Start    int32             `json:"start"`
+	End      int32             `json:"end"`
+	Segments []EnvelopeSegment `json:"segments"`
+}
+
+type EnvelopeSegment struct {
+	Duration uint16 `json:"duration"`
+	Phase    uint16 `json:"phase"`
+}
+
+// SoundFilter is a pair of IIR filters, feedforward and feedback, each of up
+// to four pole pairs interpolated between two sets of values.
+type SoundFilter struct {
+	Pairs      [2]uint8        `json:"pairs"`
+	Unity      [2]uint16       `json:"unity"`
+	Phases     [2][2][4]uint16 `json:"phases"`
+	Magnitudes [2][2][4]uint16 `json:"magnitudes"`
+}
+
+func NewSoundEffect(id uint16) *SoundEffect {
+	return &SoundEffect{ID: id}
+}
+
+func NewSoundEnvelope() *SoundEnvelope {
+	return &SoundEnvelope{Segments: []EnvelopeSegment{{0, 0}, {0xFFFF, 0xFFFF}}}
+}
+
+func (s *SoundEffect) Read(data []byte) error {
+	reader := NewReader(data)
+	for i := range s.Instruments {
+		volume, err := reader.PeekUint8()
+		if err != nil {
+			return fmt.Errorf("reading instrument %d: %w", i, err)
+		}
+		if volume == 0 {
+			reader.ReadUint8()
+			continue
+		}
+
+		instrument := &Instrument{}
+		if err := instrument.read(reader); err != nil {
+			return fmt.Errorf("reading instrument %d: %w", i, err)
+		}
+		s.Instruments[i] = instrument
+	}
+
+	var err error
+	s.LoopStart, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading loop start: %w", err)
+	}
+	s.LoopEnd, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading loop end: %w", err)
+	}
+	return nil
+}
+
+func (i *Instrument) read(reader *Reader) error {
+	var err error
+	if i.Pitch, err = readSoundEnvelope(reader); err != nil {
+		return fmt.Errorf("reading pitch envelope: %w", err)
+	}
+	if i.Volume, err = readSoundEnvelope(reader); err != nil {
+		return fmt.Errorf("reading volume envelope: %w", err)
+	}
+
+	// Each optional pair of envelopes is preceded by a non-zero form, or
+	// replaced by a zero byte.
+	pairs := []struct {
+		name         string
+		first, other **SoundEnvelope
+	}{
+		{"pitch modifier", &i.PitchModifier, &i.PitchModifierAmplitude},
+		{"volume multiplier", &i.VolumeMultiplier, &i.VolumeMultiplierAmplitude},
+		{"gate", &i.Release, &i.Attack},
+	}
+	for _, pair := range pairs {
+		form, err := reader.PeekUint8()
+		if err != nil {
+			return fmt.Errorf("reading %s envelopes: %w", pair.name, err)
+		}
+		if form == 0 {
+			reader.ReadUint8()
+			continue
+		}
+		if *pair.first, err = readSoundEnvelope(reader); err != nil {
+			return fmt.Errorf("reading %s envelope: %w", pair.name, err)
+		}
+		if *pair.other, err = readSoundEnvelope(reader); err != nil {
+			return fmt.Errorf("reading %s envelope: %w", pair.name, err)
+		}
+	}
+
+	for range 10 {
+		volume, err := reader.ReadUint16Smart()
+		if err != nil {
+			return fmt.Errorf("reading oscillator volume: %w", err)
+		}
+		if volume == 0 {
+			break
+		}
+		pitch, err := reader.ReadInt16Smart()
+		if err != nil {
+			return fmt.Errorf("reading oscillator pitch: %w", err)
+		}
+		delay, err := reader.ReadUint16Smart()
+		if err != nil {
+			return fmt.Errorf("reading oscillator delay: %w", err)
+		}
+		i.Oscillators = append(i.Oscillators, Oscillator{Volume: volume, Pitch: pitch, Delay: delay})
+	}
+
+	if i.DelayTime, err = reader.ReadUint16Smart(); err != nil {
+		return fmt.Errorf("reading delay time: %w", err)
+	}
+	if i.DelayDecay, err = reader.ReadUint16Smart(); err != nil {
+		return fmt.Errorf("reading delay decay: %w", err)
+	}
+	if i.Duration, err = reader.ReadUint16(); err != nil {
+		return fmt.Errorf("reading duration: %w", err)
+	}
+	if i.Offset, err = reader.ReadUint16(); err != nil {
+		return fmt.Errorf("reading offset: %w", err)
+	}
+
+	i.Filter = &SoundFilter{}
+	i.FilterEnvelope = NewSoundEnvelope()
+	if err := i.Filter.read(reader, i.FilterEnvelope); err != nil {
+		return fmt.Errorf("reading filter: %w", err)
+	}
+	return nil
+}
+
+func readSoundEnvelope(reader *Reader) (*SoundEnvelope, error) {
+	e := &SoundEnvelope{}
+	var err error
+	if e.Form, err = reader.ReadUint8(); err != nil {
+		return nil, fmt.Errorf("reading form: %w", err)
+	}
+	if e.Start, err = reader.ReadInt32(); err != nil {
+		return nil, fmt.Errorf("reading start: %w", err)
+	}
+	if e.End, err = reader.ReadInt32(); err != nil {
+		return nil, fmt.Errorf("reading end: %w", err)
+	}
+	if err := e.readSegments(reader); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *SoundEnvelope) readSegments(reader *Reader) error {
+	count, err := reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading segment count: %w", err)
+	}
+	e.Segments = make([]EnvelopeSegment, count)
+	for i := range e.Segments {
+		if e.Segments[i].Duration, err = reader.ReadUint16(); err != nil {
+			return fmt.Errorf("reading segment duration: %w", err)
+		}
+		if e.Segments[i].Phase, err = reader.ReadUint16(); err != nil {
+			return fmt.Errorf("reading segment phase: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f *SoundFilter) read(reader *Reader, envelope *SoundEnvelope) error {
+	pairs, err := reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading pair counts: %w", err)
+	}
+	f.Pairs = [2]uint8{pairs >> 4, pairs & 0xF}
+	if pairs == 0 {
+		return nil
+	}
+	if f.Pairs[0] > 4 || f.Pairs[1] > 4 {
+		return fmt.Errorf("too many pole pairs: %d and %d", f.Pairs[0], f.Pairs[1])
+	}
+
+	for i := range f.Unity {
+		if f.Unity[i], err = reader.ReadUint16(); err != nil {
+			return fmt.Errorf("reading unity: %w", err)
+		}
+	}
+	interpolated, err := reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading interpolation mask: %w", err)
+	}
+
+	for dir := range 2 {
+		for pair := range int(f.Pairs[dir]) {
+			if f.Phases[dir][0][pair], err = reader.ReadUint16(); err != nil {
+				return fmt.Errorf("reading phase: %w", err)
+			}
+			if f.Magnitudes[dir][0][pair], err = reader.ReadUint16(); err != nil {
+				return fmt.Errorf("reading magnitude: %w", err)
+			}
+		}
+	}
+	for dir := range 2 {
+		for pair := range int(f.Pairs[dir]) {
+			if interpolated&(1<<(dir*4)<<pair) == 0 {
+				f.Phases[dir][1][pair] = f.Phases[dir][0][pair]
+				f.Magnitudes[dir][1][pair] = f.Magnitudes[dir][0][pair]
+				continue
+			}
+			if f.Phases[dir][1][pair], err = reader.ReadUint16(); err != nil {
+				return fmt.Errorf("reading phase: %w", err)
+			}
+			if f.Magnitudes[dir][1][pair], err = reader.ReadUint16(); err != nil {
+				return fmt.Errorf("reading magnitude: %w", err)
+			}
+		}
+	}
+
+	if interpolated != 0 || f.Unity[1] != f.Unity[0] {
+		if err := envelope.readSegments(reader); err != nil {
+			return fmt.Errorf("reading filter envelope: %w", err)
+		}
+	}
+	return nil
+}
+
+// Mix synthesizes every instrument and mixes them into signed 8-bit samples
+// at SoundEffectSampleRate.
+func (s *SoundEffect) Mix() []int8 {
+	duration := 0
+	for _, instrument := range s.Instruments {
+		if instrument != nil {
+			duration = max(duration, int(instrument.Duration)+int(instrument.Offset))
+		}
+	}
+	if duration == 0 {
+		return nil
+	}
+
+	out := make([]int8, duration*SoundEffectSampleRate/1000)
+	for _, instrument := range s.Instruments {
+		if instrument == nil {
+			continue
+		}
+		steps := int(instrument.Duration) * SoundEffectSampleRate / 1000
+		offset := int(instrument.Offset) * SoundEffectSampleRate / 1000
+		samples := instrument.Synthesize(steps, int(instrument.Duration))
+		for i, sample := range samples {
+			v := sample>>8 + int32(out[i+offset])
+			if (v+128)&^0xFF != 0 {
+				v = v>>31 ^ 127
+			}
+			out[i+offset] = int8(v)
+		}
+	}
+	return out
+}
+
+// Loop returns the looped part of the mixed samples.
+func (s *SoundEffect) Loop() (start, end int) {
+	return int(s.LoopStart) * SoundEffectSampleRate / 1000, int(s.LoopEnd) * SoundEffectSampleRate / 1000
+}
+
+// Sample mixes the sound for use by a music patch.
+func (s *SoundEffect) Sample() *PCMSample {
+	start, end := s.Loop()
+	return &PCMSample{SampleRate: SoundEffectSampleRate, Samples: s.Mix(), LoopStart: start, LoopEnd: end}
+}
+
+func (s *SoundEffect) Audio() *Audio {
+	return NewAudio8(SoundEffectSampleRate, s.Mix())
+}
+
+// envelopeStepper walks an envelope one sample at a time.
+type envelopeStepper struct {
+	envelope   *SoundEnvelope
+	checkpoint int32
+	segment    int
+	step       int32
+	amplitude  int32
+	ticks      int32
+}
+
+func newEnvelopeStepper(e *SoundEnvelope) *envelopeStepper {
+	return &envelopeStepper{envelope: e}
+}
+
+// next returns the envelope value out of 65536 for the next of period
+// samples.
+func (s *envelopeStepper) next(period int) int32 {
+	segments := s.envelope.Segments
+	if len(segments) == 0 {
+		return 0
+	}
+	if s.ticks >= s.checkpoint {
+		s.amplitude = int32(segments[s.segment].Phase) << 15
+		s.segment = min(s.segment+1, len(segments)-1)
+		s.checkpoint = int32(float64(segments[s.segment].Duration) / 65536 * float64(period))
+		if s.checkpoint > s.ticks {
+			s.step = (int32(segments[s.segment].Phase)<<15 - s.amplitude) / (s.checkpoint - s.ticks)
+		}
+	}
+	s.amplitude += s.step
+	s.ticks++
+	return (s.amplitude - s.step) >> 15
+}
+
+func wave(amplitude, phase int32, form uint8) int32 {
+	switch form {
+	case WaveformSquare:
+		if phase&0x7FFF < 0x4000 {
+			return amplitude
+		}
+		return -amplitude
+	case WaveformSine:
+		return sineTable[phase&0x7FFF] * amplitude >> 14
+	case WaveformSaw:
+		return (phase&0x7FFF)*amplitude>>14 - amplitude
+	case WaveformNoise:
+		return noiseTable[phase/2607&0x7FFF] * amplitude
+	default:
+		return 0
+	}
+}
+
+// Synthesize renders the instrument as steps 16-bit samples played over
+// duration milliseconds.
+func (i *Instrument) Synthesize(steps, duration int) []int32 {
+	out := make([]int32, steps)
+	if duration < 10 {
+		return out
+	}
+	samplesPerMs := float64(steps) / float64(duration)
+
+	pitch := newEnvelopeStepper(i.Pitch)
+	volume := newEnvelopeStepper(i.Volume)
+
+	var pitchModStep, pitchModBase, pitchModPhase int32
+	var pitchMod, pitchModAmplitude *envelopeStepper
+	if i.PitchModifier != nil {
+		pitchMod = newEnvelopeStepper(i.PitchModifier)
+		pitchModAmplitude = newEnvelopeStepper(i.PitchModifierAmplitude)
+		pitchModStep = int32(float64(i.PitchModifier.End-i.PitchModifier.Start) * 32.768 / samplesPerMs)
+		pitchModBase = int32(float64(i.PitchModifier.Start) * 32.768 / samplesPerMs)
+	}
+
+	var volumeModStep, volumeModBase, volumeModPhase int32
+	var volumeMod, volumeModAmplitude *envelopeStepper
+	if i.VolumeMultiplier != nil {
+		volumeMod = newEnvelopeStepper(i.VolumeMultiplier)
+		volumeModAmplitude = newEnvelopeStepper(i.VolumeMultiplierAmplitude)
+		volumeModStep = int32(float64(i.VolumeMultiplier.End-i.VolumeMultiplier.Start) * 32.768 / samplesPerMs)
+		volumeModBase = int32(float64(i.VolumeMultiplier.Start) * 32.768 / samplesPerMs)
+	}
+
+	n := len(i.Oscillators)
+	phases := make([]int32, n)
+	delays := make([]int, n)
+	volumes := make([]int32, n)
+	pitchSteps := make([]int32, n)
+	pitchBases := make([]int32, n)
+	for j, osc := range i.Oscillators {
+		delays[j] = int(float64(osc.Delay) * samplesPerMs)
+		volumes[j] = int32(osc.Volume) << 14 / 100
+		pitchSteps[j] = int32(float64(i.Pitch.End-i.Pitch.Start) * 32.768 * math.Pow(1.0057929410678534, float64(osc.Pitch)) / samplesPerMs)
+		pitchBases[j] = int32(float64(i.Pitch.Start) * 32.768 / samplesPerMs)
+	}
+
+	for offset := range steps {
+		pitchChange := pitch.next(steps)
+		volumeChange := volume.next(steps)
+		if pitchMod != nil {
+			modulation := pitchMod.next(steps)
+			amplitude := pitchModAmplitude.next(steps)
+			pitchChange += wave(amplitude, pitchModPhase, i.PitchModifier.Form) >> 1
+			pitchModPhase += modulation*pitchModStep>>16 + pitchModBase
+		}
+		if volumeMod != nil {
+			modulation := volumeMod.next(steps)
+			amplitude := volumeModAmplitude.next(steps)
+			volumeChange = volumeChange * (wave(amplitude, volumeModPhase, i.VolumeMultiplier.Form)>>1 + 32768) >> 15
+			volumeModPhase += modulation*volumeModStep>>16 + volumeModBase
+		}
+
+		for j := range i.Oscillators {
+			position := offset + delays[j]
+			if position >= steps {
+				continue
+			}
+			out[position] += wave(volumeChange*volumes[j]>>15, phases[j], i.Pitch.Form)
+			phases[j] += pitchChange*pitchSteps[j]>>16 + pitchBases[j]
+		}
+	}
+
+	if i.Release != nil {
+		release := newEnvelopeStepper(i.Release)
+		attack := newEnvelopeStepper(i.Attack)
+		var counter int32
+		muted := true
+		for position := range steps {
+			on := release.next(steps)
+			off := attack.next(steps)
+			var threshold int32
+			if muted {
+				threshold = i.Release.Start + (i.Release.End-i.Release.Start)*on>>8
+			} else {
+				threshold = i.Release.Start + (i.Release.End-i.Release.Start)*off>>8
+			}
+			counter += 256
+			if counter >= threshold {
+				counter = 0
+				muted = !muted
+			}
+			if muted {
+				out[position] = 0
+			}
+		}
+	}
+
+	if i.DelayTime > 0 && i.DelayDecay > 0 {
+		delay := int(float64(i.DelayTime) * samplesPerMs)
+		for position := delay; position < steps; position++ {
+			out[position] += out[position-delay] * int32(i.DelayDecay) / 100
+		}
+	}
+
+	if i.Filter != nil && (i.Filter.Pairs[0] > 0 || i.Filter.Pairs[1] > 0) {
+		i.applyFilter(out)
+	}
+
+	for j, sample := range out {
+		out[j] = min(max(sample, -32768), 32767)
+	}
+	return out
+}
+
+// filterState holds the fixed point coefficients of a filter at a point in
+// its envelope.
+type filterState struct {
+	filter            *SoundFilter
+	coefficients      [2][8]int32
+	forwardMinimum    float32
+	forwardMultiplier int32
+}
+
+func (f *SoundFilter) gain(dir, pair int, t float32) float32 {
+	magnitude := float32(f.Magnitudes[dir][0][pair]) + t*float32(int32(f.Magnitudes[dir][1][pair])-int32(f.Magnitudes[dir][0][pair]))
+	magnitude *= 0.001525879
+	return 1 - float32(math.Pow(10, float64(-magnitude/20)))
+}
+
+func (f *SoundFilter) phase(dir, pair int, t float32) float32 {
+	phase := float32(f.Phases[dir][0][pair]) + t*float32(int32(f.Phases[dir][1][pair])-int32(f.Phases[dir][0][pair]))
+	phase *= 0.0001220703
+	frequency := 32.7032 * float32(math.Pow(2, float64(phase)))
+	return frequency * 3.141593 / 11025
+}
+
+// compute sets the coefficients of direction dir at position t of the
+// envelope and returns how many there are.
+func (s *filterState) compute(dir int, t float32) int {
+	f := s.filter
+	if dir == 0 {
+		unity := float32(f.Unity[0]) + float32(int32(f.Unity[1])-int32(f.Unity[0]))*t
+		unity *= 0.003051758
+		s.forwardMinimum = float32(math.Pow(0.1, float64(unity/20)))
+		s.forwardMultiplier = int32(s.forwardMinimum * 65536)
+	}
+
+	pairs := int(f.Pairs[dir])
+	if pairs == 0 {
+		return 0
+	}
+
+	var c [8]float32
+	g := f.gain(dir, 0, t)
+	c[0] = -2 * g * float32(math.Cos(float64(f.phase(dir, 0, t))))
+	c[1] = g * g
+	for k := 1; k < pairs; k++ {
+		g := f.gain(dir, k, t)
+		a := -2 * g * float32(math.Cos(float64(f.phase(dir, k, t))))
+		b := g * g
+		c[k*2+1] = c[k*2-1] * b
+		c[k*2] = c[k*2-1]*a + c[k*2-2]*b
+		for j := k*2 - 1; j >= 2; j-- {
+			c[j] += c[j-1]*a + c[j-2]*b
+		}
+		c[1] += c[0]*a + b
+		c[0] += a
+	}
+
+	if dir == 0 {
+		for k := range pairs * 2 {
+			c[k] *= s.forwardMinimum
+		}
+	}
+	for k := range pairs * 2 {
+		s.coefficients[dir][k] = int32(c[k] * 65536)
+	}
+	return pairs * 2
+}
+
+// applyFilter runs the filter over out in place, recomputing the
+// coefficients from the filter envelope every 128 samples.
+func (i *Instrument) applyFilter(out []int32) {
+	steps := len(out)
+	envelope := newEnvelopeStepper(i.FilterEnvelope)
+	state := &filterState{filter: i.Filter}
+
+	t := envelope.next(steps + 1)
+	m := state.compute(0, float32(t)/65536)
+	n := state.compute(1, float32(t)/65536)
+	if steps < m+n {
+		return
+	}
+
+	sample := func(pos, feedforward, feedback int, tail bool) int32 {
+		var y int32
+		if !tail {
+			y = int32(int64(out[pos+m]) * int64(state.forwardMultiplier) >> 16)
+		}
+		start := 0
+		if tail {
+			start = pos + m - steps
+		}
+		for k := start; k < feedforward; k++ {
+			y += int32(int64(out[pos+m-1-k]) * int64(state.coefficients[0][k]) >> 16)
+		}
+		for k := range feedback {
+			y -= int32(int64(out[pos-1-k]) * int64(state.coefficients[1][k]) >> 16)
+		}
+		return y
+	}
+
+	pos := 0
+	limit := min(n, steps-m)
+	for ; pos < limit; pos++ {
+		out[pos] = sample(pos, m, pos, false)
+		t = envelope.next(steps + 1)
+	}
+
+	const block = 128
+	limit = block
+	for {
+		limit = min(limit, steps-m)
+		for ; pos < limit; pos++ {
+			out[pos] = sample(pos, m, n, false)
+			t = envelope.next(steps + 1)
+		}
+		if pos >= steps-m {
+			break
+		}
+		m = state.compute(0, float32(t)/65536)
+		n = state.compute(1, float32(t)/65536)
+		limit += block
+	}
+
+	for ; pos < steps; pos++ {
+		out[pos] = sample(pos, m, n, true)
+		envelope.next(steps + 1)
+	}
+}
@@ -0,0 +1,158 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// envelopeBytes encodes an envelope with a form, start and end, and
+// segments given as duration, phase pairs.
+func envelopeBytes(form uint8, start, end int32, segments ...uint16) []byte {
+	b := []byte{form}
+	b = binary.BigEndian.AppendUint32(b, uint32(start))
+	b = binary.BigEndian.AppendUint32(b, uint32(end))
+	b = append(b, byte(len(segments)/2))
+	for _, v := range segments {
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+	return b
+}
+
+// soundEffectBytes encodes an effect by hand with one 100ms square wave
+// instrument at full volume. It has a sine pitch modifier, no volume
+// multiplier or gate, and a filter of one pole pair each way whose
+// feedforward pair moves over a two segment envelope. Without the filter
+// the filter byte is zero.
+func soundEffectBytes(filter bool) []byte {
+	full := []uint16{0, 0xFFFF, 0xFFFF, 0xFFFF}
+	b := slices.Concat(
+		envelopeBytes(WaveformSquare, 400, 400, full...), // pitch
+		envelopeBytes(WaveformOff, 0, 0, full...),        // volume
+		envelopeBytes(WaveformSine, 10, 20, full...),     // pitch modifier
+		envelopeBytes(WaveformOff, 0, 0, full...),        // pitch modifier amplitude
+		[]byte{0},          // no volume multiplier
+		[]byte{0},          // no gate
+		[]byte{100, 64, 0}, // oscillator at full volume, no pitch offset or delay
+		[]byte{0},          // end of oscillators
+		[]byte{0, 0},       // delay time and decay
+		[]byte{0, 100},     // duration
+		[]byte{0, 0},       // offset
+	)
+	if filter {
+		b = append(b,
+			0x11,       // one pole pair each way
+			0, 0, 0, 0, // unity
+			0x01,                   // only the first feedforward pair moves
+			0x30, 0x00, 0x40, 0x00, // feedforward phase and magnitude
+			0x20, 0x00, 0x20, 0x00, // feedback phase and magnitude
+			0x50, 0x00, 0x60, 0x00, // feedforward phase and magnitude at the end
+			2, 0, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF, // filter envelope
+		)
+	} else {
+		b = append(b, 0)
+	}
+	b = append(b, make([]byte, 9)...) // nine unused instruments
+	return append(b, 0, 10, 0, 90)    // loop
+}
+
+func TestSoundEffectRead(t *testing.T) {
+	effect := NewSoundEffect(1)
+	if err := effect.Read(soundEffectBytes(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	if effect.LoopStart != 10 || effect.LoopEnd != 90 {
+		t.Errorf("loop = %d-%d, want 10-90", effect.LoopStart, effect.LoopEnd)
+	}
+	for i, instrument := range effect.Instruments[1:] {
+		if instrument != nil {
+			t.Errorf("instrument %d = %+v, want none", i+1, instrument)
+		}
+	}
+	instrument := effect.Instruments[0]
+	if instrument == nil {
+		t.Fatal("instrument 0 is missing")
+	}
+
+	if instrument.Pitch.Form != WaveformSquare || instrument.Pitch.Start != 400 || len(instrument.Pitch.Segments) != 2 {
+		t.Errorf("pitch = %+v, want a square wave at 400", instrument.Pitch)
+	}
+	if instrument.PitchModifier == nil || instrument.PitchModifier.Form != WaveformSine || instrument.PitchModifierAmplitude == nil {
+		t.Errorf("pitch modifier = %+v and %+v, want a sine pair", instrument.PitchModifier, instrument.PitchModifierAmplitude)
+	}
+	if instrument.VolumeMultiplier != nil || instrument.VolumeMultiplierAmplitude != nil {
+		t.Error("want no volume multiplier")
+	}
+	if instrument.Release != nil || instrument.Attack != nil {
+		t.Error("want no gate")
+	}
+	if want := []Oscillator{{Volume: 100}}; !slices.Equal(instrument.Oscillators, want) {
+		t.Errorf("oscillators = %+v, want %+v", instrument.Oscillators, want)
+	}
+	if instrument.Duration != 100 || instrument.Offset != 0 {
+		t.Errorf("duration = %d at %d, want 100 at 0", instrument.Duration, instrument.Offset)
+	}
+
+	filter := &SoundFilter{Pairs: [2]uint8{1, 1}}
+	filter.Phases[0] = [2][4]uint16{{0x3000}, {0x5000}}
+	filter.Magnitudes[0] = [2][4]uint16{{0x4000}, {0x6000}}
+	filter.Phases[1] = [2][4]uint16{{0x2000}, {0x2000}}
+	filter.Magnitudes[1] = [2][4]uint16{{0x2000}, {0x2000}}
+	if !reflect.DeepEqual(instrument.Filter, filter) {
+		t.Errorf("filter = %+v, want %+v", instrument.Filter, filter)
+	}
+	if want := []EnvelopeSegment{{0, 0}, {0xFFFF, 0xFFFF}}; !slices.Equal(instrument.FilterEnvelope.Segments, want) {
+		t.Errorf("filter envelope = %v, want %v", instrument.FilterEnvelope.Segments, want)
+	}
+
+	data := soundEffectBytes(true)
+	if err := NewSoundEffect(1).Read(data[:len(data)-1]); err == nil {
+		t.Error("want an error for a truncated effect")
+	}
+}
+
+func TestSoundEffectMix(t *testing.T) {
+	plain := NewSoundEffect(1)
+	if err := plain.Read(soundEffectBytes(false)); err != nil {
+		t.Fatal(err)
+	}
+	samples := plain.Mix()
+	if len(samples) != 100*SoundEffectSampleRate/1000 {
+		t.Fatalf("mixed %d samples, want 100ms", len(samples))
+	}
+	// A full volume square wave is clipped to the 8-bit range, which the
+	// pitch modifier cannot change.
+	highs := 0
+	for i, sample := range samples {
+		if sample != 127 && sample != -128 {
+			t.Fatalf("sample %d = %d, want a full volume square wave", i, sample)
+		}
+		if sample == 127 {
+			highs++
+		}
+	}
+	if highs == 0 || highs == len(samples) {
+		t.Errorf("%d of %d samples are high, want a wave", highs, len(samples))
+	}
+
+	filtered := NewSoundEffect(1)
+	if err := filtered.Read(soundEffectBytes(true)); err != nil {
+		t.Fatal(err)
+	}
+	got := filtered.Mix()
+	if len(got) != len(samples) {
+		t.Fatalf("mixed %d filtered samples, want %d", len(got), len(samples))
+	}
+	if slices.Equal(got, samples) {
+		t.Error("filter left the samples unchanged")
+	}
+
+	if start, end := filtered.Loop(); start != 220 || end != 1984 {
+		t.Errorf("loop = %d-%d, want 220-1984", start, end)
+	}
+	if samples := NewSoundEffect(2).Mix(); samples != nil {
+		t.Errorf("empty effect mixed %d samples, want none", len(samples))
+	}
+}
@@ -0,0 +1,133 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// SpotAnim is a graphic such as a spell projectile or the splash of a hit,
+// played as a model running an animation.
+type SpotAnim struct {
+	ID      uint16 `json:"id"`
+	ModelID uint16 `json:"model_id"`
+	// AnimationID is the sequence the model plays, math.MaxUint16 when it is
+	// not animated.
+	AnimationID uint16 `json:"animation_id"`
+	// ScaleWidth and ScaleHeight scale the model horizontally and vertically,
+	// with 128 the original size.
+	ScaleWidth  uint16 `json:"scale_width"`
+	ScaleHeight uint16 `json:"scale_height"`
+	// Rotation turns the model in multiples of 90 degrees.
+	Rotation      uint16         `json:"rotation"`
+	Ambient       uint8          `json:"ambient"`
+	Contrast      uint8          `json:"contrast"`
+	RecolorFrom   []uint16       `json:"recolor_from"`
+	RecolorTo     []uint16       `json:"recolor_to"`
+	RetextureFrom []uint16       `json:"retexture_from"`
+	RetextureTo   []uint16       `json:"retexture_to"`
+	Unknown       *UnknownOpcode `json:"unknown,omitempty"`
+}
+
+func NewSpotAnim(id uint16) *SpotAnim {
+	return &SpotAnim{
+		ID:          id,
+		AnimationID: math.MaxUint16,
+		ScaleWidth:  128,
+		ScaleHeight: 128,
+	}
+}
+
+func (s *SpotAnim) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
+	reader := NewReader(data)
+	for {
+		opcode, err := reader.ReadUint8()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading opcode: %w", err)
+		}
+		if opcode == 0 {
+			break
+		}
+		switch opcode {
+		case 1:
+			s.ModelID, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading model id: %w", err)
+			}
+		case 2:
+			s.AnimationID, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading animation id: %w", err)
+			}
+		case 4:
+			s.ScaleWidth, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading scale width: %w", err)
+			}
+		case 5:
+			s.ScaleHeight, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading scale height: %w", err)
+			}
+		case 6:
+			s.Rotation, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading rotation: %w", err)
+			}
+		case 7:
+			s.Ambient, err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading ambient: %w", err)
+			}
+		case 8:
+			s.Contrast, err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading contrast: %w", err)
+			}
+		case 40:
+			length, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading recolor length: %w", err)
+			}
+			s.RecolorFrom = make([]uint16, length)
+			s.RecolorTo = make([]uint16, length)
+			for i := 0; i < int(length); i++ {
+				s.RecolorFrom[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading recolor from: %w", err)
+				}
+				s.RecolorTo[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading recolor to: %w", err)
+				}
+			}
+		case 41:
+			length, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading retexture length: %w", err)
+			}
+			s.RetextureFrom = make([]uint16, length)
+			s.RetextureTo = make([]uint16, length)
+			for i := 0; i < int(length); i++ {
+				s.RetextureFrom[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading retexture from: %w", err)
+				}
+				s.RetextureTo[i], err = reader.ReadUint16()
+				if err != nil {
+					return fmt.Errorf("reading retexture to: %w", err)
+				}
+			}
+		default:
+			s.Unknown, err = options.unknownOpcode("spotanim", s.ID, opcode, reader)
+			return err
+		}
+	}
+	return nil
+}
@@ -103,13 +103,19 @@ func (s *Sprite) Read(data []byte) error {
 		}
 	}
 
+	// Frame pixels are stored back to back from the start of the data.
+	offset := 0
 	s.Frames = make([]*Frame, frameLength)
 	for i := range s.Frames {
-		frame, err := NewFrame(uint16(i), xOffsets[i], yOffsets[i], maxWidths[i], maxHeights[i], data)
+		if offset > len(data) {
+			return fmt.Errorf("frame %d starts past the end of the data", i)
+		}
+		frame, err := NewFrame(uint16(i), xOffsets[i], yOffsets[i], maxWidths[i], maxHeights[i], data[offset:])
 		if err != nil {
 			return fmt.Errorf("creating frame: %w", err)
 		}
 		s.Frames[i] = frame
+		offset += frame.encodedSize()
 	}
 	return nil
 }
@@ -149,6 +155,29 @@ func (s *Sprite) Image() *image.RGBA {
 	return img
 }
 
+// FrameImage returns frame i on its own, without the sprite's offsets.
+func (s *Sprite) FrameImage(i int) *image.RGBA {
+	frame := s.Frames[i]
+	img := image.NewRGBA(image.Rect(0, 0, int(frame.MaxWidth), int(frame.MaxHeight)))
+	for index, paletteIndex := range frame.Pixels {
+		if paletteIndex == 0 || int(paletteIndex) > len(s.Palette) {
+			continue
+		}
+		paletteColor := s.Palette[paletteIndex-1]
+		c := color.NRGBA{
+			R: uint8((paletteColor >> 16) & 0xFF),
+			G: uint8((paletteColor >> 8) & 0xFF),
+			B: uint8(paletteColor & 0xFF),
+			A: 255,
+		}
+		if frame.Alpha != nil {
+			c.A = frame.Alpha[index]
+		}
+		img.Set(index%int(frame.MaxWidth), index/int(frame.MaxWidth), c)
+	}
+	return img
+}
+
 type Frame struct {
 	ID        uint16 `json:"id"`
 	OffsetX   uint16 `json:"offset_x"`
@@ -228,3 +257,9 @@ func (f *Frame) Read(data []byte) error {
 	}
 	return nil
 }
+
+// encodedSize returns the number of bytes the frame occupies in a sprite
+// group: a flags byte, the palette indexes and the optional alpha channel.
+func (f *Frame) encodedSize() int {
+	return 1 + len(f.Pixels) + len(f.Alpha)
+}
@@ -0,0 +1,38 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+func TestSpriteReadFrames(t *testing.T) {
+	data := slices.Concat(
+		[]byte{0, 1, 2},                   // frame 0: 1x2, row major
+		[]byte{FlagAlpha, 2, 1, 255, 128}, // frame 1: 2x1 with alpha
+		[]byte{0xFF, 0, 0, 0, 0xFF, 0},    // palette
+		[]byte{0, 4, 0, 4, 2},             // sprite size and palette length
+		[]byte{0, 0, 0, 1, 0, 2, 0, 3},    // x and y offsets
+		[]byte{0, 1, 0, 2, 0, 2, 0, 1},    // frame widths and heights
+	)
+	data = binary.BigEndian.AppendUint16(data, 2)
+
+	sprite := NewSprite(1)
+	if err := sprite.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(sprite.Frames) != 2 {
+		t.Fatalf("frames = %d, want 2", len(sprite.Frames))
+	}
+	if got := sprite.Frames[0]; !slices.Equal(got.Pixels, []byte{1, 2}) || got.Alpha != nil {
+		t.Errorf("frame 0 = %v alpha %v", got.Pixels, got.Alpha)
+	}
+	// Frame 1 starts after frame 0's flags and pixels rather than at the
+	// start of the data.
+	if got := sprite.Frames[1]; !slices.Equal(got.Pixels, []byte{2, 1}) || !slices.Equal(got.Alpha, []byte{255, 128}) {
+		t.Errorf("frame 1 = %v alpha %v, want its own pixels", got.Pixels, got.Alpha)
+	}
+	if got := sprite.Frames[1]; got.OffsetX != 1 || got.OffsetY != 3 {
+		t.Errorf("frame 1 offset = %d,%d, want 1,3", got.OffsetX, got.OffsetY)
+	}
+}
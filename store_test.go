@@ -0,0 +1,72 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// memStore is a Store holding uncompressed groups by archive. The reference
+// tables of archive 255 are built from the groups of each archive, every group
+// holding a single file.
+type memStore map[uint8]map[uint32][]byte
+
+func (s memStore) ArchiveList() ([]uint8, error) {
+	return slices.Sorted(maps.Keys(s)), nil
+}
+
+func (s memStore) ArchiveExists(archiveID uint8) bool {
+	_, ok := s[archiveID]
+	return ok
+}
+
+func (s memStore) GroupList(archiveID uint8) ([]uint32, error) {
+	return slices.Sorted(maps.Keys(s[archiveID])), nil
+}
+
+func (s memStore) GroupExists(archiveID uint8, groupID uint32) bool {
+	if archiveID == 255 {
+		return groupID < 255 && s.ArchiveExists(uint8(groupID))
+	}
+	_, ok := s[archiveID][groupID]
+	return ok
+}
+
+func (s memStore) Read(archiveID uint8, groupID uint32) ([]byte, error) {
+	if archiveID == 255 {
+		groups, ok := s[uint8(groupID)]
+		if !ok {
+			return nil, fmt.Errorf("group %d in archive %d not found", groupID, archiveID)
+		}
+		return container(referenceTable(slices.Sorted(maps.Keys(groups)))), nil
+	}
+	data, ok := s[archiveID][groupID]
+	if !ok {
+		return nil, fmt.Errorf("group %d in archive %d not found", groupID, archiveID)
+	}
+	return container(data), nil
+}
+
+// container wraps data in an uncompressed container.
+func container(data []byte) []byte {
+	out := binary.BigEndian.AppendUint32([]byte{CompressionNone}, uint32(len(data)))
+	return append(out, data...)
+}
+
+// referenceTable builds a protocol 6 reference table listing groups, each
+// holding a single file.
+func referenceTable(groups []uint32) []byte {
+	buf := []byte{6, 0, 0, 0, 1, 0}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(groups)))
+	var prev uint32
+	for _, group := range groups {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(group-prev))
+		prev = group
+	}
+	buf = append(buf, make([]byte, 8*len(groups))...) // checksums and versions
+	for range groups {
+		buf = binary.BigEndian.AppendUint16(buf, 1)
+	}
+	return append(buf, make([]byte, 2*len(groups))...) // file ID deltas
+}
@@ -7,15 +7,18 @@ import (
 )
 
 type Struct struct {
-	ID     uint16
-	Params map[uint32]any
+	ID      uint16
+	Params  map[uint32]any
+	Unknown *UnknownOpcode
 }
 
 func NewStruct(id uint16) *Struct {
 	return &Struct{ID: id, Params: make(map[uint32]any)}
 }
 
-func (s *Struct) Read(data []byte) error {
+func (s *Struct) Read(data []byte, opts ...DecodeOption) error {
+	options := newDecodeOptions(opts)
+
 	reader := NewReader(data)
 	for {
 		opcode, err := reader.ReadUint8()
@@ -63,7 +66,8 @@ func (s *Struct) Read(data []byte) error {
 				s.Params[key] = value
 			}
 		default:
-			return fmt.Errorf("unsupported opcode: %d", opcode)
+			s.Unknown, err = options.unknownOpcode("struct", s.ID, opcode, reader)
+			return err
 		}
 	}
 	return nil
@@ -0,0 +1,682 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// DefaultSynthSampleRate is the output rate of the client's audio.
+const DefaultSynthSampleRate = 22050
+
+// synthTail bounds how long notes may ring after the last event of a track,
+// in ticks of 10ms.
+const synthTail = 1000
+
+type SynthOption func(*Synthesizer)
+
+// WithSynthSampleRate sets the output sample rate, DefaultSynthSampleRate by
+// default. NewSynthesizer rejects rates that are not positive.
+func WithSynthSampleRate(rate int) SynthOption {
+	return func(s *Synthesizer) {
+		s.sampleRate = rate
+	}
+}
+
+// Synthesizer renders music tracks offline with the patches of archive 15 and
+// the samples they reference, following the client's MIDI player. Envelopes,
+// volume and pitch are updated every 10ms, as in the client.
+type Synthesizer struct {
+	cache      *Cache
+	sampleRate int
+
+	indices map[uint8]*Index
+	setup   *VorbisSetup
+	patches map[uint32]*MusicPatch
+	samples map[uint32]*PCMSample
+}
+
+func NewSynthesizer(cache *Cache, opts ...SynthOption) (*Synthesizer, error) {
+	s := &Synthesizer{
+		cache:      cache,
+		sampleRate: DefaultSynthSampleRate,
+		indices:    make(map[uint8]*Index),
+		patches:    make(map[uint32]*MusicPatch),
+		samples:    make(map[uint32]*PCMSample),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", s.sampleRate)
+	}
+	return s, nil
+}
+
+// hasGroup reports whether the group exists, so that patches and samples
+// missing from a cache are skipped rather than failing the render.
+func (s *Synthesizer) hasGroup(archiveID uint8, id uint32) (bool, error) {
+	index, ok := s.indices[archiveID]
+	if !ok {
+		var err error
+		if index, err = s.cache.Index(archiveID); err != nil {
+			return false, fmt.Errorf("getting index %d: %w", archiveID, err)
+		}
+		s.indices[archiveID] = index
+	}
+	_, err := index.Group(id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Synthesizer) patch(id uint32) (*MusicPatch, error) {
+	if patch, ok := s.patches[id]; ok {
+		return patch, nil
+	}
+	var patch *MusicPatch
+	ok, err := s.hasGroup(15, id)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if patch, err = s.cache.MusicPatch(id); err != nil {
+			return nil, err
+		}
+	}
+	s.patches[id] = patch
+	return patch, nil
+}
+
+func (s *Synthesizer) sample(key PatchKey) (*PCMSample, error) {
+	if sample, ok := s.samples[key.Sample]; ok {
+		return sample, nil
+	}
+	archiveID, group, ok := key.SampleGroup()
+	if !ok {
+		return nil, nil
+	}
+
+	var sample *PCMSample
+	ok, err := s.hasGroup(archiveID, group)
+	if err != nil {
+		return nil, err
+	}
+	if ok && archiveID == 4 {
+		effect, err := s.cache.SoundEffect(uint16(group))
+		if err != nil {
+			return nil, err
+		}
+		sample = effect.Sample()
+	} else if ok {
+		if s.setup == nil {
+			if s.setup, err = s.cache.VorbisSetup(); err != nil {
+				return nil, err
+			}
+		}
+		vorbis, err := s.cache.MusicSample(group)
+		if err != nil {
+			return nil, err
+		}
+		if sample, err = vorbis.Decode(s.setup); err != nil {
+			return nil, fmt.Errorf("decoding sample %d: %w", group, err)
+		}
+	}
+	s.samples[key.Sample] = sample
+	return sample, nil
+}
+
+// midiEvent is a channel or tempo event of a MIDI file.
+type midiEvent struct {
+	tick   uint64
+	status byte
+	data1  int
+	data2  int
+	tempo  int
+}
+
+// parseMIDI reads the events of a standard MIDI file, merged across tracks in
+// time order.
+func parseMIDI(data []byte) ([]midiEvent, uint16, error) {
+	if len(data) < 14 || string(data[:4]) != "MThd" {
+		return nil, 0, fmt.Errorf("missing MIDI header")
+	}
+	headerSize := int(binary.BigEndian.Uint32(data[4:]))
+	tracks := int(binary.BigEndian.Uint16(data[10:]))
+	division := binary.BigEndian.Uint16(data[12:])
+	if division == 0 || division&0x8000 != 0 {
+		return nil, 0, fmt.Errorf("unsupported division %#x", division)
+	}
+
+	var events []midiEvent
+	reader := NewReader(data[min(8+headerSize, len(data)):])
+	for track := range tracks {
+		chunk, err := reader.ReadBytes(4)
+		if err != nil {
+			return nil, 0, fmt.Errorf("reading track %d: %w", track, err)
+		}
+		length, err := reader.ReadUint32()
+		if err != nil {
+			return nil, 0, fmt.Errorf("reading track %d length: %w", track, err)
+		}
+		body, err := reader.ReadBytes(int(length))
+		if err != nil {
+			return nil, 0, fmt.Errorf("reading track %d: %w", track, err)
+		}
+		if string(chunk) != "MTrk" {
+			continue
+		}
+		if events, err = parseMIDITrack(body, events); err != nil {
+			return nil, 0, fmt.Errorf("reading track %d: %w", track, err)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].tick < events[j].tick })
+	return events, division, nil
+}
+
+func parseMIDITrack(body []byte, events []midiEvent) ([]midiEvent, error) {
+	reader := NewReader(body)
+	var tick uint64
+	var status byte
+	for reader.Len() > 0 {
+		delta, err := readMusicVarInt(reader)
+		if err != nil {
+			return nil, fmt.Errorf("reading delta time: %w", err)
+		}
+		tick += uint64(delta)
+
+		b, err := reader.ReadUint8()
+		if err != nil {
+			return nil, fmt.Errorf("reading status: %w", err)
+		}
+		if b&0x80 != 0 {
+			status = b
+		} else {
+			reader.Seek(-1, io.SeekCurrent)
+		}
+
+		switch {
+		case status == 0xFF:
+			kind, err := reader.ReadUint8()
+			if err != nil {
+				return nil, fmt.Errorf("reading meta event: %w", err)
+			}
+			size, err := readMusicVarInt(reader)
+			if err != nil {
+				return nil, fmt.Errorf("reading meta event size: %w", err)
+			}
+			value, err := reader.ReadBytes(int(size))
+			if err != nil {
+				return nil, fmt.Errorf("reading meta event: %w", err)
+			}
+			if kind == 0x2F {
+				return events, nil
+			}
+			if kind == 0x51 && size == 3 {
+				tempo := int(value[0])<<16 | int(value[1])<<8 | int(value[2])
+				events = append(events, midiEvent{tick: tick, status: status, tempo: tempo})
+			}
+		case status == 0xF0 || status == 0xF7:
+			size, err := readMusicVarInt(reader)
+			if err != nil {
+				return nil, fmt.Errorf("reading sysex size: %w", err)
+			}
+			if _, err := reader.ReadBytes(int(size)); err != nil {
+				return nil, fmt.Errorf("reading sysex: %w", err)
+			}
+		case status >= 0x80:
+			size := 2
+			if kind := status & 0xF0; kind == 0xC0 || kind == 0xD0 {
+				size = 1
+			}
+			value, err := reader.ReadBytes(size)
+			if err != nil {
+				return nil, fmt.Errorf("reading event: %w", err)
+			}
+			event := midiEvent{tick: tick, status: status, data1: int(value[0] & 0x7F)}
+			if size == 2 {
+				event.data2 = int(value[1] & 0x7F)
+			}
+			events = append(events, event)
+		default:
+			return nil, fmt.Errorf("data byte %#x without status", b)
+		}
+	}
+	return events, nil
+}
+
+// synthChannel is the state of a MIDI channel. Continuous controllers are
+// 14-bit, combining their MSB and LSB.
+type synthChannel struct {
+	bank       uint32
+	patch      uint32
+	volume     int
+	expression int
+	pan        int
+	modulation int
+	bend       int
+	bendRange  int
+	sustain    bool
+	rpn        int
+	notes      [128]*synthVoice
+	exclusive  [128]*synthVoice
+}
+
+func (c *synthChannel) reset() {
+	c.volume = 100 << 7
+	c.expression = 0x3FFF
+	c.pan = 0x2000
+	c.modulation = 0
+	c.bend = 0x2000
+	c.bendRange = 2 << 7
+	c.sustain = false
+	c.rpn = -1
+}
+
+// synthVoice is a playing note.
+type synthVoice struct {
+	channel  *synthChannel
+	key      int
+	class    int
+	envelope *PatchEnvelope
+	sample   *PCMSample
+	looped   bool
+
+	volume int
+	pan    int
+	pitch  int
+
+	position float64
+	step     float64
+
+	ticks        int
+	vibrato      int
+	decay        int
+	volumeTime   int
+	volumePoint  int
+	releaseTime  int
+	releasePoint int
+	finished     bool
+
+	// Gains ramp from the previous tick's values to the current ones.
+	left, right             float64
+	targetLeft, targetRight float64
+}
+
+// Render synthesizes a track to 16-bit stereo audio.
+func (s *Synthesizer) Render(track *MusicTrack) (*Audio, error) {
+	events, division, err := parseMIDI(track.MIDI)
+	if err != nil {
+		return nil, fmt.Errorf("parsing track %d: %w", track.ID, err)
+	}
+
+	var channels [16]synthChannel
+	for i := range channels {
+		channels[i].reset()
+	}
+	channels[9].bank = 128
+
+	r := &synthRender{synth: s, channels: &channels}
+	tickLength := max(s.sampleRate/100, 1)
+	tempo := 500000
+	var lastTick uint64
+	var eventTime float64
+
+	next := 0
+	tail := 0
+	for now := 0; ; now += tickLength {
+		end := now + tickLength
+		// Events are applied at their sample, splitting the tick.
+		for next < len(events) {
+			event := events[next]
+			at := eventTime + float64(event.tick-lastTick)*float64(tempo)*float64(s.sampleRate)/(1e6*float64(division))
+			if int(at) >= end {
+				break
+			}
+			r.mix(int(at))
+			if err := r.apply(event); err != nil {
+				return nil, err
+			}
+			if event.status == 0xFF {
+				tempo = event.tempo
+			}
+			eventTime, lastTick = at, event.tick
+			next++
+		}
+		r.mix(end)
+		r.update(end - now)
+
+		if next == len(events) {
+			tail++
+			if len(r.voices) == 0 || tail > synthTail {
+				break
+			}
+		}
+	}
+
+	out := make([]int16, len(r.out))
+	for i, sample := range r.out {
+		out[i] = int16(min(max(sample, math.MinInt16), math.MaxInt16))
+	}
+	return NewAudio16(s.sampleRate, 2, out), nil
+}
+
+type synthRender struct {
+	synth    *Synthesizer
+	channels *[16]synthChannel
+	voices   []*synthVoice
+	// out is interleaved stereo mixed up to len(out)/2 samples.
+	out []int32
+	// tickStart is the sample of the last tick, for ramping gains.
+	tickStart int
+	tickEnd   int
+}
+
+func (r *synthRender) apply(event midiEvent) error {
+	if event.status == 0xFF {
+		return nil
+	}
+	channel := &r.channels[event.status&0xF]
+	switch event.status & 0xF0 {
+	case 0x80:
+		r.noteOff(channel, event.data1)
+	case 0x90:
+		if event.data2 == 0 {
+			r.noteOff(channel, event.data1)
+			return nil
+		}
+		return r.noteOn(channel, event.data1, event.data2)
+	case 0xB0:
+		r.controller(channel, event.data1, event.data2)
+	case 0xC0:
+		channel.patch = channel.bank + uint32(event.data1)
+	case 0xE0:
+		channel.bend = event.data2<<7 | event.data1
+	}
+	return nil
+}
+
+func (r *synthRender) controller(c *synthChannel, controller, value int) {
+	setMSB := func(v *int) { *v = value<<7 | *v&0x7F }
+	setLSB := func(v *int) { *v = *v&^0x7F | value }
+	switch controller {
+	case 0:
+		c.bank = uint32(value)<<14 | c.bank&^0x1FC000
+	case 32:
+		c.bank = uint32(value)<<7 | c.bank&^0x3F80
+	case 1:
+		setMSB(&c.modulation)
+	case 33:
+		setLSB(&c.modulation)
+	case 7:
+		setMSB(&c.volume)
+	case 39:
+		setLSB(&c.volume)
+	case 10:
+		setMSB(&c.pan)
+	case 42:
+		setLSB(&c.pan)
+	case 11:
+		setMSB(&c.expression)
+	case 43:
+		setLSB(&c.expression)
+	case 64:
+		c.sustain = value >= 64
+	case 101:
+		c.rpn = value<<7 | max(c.rpn, 0)&0x7F
+	case 100:
+		c.rpn = max(c.rpn, 0)&^0x7F | value
+	case 98, 99:
+		c.rpn = -1
+	case 6:
+		if c.rpn == 0 {
+			setMSB(&c.bendRange)
+		}
+	case 38:
+		if c.rpn == 0 {
+			setLSB(&c.bendRange)
+		}
+	case 120:
+		for _, v := range r.voices {
+			if v.channel == c {
+				v.finished = true
+			}
+		}
+	case 121:
+		bank, patch := c.bank, c.patch
+		c.reset()
+		c.bank, c.patch = bank, patch
+	case 123:
+		for key := range c.notes {
+			r.noteOff(c, key)
+		}
+	}
+}
+
+func (r *synthRender) noteOn(c *synthChannel, key, velocity int) error {
+	r.noteOff(c, key)
+
+	patch, err := r.synth.patch(c.patch)
+	if err != nil || patch == nil {
+		return err
+	}
+	info := patch.Keys[key]
+	sample, err := r.synth.sample(info)
+	if err != nil || sample == nil || len(sample.Samples) == 0 {
+		return err
+	}
+	if info.Envelope >= len(patch.Envelopes) {
+		return fmt.Errorf("patch %d envelope %d out of range", patch.ID, info.Envelope)
+	}
+
+	v := &synthVoice{
+		channel:     c,
+		key:         key,
+		class:       int(info.ExclusiveClass),
+		envelope:    &patch.Envelopes[info.Envelope],
+		sample:      sample,
+		looped:      info.Looped && sample.LoopStart < sample.LoopEnd && sample.LoopEnd <= len(sample.Samples),
+		volume:      (velocity*velocity*int(info.Volume)*patch.Volume + 1024) >> 11,
+		pan:         int(info.Pan),
+		pitch:       key<<8 - info.Root,
+		releaseTime: -1,
+	}
+	if v.class >= 0 {
+		if other := c.exclusive[v.class]; other != nil && other.releaseTime < 0 {
+			if c.notes[other.key] == other {
+				c.notes[other.key] = nil
+			}
+			other.releaseTime = 0
+		}
+		c.exclusive[v.class] = v
+	}
+	c.notes[key] = v
+
+	r.refresh(v)
+	v.left, v.right = v.targetLeft, v.targetRight
+	r.voices = append(r.voices, v)
+	return nil
+}
+
+func (r *synthRender) noteOff(c *synthChannel, key int) {
+	v := c.notes[key]
+	if v == nil {
+		return
+	}
+	c.notes[key] = nil
+	v.releaseTime = 0
+	if v.envelope.Release == nil {
+		v.finished = true
+	}
+}
+
+// mix renders the voices up to sample end.
+func (r *synthRender) mix(end int) {
+	start := len(r.out) / 2
+	if end <= start {
+		return
+	}
+	r.out = append(r.out, make([]int32, (end-start)*2)...)
+	span := float64(max(r.tickEnd-r.tickStart, 1))
+
+	for _, v := range r.voices {
+		samples := v.sample.Samples
+		for i := start; i < end; i++ {
+			if v.position >= float64(len(samples)) {
+				break
+			}
+			index := int(v.position)
+			frac := v.position - float64(index)
+			value := float64(samples[index])
+			if index+1 < len(samples) {
+				value += (float64(samples[index+1]) - value) * frac
+			}
+			value *= 256
+
+			ramp := min(float64(i-r.tickStart)/span, 1)
+			r.out[i*2] += int32(value * (v.left + (v.targetLeft-v.left)*ramp))
+			r.out[i*2+1] += int32(value * (v.right + (v.targetRight-v.right)*ramp))
+
+			v.position += v.step
+			if v.looped && v.position >= float64(v.sample.LoopEnd) {
+				v.position -= float64(v.sample.LoopEnd - v.sample.LoopStart)
+			}
+		}
+	}
+}
+
+// update advances the voices by a tick of length samples.
+func (r *synthRender) update(length int) {
+	r.tickStart = len(r.out) / 2
+	r.tickEnd = r.tickStart + length
+
+	voices := r.voices[:0]
+	for _, v := range r.voices {
+		v.left, v.right = v.targetLeft, v.targetRight
+		if v.finished || (!v.looped && v.position >= float64(len(v.sample.Samples))) {
+			// Finished voices fade out over a tick.
+			if v.left != 0 || v.right != 0 {
+				v.targetLeft, v.targetRight = 0, 0
+				voices = append(voices, v)
+			}
+			v.finished = true
+			r.release(v)
+			continue
+		}
+		r.advance(v)
+		r.refresh(v)
+		voices = append(voices, v)
+	}
+	clear(r.voices[len(voices):])
+	r.voices = voices
+}
+
+func (r *synthRender) release(v *synthVoice) {
+	c := v.channel
+	if c.notes[v.key] == v {
+		c.notes[v.key] = nil
+	}
+	if v.class >= 0 && c.exclusive[v.class] == v {
+		c.exclusive[v.class] = nil
+	}
+}
+
+// advance moves the envelopes and vibrato of a voice on by a tick.
+func (r *synthRender) advance(v *synthVoice) {
+	e := v.envelope
+	keyOffset := float64((v.key-60)<<8) * 5.086263020833333e-6
+	step := func(scale int) int {
+		if scale > 0 {
+			return int(128*math.Pow(2, keyOffset*float64(scale)) + 0.5)
+		}
+		return 128
+	}
+
+	v.ticks++
+	v.vibrato += e.VibratoRate
+	if e.Decay > 0 {
+		v.decay += step(e.DecayKeyScale)
+	}
+	if e.Volume != nil {
+		v.volumeTime += step(e.VolumeKeyScale)
+		for v.volumePoint < len(e.Volume)-1 && v.volumeTime > e.Volume[v.volumePoint+1].Time<<8 {
+			v.volumePoint++
+		}
+		if v.volumePoint == len(e.Volume)-1 && e.Volume[v.volumePoint].Level == 0 {
+			v.finished = true
+		}
+	}
+
+	// Released notes hold while the sustain pedal is down, and the last note
+	// of an exclusive class rings until another note of the class cuts it.
+	c := v.channel
+	holding := c.sustain || (v.class >= 0 && c.exclusive[v.class] == v)
+	if v.releaseTime >= 0 && e.Release != nil && !holding {
+		v.releaseTime += step(e.ReleaseKeyScale)
+		for v.releasePoint < len(e.Release)-1 && v.releaseTime > e.Release[v.releasePoint+1].Time<<8 {
+			v.releasePoint++
+		}
+		if v.releasePoint == len(e.Release)-1 {
+			v.finished = true
+		}
+	}
+}
+
+// refresh recomputes the gains and playback rate of a voice.
+func (r *synthRender) refresh(v *synthVoice) {
+	c := v.channel
+	e := v.envelope
+
+	volume := (c.volume*c.expression + 4096) >> 13
+	volume = (volume*volume + 16384) >> 15
+	volume = (volume*v.volume + 16384) >> 15
+	if e.Decay > 0 {
+		volume = int(float64(volume)*math.Pow(0.5, float64(e.Decay)*float64(v.decay)*1.953125e-5) + 0.5)
+	}
+	if e.Volume != nil {
+		volume = (volume*envelopeLevel(e.Volume, v.volumePoint, v.volumeTime) + 32) >> 6
+	}
+	if v.releaseTime > 0 && e.Release != nil {
+		volume = (volume*envelopeLevel(e.Release, v.releasePoint, v.releaseTime) + 32) >> 6
+	}
+
+	pan := (c.pan*v.pan + 32) >> 6
+	if c.pan >= 0x2000 {
+		pan = 0x4000 - (((128-v.pan)*(0x4000-c.pan) + 32) >> 6)
+	}
+	pan = min(max(pan, 0), 0x4000)
+
+	gain := float64(volume) / 65536
+	v.targetLeft = gain * float64(0x4000-pan) / 0x4000
+	v.targetRight = gain * float64(pan) / 0x4000
+
+	pitch := v.pitch + (c.bend-0x2000)*c.bendRange>>12
+	if e.VibratoRate > 0 && (e.VibratoDepth > 0 || c.modulation > 0) {
+		depth := e.VibratoDepth << 2
+		if delay := e.VibratoDelay << 1; v.ticks < delay {
+			depth = depth * v.ticks / delay
+		}
+		depth += c.modulation >> 7
+		pitch += int(float64(depth) * math.Sin(float64(v.vibrato&511)*2*math.Pi/512))
+	}
+	v.step = float64(v.sample.SampleRate) * math.Pow(2, float64(pitch)/3072) / float64(r.synth.sampleRate)
+}
+
+// envelopeLevel interpolates the level of an envelope at time, which lies
+// after point.
+func envelopeLevel(points []EnvelopePoint, point, time int) int {
+	level := points[point].Level
+	if point < len(points)-1 {
+		start := points[point].Time << 8
+		end := points[point+1].Time << 8
+		if end > start {
+			level += (points[point+1].Level - level) * (time - start) / (end - start)
+		}
+	}
+	return level
+}
@@ -0,0 +1,53 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// oneNoteMIDI is a MIDI file playing key 60 on program 0 for half a second.
+func oneNoteMIDI() []byte {
+	track := slices.Concat(
+		[]byte{0, 0xC0, 0},                    // program 0
+		[]byte{0, 0xFF, 0x51, 3, 7, 0xA1, 32}, // 120 bpm
+		[]byte{0, 0x90, 60, 100},              // note on
+		[]byte{96, 0x80, 60, 0},               // note off a beat later
+		[]byte{0, 0xFF, 0x2F, 0},
+	)
+	header := slices.Concat([]byte("MThd"), []byte{0, 0, 0, 6, 0, 0, 0, 1, 0, 96}, []byte("MTrk"))
+	return append(binary.BigEndian.AppendUint32(header, uint32(len(track))), track...)
+}
+
+func TestSynthesizerRender(t *testing.T) {
+	store := memStore{
+		14: {0: vorbisSetupBytes(), 1: vorbisSampleBytes()},
+		15: {0: patchBytes()},
+	}
+	synth, err := NewSynthesizer(New(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio, err := synth.Render(&MusicTrack{ID: 1, MIDI: oneNoteMIDI()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if audio.SampleRate != DefaultSynthSampleRate || audio.Channels != 2 || audio.BitsPerSample != 16 {
+		t.Errorf("format = %d Hz, %d channels, %d bits", audio.SampleRate, audio.Channels, audio.BitsPerSample)
+	}
+	if d := audio.Duration(); d < 0.5 {
+		t.Errorf("duration = %.3fs, want at least the half second note", d)
+	}
+	if !slices.ContainsFunc(audio.Data, func(b byte) bool { return b != 0 }) {
+		t.Error("audio is silent, want the note")
+	}
+}
+
+func TestNewSynthesizerSampleRate(t *testing.T) {
+	for _, rate := range []int{0, -1} {
+		if _, err := NewSynthesizer(nil, WithSynthSampleRate(rate)); err == nil {
+			t.Errorf("rate %d: want an error", rate)
+		}
+	}
+}
@@ -0,0 +1,916 @@
+package osrscache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// errEndOfPacket is returned when a Vorbis packet ends inside a field.
+var errEndOfPacket = errors.New("end of packet")
+
+// vorbisBits reads Vorbis fields, which are packed least significant bit
+// first.
+type vorbisBits struct {
+	data []byte
+	pos  int
+	bit  uint
+	// err records reading past the end, for headers that are checked once
+	// fully read.
+	err error
+}
+
+func (b *vorbisBits) read(n uint) (uint32, error) {
+	var value uint32
+	for shift := uint(0); shift < n; {
+		if b.pos >= len(b.data) {
+			b.err = errEndOfPacket
+			return 0, b.err
+		}
+		take := min(8-b.bit, n-shift)
+		value |= uint32(b.data[b.pos]>>b.bit&(1<<take-1)) << shift
+		shift += take
+		b.bit += take
+		if b.bit == 8 {
+			b.bit = 0
+			b.pos++
+		}
+	}
+	return value, nil
+}
+
+// int reads a header field, leaving any error in b.err.
+func (b *vorbisBits) int(n uint) int {
+	v, _ := b.read(n)
+	return int(v)
+}
+
+func (b *vorbisBits) flag() (bool, error) {
+	v, err := b.read(1)
+	return v != 0, err
+}
+
+func ilog(x int) uint {
+	n := uint(0)
+	for ; x > 0; x >>= 1 {
+		n++
+	}
+	return n
+}
+
+// VorbisSetup holds the codebooks and configurations shared by every music
+// sample. It is the setup header of a mono Vorbis stream without its packet
+// framing, preceded by the two block sizes.
+type VorbisSetup struct {
+	BlockSize0, BlockSize1 int
+
+	codebooks []*vorbisCodebook
+	floors    []*vorbisFloor
+	residues  []*vorbisResidue
+	mappings  []*vorbisMapping
+	modes     []vorbisMode
+
+	mdctOnce sync.Once
+	mdct     map[int][]float32
+}
+
+type vorbisCodebook struct {
+	dimensions int
+	entries    int
+	lengths    []uint8
+	// codes maps a codeword, prefixed by a set bit to keep its length, to
+	// its entry.
+	codes     map[uint32]int
+	maxLength uint8
+	vectors   [][]float32
+}
+
+type vorbisFloor struct {
+	partitionClasses []int
+	classDimensions  []int
+	classSubclasses  []int
+	classMasterbooks []int
+	subclassBooks    [][]int
+	multiplier       int
+	xList            []int
+	sorted           []int
+}
+
+type vorbisResidue struct {
+	kind            int
+	begin, end      int
+	partitionSize   int
+	classifications int
+	classbook       int
+	books           [][8]int
+}
+
+type vorbisMapping struct {
+	mux           int
+	submapFloors  []int
+	submapResidue []int
+}
+
+type vorbisMode struct {
+	blockFlag bool
+	mapping   int
+}
+
+// ReadVorbisSetup decodes the setup stored in archive 14 group 0.
+func ReadVorbisSetup(data []byte) (*VorbisSetup, error) {
+	b := &vorbisBits{data: data}
+	s := &VorbisSetup{}
+
+	s.BlockSize0 = 1 << b.int(4)
+	s.BlockSize1 = 1 << b.int(4)
+
+	codebookCount := b.int(8) + 1
+	for i := range codebookCount {
+		codebook, err := readVorbisCodebook(b)
+		if err != nil {
+			return nil, fmt.Errorf("reading codebook %d: %w", i, err)
+		}
+		s.codebooks = append(s.codebooks, codebook)
+	}
+
+	for range b.int(6) + 1 {
+		b.int(16)
+	}
+
+	for i := range b.int(6) + 1 {
+		floor, err := readVorbisFloor(b)
+		if err != nil {
+			return nil, fmt.Errorf("reading floor %d: %w", i, err)
+		}
+		s.floors = append(s.floors, floor)
+	}
+
+	for range b.int(6) + 1 {
+		r := &vorbisResidue{
+			kind:            b.int(16),
+			begin:           b.int(24),
+			end:             b.int(24),
+			partitionSize:   b.int(24) + 1,
+			classifications: b.int(6) + 1,
+			classbook:       b.int(8),
+		}
+		cascade := make([]int, r.classifications)
+		for i := range cascade {
+			high := b.int(3)
+			low := 0
+			if b.int(1) != 0 {
+				low = b.int(5)
+			}
+			cascade[i] = low<<3 | high
+		}
+		r.books = make([][8]int, r.classifications)
+		for i := range r.books {
+			for pass := range 8 {
+				r.books[i][pass] = -1
+				if cascade[i]&(1<<pass) != 0 {
+					r.books[i][pass] = b.int(8)
+				}
+			}
+		}
+		s.residues = append(s.residues, r)
+	}
+
+	for range b.int(6) + 1 {
+		b.int(16)
+		m := &vorbisMapping{}
+		submaps := 1
+		if b.int(1) != 0 {
+			submaps = b.int(4) + 1
+		}
+		if b.int(1) != 0 {
+			// Coupling steps, which a mono stream has no channels for.
+			b.int(8)
+		}
+		b.int(2)
+		if submaps > 1 {
+			m.mux = b.int(4)
+		}
+		for range submaps {
+			b.int(8)
+			m.submapFloors = append(m.submapFloors, b.int(8))
+			m.submapResidue = append(m.submapResidue, b.int(8))
+		}
+		s.mappings = append(s.mappings, m)
+	}
+
+	for range b.int(6) + 1 {
+		mode := vorbisMode{blockFlag: b.int(1) != 0}
+		b.int(16)
+		b.int(16)
+		mode.mapping = b.int(8)
+		s.modes = append(s.modes, mode)
+	}
+
+	if b.err != nil {
+		return nil, fmt.Errorf("reading setup: %w", b.err)
+	}
+	return s, s.validate()
+}
+
+// validate checks the references between the parts of the setup so that
+// decoding packets cannot index out of range.
+func (s *VorbisSetup) validate() error {
+	book := func(i int) error {
+		if i < 0 || i >= len(s.codebooks) {
+			return fmt.Errorf("codebook %d out of range", i)
+		}
+		return nil
+	}
+	for _, f := range s.floors {
+		for i, master := range f.classMasterbooks {
+			if f.classSubclasses[i] != 0 {
+				if err := book(master); err != nil {
+					return err
+				}
+			}
+			for _, b := range f.subclassBooks[i] {
+				if b >= 0 {
+					if err := book(b); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	for _, r := range s.residues {
+		if err := book(r.classbook); err != nil {
+			return err
+		}
+		for _, books := range r.books {
+			for _, b := range books {
+				if b >= 0 {
+					if err := book(b); err != nil {
+						return err
+					}
+					if s.codebooks[b].vectors == nil {
+						return fmt.Errorf("residue codebook %d has no vectors", b)
+					}
+				}
+			}
+		}
+	}
+	for _, m := range s.mappings {
+		if m.mux >= len(m.submapFloors) {
+			return fmt.Errorf("mapping mux %d out of range", m.mux)
+		}
+		for i := range m.submapFloors {
+			if m.submapFloors[i] >= len(s.floors) || m.submapResidue[i] >= len(s.residues) {
+				return fmt.Errorf("mapping submap %d out of range", i)
+			}
+		}
+	}
+	for _, mode := range s.modes {
+		if mode.mapping >= len(s.mappings) {
+			return fmt.Errorf("mode mapping %d out of range", mode.mapping)
+		}
+	}
+	return nil
+}
+
+func readVorbisCodebook(b *vorbisBits) (*vorbisCodebook, error) {
+	b.int(24)
+	c := &vorbisCodebook{dimensions: b.int(16), entries: b.int(24)}
+	c.lengths = make([]uint8, c.entries)
+
+	if b.int(1) != 0 {
+		length := b.int(5) + 1
+		for entry := 0; entry < c.entries; length++ {
+			if b.err != nil || length > 32 {
+				return nil, fmt.Errorf("invalid ordered lengths")
+			}
+			count := b.int(ilog(c.entries - entry))
+			for range count {
+				if entry >= c.entries {
+					return nil, fmt.Errorf("ordered lengths overflow %d entries", c.entries)
+				}
+				c.lengths[entry] = uint8(length)
+				entry++
+			}
+		}
+	} else {
+		sparse := b.int(1) != 0
+		for i := range c.lengths {
+			if sparse && b.int(1) == 0 {
+				continue
+			}
+			c.lengths[i] = uint8(b.int(5) + 1)
+		}
+	}
+	if err := c.buildCodes(); err != nil {
+		return nil, err
+	}
+
+	lookupType := b.int(4)
+	if lookupType == 0 {
+		return c, nil
+	}
+	if lookupType > 2 {
+		return nil, fmt.Errorf("unknown lookup type %d", lookupType)
+	}
+
+	minimum := float32Unpack(uint32(b.int(32)))
+	delta := float32Unpack(uint32(b.int(32)))
+	valueBits := uint(b.int(4) + 1)
+	sequence := b.int(1) != 0
+
+	lookupValues := c.entries * c.dimensions
+	if lookupType == 1 {
+		lookupValues = lookup1Values(c.entries, c.dimensions)
+	}
+	multiplicands := make([]int, lookupValues)
+	for i := range multiplicands {
+		multiplicands[i] = b.int(valueBits)
+	}
+
+	c.vectors = make([][]float32, c.entries)
+	for entry := range c.vectors {
+		vector := make([]float32, c.dimensions)
+		var last float32
+		divisor := 1
+		for dim := range vector {
+			offset := entry*c.dimensions + dim
+			if lookupType == 1 {
+				offset = entry / divisor % lookupValues
+				divisor *= lookupValues
+			}
+			vector[dim] = float32(multiplicands[offset])*delta + minimum + last
+			if sequence {
+				last = vector[dim]
+			}
+		}
+		c.vectors[entry] = vector
+	}
+	return c, nil
+}
+
+// buildCodes assigns codewords to entries in order, each the lowest
+// codeword of its length still free.
+func (c *vorbisCodebook) buildCodes() error {
+	c.codes = make(map[uint32]int)
+	var marker [33]uint32
+	for entry, length := range c.lengths {
+		if length == 0 {
+			continue
+		}
+		code := marker[length]
+		if length < 32 && code>>length != 0 {
+			return fmt.Errorf("codebook lengths are overspecified")
+		}
+		c.codes[code|1<<length] = entry
+		c.maxLength = max(c.maxLength, length)
+
+		for j := int(length); j > 0; j-- {
+			if marker[j]&1 != 0 {
+				if j == 1 {
+					marker[1]++
+				} else {
+					marker[j] = marker[j-1] << 1
+				}
+				break
+			}
+			marker[j]++
+		}
+		for j := int(length) + 1; j < 33; j++ {
+			if marker[j]>>1 != code {
+				break
+			}
+			code = marker[j]
+			marker[j] = marker[j-1] << 1
+		}
+	}
+	return nil
+}
+
+func (c *vorbisCodebook) decode(b *vorbisBits) (int, error) {
+	code := uint32(1)
+	for range c.maxLength {
+		bit, err := b.read(1)
+		if err != nil {
+			return 0, err
+		}
+		code = code<<1 | bit
+		if entry, ok := c.codes[code]; ok {
+			return entry, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid codeword")
+}
+
+func float32Unpack(x uint32) float32 {
+	mantissa := float64(x & 0x1FFFFF)
+	exponent := int(x&0x7FE00000) >> 21
+	if x&0x80000000 != 0 {
+		mantissa = -mantissa
+	}
+	return float32(math.Ldexp(mantissa, exponent-788))
+}
+
+// lookup1Values returns the largest r with r^dimensions <= entries.
+func lookup1Values(entries, dimensions int) int {
+	r := int(math.Floor(math.Pow(float64(entries), 1/float64(dimensions))))
+	for pow(r+1, dimensions) <= entries {
+		r++
+	}
+	for r > 0 && pow(r, dimensions) > entries {
+		r--
+	}
+	return r
+}
+
+func pow(base, exponent int) int {
+	result := 1
+	for range exponent {
+		result *= base
+	}
+	return result
+}
+
+func readVorbisFloor(b *vorbisBits) (*vorbisFloor, error) {
+	if kind := b.int(16); kind != 1 {
+		return nil, fmt.Errorf("unsupported floor type %d", kind)
+	}
+
+	f := &vorbisFloor{}
+	classes := 0
+	f.partitionClasses = make([]int, b.int(5))
+	for i := range f.partitionClasses {
+		f.partitionClasses[i] = b.int(4)
+		classes = max(classes, f.partitionClasses[i]+1)
+	}
+
+	f.classDimensions = make([]int, classes)
+	f.classSubclasses = make([]int, classes)
+	f.classMasterbooks = make([]int, classes)
+	f.subclassBooks = make([][]int, classes)
+	for i := range classes {
+		f.classDimensions[i] = b.int(3) + 1
+		f.classSubclasses[i] = b.int(2)
+		if f.classSubclasses[i] != 0 {
+			f.classMasterbooks[i] = b.int(8)
+		}
+		f.subclassBooks[i] = make([]int, 1<<f.classSubclasses[i])
+		for j := range f.subclassBooks[i] {
+			f.subclassBooks[i][j] = b.int(8) - 1
+		}
+	}
+
+	f.multiplier = b.int(2) + 1
+	rangeBits := uint(b.int(4))
+	f.xList = []int{0, 1 << rangeBits}
+	for _, class := range f.partitionClasses {
+		for range f.classDimensions[class] {
+			f.xList = append(f.xList, b.int(rangeBits))
+		}
+	}
+
+	f.sorted = make([]int, len(f.xList))
+	for i := range f.sorted {
+		f.sorted[i] = i
+	}
+	sort.SliceStable(f.sorted, func(i, j int) bool { return f.xList[f.sorted[i]] < f.xList[f.sorted[j]] })
+	return f, nil
+}
+
+// decode reads the floor of a packet, returning nil when the packet has no
+// audio energy.
+func (f *vorbisFloor) decode(b *vorbisBits, codebooks []*vorbisCodebook) ([]int, error) {
+	nonzero, err := b.flag()
+	if err != nil || !nonzero {
+		return nil, err
+	}
+
+	rangeBits := ilog([]int{256, 128, 86, 64}[f.multiplier-1] - 1)
+	y := make([]int, len(f.xList))
+	for i := range 2 {
+		v, err := b.read(rangeBits)
+		if err != nil {
+			return nil, err
+		}
+		y[i] = int(v)
+	}
+
+	offset := 2
+	for _, class := range f.partitionClasses {
+		dimensions := f.classDimensions[class]
+		bits := f.classSubclasses[class]
+		subclasses := 1<<bits - 1
+		value := 0
+		if bits > 0 {
+			if value, err = codebooks[f.classMasterbooks[class]].decode(b); err != nil {
+				return nil, err
+			}
+		}
+		for j := range dimensions {
+			book := f.subclassBooks[class][value&subclasses]
+			value >>= bits
+			if book >= 0 {
+				if y[offset+j], err = codebooks[book].decode(b); err != nil {
+					return nil, err
+				}
+			}
+		}
+		offset += dimensions
+	}
+	return y, nil
+}
+
+// curve synthesizes the floor of n values from the decoded amplitudes.
+func (f *vorbisFloor) curve(y []int, n int) []float32 {
+	valueRange := []int{256, 128, 86, 64}[f.multiplier-1]
+	final := make([]int, len(y))
+	used := make([]bool, len(y))
+	final[0], final[1] = y[0], y[1]
+	used[0], used[1] = true, true
+
+	for i := 2; i < len(y); i++ {
+		low, high := 0, 1
+		for j := range i {
+			if f.xList[j] < f.xList[i] && f.xList[j] > f.xList[low] {
+				low = j
+			}
+			if f.xList[j] > f.xList[i] && f.xList[j] < f.xList[high] {
+				high = j
+			}
+		}
+		predicted := renderPoint(f.xList[low], final[low], f.xList[high], final[high], f.xList[i])
+		value := y[i]
+		highRoom := valueRange - predicted
+		lowRoom := predicted
+		room := min(highRoom, lowRoom) * 2
+		if value == 0 {
+			final[i] = predicted
+			continue
+		}
+		used[low], used[high], used[i] = true, true, true
+		switch {
+		case value >= room && highRoom > lowRoom:
+			final[i] = value - lowRoom + predicted
+		case value >= room:
+			final[i] = predicted - value + highRoom - 1
+		case value%2 == 1:
+			final[i] = predicted - (value+1)/2
+		default:
+			final[i] = predicted + value/2
+		}
+	}
+
+	out := make([]float32, n)
+	lx, ly := 0, final[f.sorted[0]]*f.multiplier
+	hx, hy := 0, ly
+	for _, i := range f.sorted[1:] {
+		if !used[i] {
+			continue
+		}
+		hx, hy = f.xList[i], final[i]*f.multiplier
+		renderLine(lx, ly, hx, hy, out)
+		lx, ly = hx, hy
+	}
+	if hx < n {
+		renderLine(hx, hy, n, hy, out)
+	}
+	return out
+}
+
+func renderPoint(x0, y0, x1, y1, x int) int {
+	dy := y1 - y0
+	adx := x1 - x0
+	ady := abs(dy)
+	off := ady * (x - x0) / adx
+	if dy < 0 {
+		return y0 - off
+	}
+	return y0 + off
+}
+
+func renderLine(x0, y0, x1, y1 int, out []float32) {
+	dy := y1 - y0
+	adx := x1 - x0
+	if adx <= 0 {
+		return
+	}
+	ady := abs(dy)
+	base := dy / adx
+	sy := base + 1
+	if dy < 0 {
+		sy = base - 1
+	}
+	ady -= abs(base) * adx
+
+	x, y, errAcc := x0, y0, 0
+	set := func(x, y int) {
+		if x < len(out) {
+			out[x] = floorInverseDB(y)
+		}
+	}
+	set(x, y)
+	for x = x0 + 1; x < x1; x++ {
+		errAcc += ady
+		if errAcc >= adx {
+			errAcc -= adx
+			y += sy
+		} else {
+			y += base
+		}
+		set(x, y)
+	}
+}
+
+// floorInverseDB maps a floor value to its linear amplitude, each step being
+// a ratio of about 0.55 dB.
+func floorInverseDB(y int) float32 {
+	y = min(max(y, 0), 255)
+	return float32(math.Pow(1.0649863, float64(y-255)))
+}
+
+// decode adds the residue of a packet to v, the first n/2 spectral values.
+func (r *vorbisResidue) decode(b *vorbisBits, codebooks []*vorbisCodebook, v []float32) error {
+	n := len(v)
+	begin, end := min(r.begin, n), min(r.end, n)
+	if end <= begin {
+		return nil
+	}
+
+	classbook := codebooks[r.classbook]
+	perCodeword := classbook.dimensions
+	partitions := (end - begin) / r.partitionSize
+	classifications := make([]int, partitions+perCodeword)
+
+	for pass := range 8 {
+		for partition := 0; partition < partitions; {
+			if pass == 0 {
+				word, err := classbook.decode(b)
+				if err != nil {
+					return nil
+				}
+				for i := perCodeword - 1; i >= 0; i-- {
+					classifications[partition+i] = word % r.classifications
+					word /= r.classifications
+				}
+			}
+			for i := 0; i < perCodeword && partition < partitions; i++ {
+				book := r.books[classifications[partition]][pass]
+				if book >= 0 {
+					offset := begin + partition*r.partitionSize
+					if err := r.decodePartition(b, codebooks[book], v[offset:offset+r.partitionSize]); err != nil {
+						return nil
+					}
+				}
+				partition++
+			}
+		}
+	}
+	return nil
+}
+
+func (r *vorbisResidue) decodePartition(b *vorbisBits, book *vorbisCodebook, v []float32) error {
+	dimensions := book.dimensions
+	if r.kind == 0 {
+		step := len(v) / dimensions
+		for j := range step {
+			entry, err := book.decode(b)
+			if err != nil {
+				return err
+			}
+			for k, value := range book.vectors[entry] {
+				v[j+k*step] += value
+			}
+		}
+		return nil
+	}
+
+	for i := 0; i < len(v); {
+		entry, err := book.decode(b)
+		if err != nil {
+			return err
+		}
+		for _, value := range book.vectors[entry] {
+			if i < len(v) {
+				v[i] += value
+				i++
+			}
+		}
+	}
+	return nil
+}
+
+// cosines returns the IMDCT kernel for blocks of n samples.
+func (s *VorbisSetup) cosines(n int) []float32 {
+	s.mdctOnce.Do(func() {
+		s.mdct = make(map[int][]float32)
+		for _, size := range []int{s.BlockSize0, s.BlockSize1} {
+			if _, ok := s.mdct[size]; ok {
+				continue
+			}
+			half := size / 2
+			table := make([]float32, size*half)
+			for i := range size {
+				for k := range half {
+					table[i*half+k] = float32(math.Cos(math.Pi / 2 / float64(size) * float64(2*i+1+half) * float64(2*k+1)))
+				}
+			}
+			s.mdct[size] = table
+		}
+	})
+	return s.mdct[n]
+}
+
+// VorbisSample is a music sample from archive 14, a mono Vorbis stream
+// decoded with the shared VorbisSetup.
+type VorbisSample struct {
+	ID          uint32 `json:"id"`
+	SampleRate  int    `json:"sample_rate"`
+	SampleCount int    `json:"sample_count"`
+	LoopStart   int    `json:"loop_start"`
+	LoopEnd     int    `json:"loop_end"`
+	// EndInverted is set when the loop end is stored bitwise inverted,
+	// which the client passes on to its sample player.
+	EndInverted bool     `json:"end_inverted"`
+	Packets     [][]byte `json:"-"`
+}
+
+func NewVorbisSample(id uint32) *VorbisSample {
+	return &VorbisSample{ID: id}
+}
+
+func (v *VorbisSample) Read(data []byte) error {
+	reader := NewReader(data)
+	fields := []*int{&v.SampleRate, &v.SampleCount, &v.LoopStart, &v.LoopEnd}
+	for _, field := range fields {
+		value, err := reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading header: %w", err)
+		}
+		*field = int(value)
+	}
+	if v.LoopEnd < 0 {
+		v.LoopEnd = ^v.LoopEnd
+		v.EndInverted = true
+	}
+
+	count, err := reader.ReadInt32()
+	if err != nil {
+		return fmt.Errorf("reading packet count: %w", err)
+	}
+	if count < 0 || int(count) > reader.Len() {
+		return fmt.Errorf("invalid packet count %d", count)
+	}
+	v.Packets = make([][]byte, count)
+	for i := range v.Packets {
+		size := 0
+		for {
+			b, err := reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading packet %d size: %w", i, err)
+			}
+			size += int(b)
+			if b < 0xFF {
+				break
+			}
+		}
+		if v.Packets[i], err = reader.ReadBytes(size); err != nil {
+			return fmt.Errorf("reading packet %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the packets to signed 8-bit samples.
+func (v *VorbisSample) Decode(setup *VorbisSetup) (*PCMSample, error) {
+	if v.SampleCount < 0 {
+		return nil, fmt.Errorf("invalid sample count %d", v.SampleCount)
+	}
+	samples := make([]int8, 0, v.SampleCount)
+	var previous []float32
+	for i, packet := range v.Packets {
+		block, err := setup.decodePacket(packet)
+		if err != nil {
+			return nil, fmt.Errorf("decoding packet %d: %w", i, err)
+		}
+
+		if previous != nil {
+			// The output runs from the center of the previous block to the
+			// center of this one, with their windows overlapping around
+			// the boundary at three quarters and one quarter.
+			a, n := len(previous), len(block)
+			for t := -a / 4; t < n/4 && len(samples) < v.SampleCount; t++ {
+				var sum float32
+				if j := 3*a/4 + t; j < a {
+					sum += previous[j]
+				}
+				if j := n/4 + t; j >= 0 {
+					sum += block[j]
+				}
+				sample := int32(128 + sum*128)
+				if sample&^0xFF != 0 {
+					sample = ^sample >> 31 & 0xFF
+				}
+				samples = append(samples, int8(sample-128))
+			}
+		}
+		previous = block
+	}
+
+	for len(samples) < v.SampleCount {
+		samples = append(samples, 0)
+	}
+	return &PCMSample{
+		SampleRate: v.SampleRate,
+		Samples:    samples,
+		LoopStart:  v.LoopStart,
+		LoopEnd:    v.LoopEnd,
+	}, nil
+}
+
+// decodePacket decodes an audio packet to a windowed block.
+func (s *VorbisSetup) decodePacket(packet []byte) ([]float32, error) {
+	b := &vorbisBits{data: packet}
+	if _, err := b.read(1); err != nil {
+		return nil, err
+	}
+	modeIndex, err := b.read(ilog(len(s.modes) - 1))
+	if err != nil {
+		return nil, err
+	}
+	if int(modeIndex) >= len(s.modes) {
+		return nil, fmt.Errorf("mode %d out of range", modeIndex)
+	}
+	mode := s.modes[modeIndex]
+
+	n := s.BlockSize0
+	var previousLong, nextLong bool
+	if mode.blockFlag {
+		n = s.BlockSize1
+		previousLong, _ = b.flag()
+		nextLong, _ = b.flag()
+	}
+	half := n / 2
+
+	mapping := s.mappings[mode.mapping]
+	floor := s.floors[mapping.submapFloors[mapping.mux]]
+	y, err := floor.decode(b, s.codebooks)
+	if err != nil && !errors.Is(err, errEndOfPacket) {
+		return nil, err
+	}
+
+	spectrum := make([]float32, half)
+	if y != nil {
+		for _, residue := range mapping.submapResidue {
+			if err := s.residues[residue].decode(b, s.codebooks, spectrum); err != nil {
+				return nil, err
+			}
+		}
+		curve := floor.curve(y, half)
+		for i := range spectrum {
+			spectrum[i] *= curve[i]
+		}
+	}
+
+	out := make([]float32, n)
+	if y != nil {
+		table := s.cosines(n)
+		for i := range out {
+			row := table[i*half : (i+1)*half]
+			var acc float32
+			for k, x := range spectrum {
+				if x != 0 {
+					acc += x * row[k]
+				}
+			}
+			out[i] = acc
+		}
+	}
+
+	leftStart, leftEnd, leftN := 0, half, half
+	if mode.blockFlag && !previousLong {
+		leftStart, leftEnd, leftN = n/4-s.BlockSize0/4, n/4+s.BlockSize0/4, s.BlockSize0/2
+	}
+	rightStart, rightEnd, rightN := half, n, half
+	if mode.blockFlag && !nextLong {
+		rightStart, rightEnd, rightN = n*3/4-s.BlockSize0/4, n*3/4+s.BlockSize0/4, s.BlockSize0/2
+	}
+	for i := range out {
+		switch {
+		case i < leftStart || i >= rightEnd:
+			out[i] = 0
+		case i < leftEnd:
+			out[i] *= vorbisWindow(i-leftStart, leftN)
+		case i >= rightStart:
+			out[i] *= vorbisWindow(i-rightStart+rightN, rightN)
+		}
+	}
+	return out, nil
+}
+
+// vorbisWindow is the power sine window rising over n samples and falling
+// over the next n.
+func vorbisWindow(i, n int) float32 {
+	s := math.Sin((float64(i) + 0.5) / float64(n) * math.Pi / 2)
+	return float32(math.Sin(math.Pi / 2 * s * s))
+}
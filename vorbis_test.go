@@ -0,0 +1,163 @@
+package osrscache
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// bitWriter packs fields least significant bit first, as Vorbis does.
+type bitWriter struct {
+	data []byte
+	bit  uint
+}
+
+func (w *bitWriter) write(value uint32, n uint) {
+	for i := range n {
+		if w.bit == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(value>>i&1) << w.bit
+		w.bit = (w.bit + 1) % 8
+	}
+}
+
+// vorbisSetupBytes encodes a setup with 64 sample blocks, one codebook of two
+// one-bit codewords for the values 0 and 1, a flat floor and a residue
+// covering the whole spectrum in one partition.
+func vorbisSetupBytes() []byte {
+	w := &bitWriter{}
+	w.write(6, 4) // block sizes
+	w.write(6, 4)
+
+	w.write(0, 8) // one codebook
+	w.write(0x564342, 24)
+	w.write(1, 16) // dimensions
+	w.write(2, 24) // entries
+	w.write(0, 1)  // unordered
+	w.write(0, 1)  // not sparse
+	w.write(0, 5)  // lengths of 1
+	w.write(0, 5)
+	w.write(1, 4)          // lookup type
+	w.write(0, 32)         // minimum 0
+	w.write(788<<21|1, 32) // delta 1
+	w.write(0, 4)          // one bit values
+	w.write(0, 1)          // not a sequence
+	w.write(0, 1)          // multiplicands
+	w.write(1, 1)
+
+	w.write(0, 6) // one time
+	w.write(0, 16)
+
+	w.write(0, 6) // one floor
+	w.write(1, 16)
+	w.write(0, 5) // no partitions
+	w.write(0, 2) // multiplier 1
+	w.write(6, 4) // range bits
+
+	w.write(0, 6) // one residue
+	w.write(1, 16)
+	w.write(0, 24)  // begin
+	w.write(32, 24) // end
+	w.write(31, 24) // partition size 32
+	w.write(0, 6)   // one classification
+	w.write(0, 8)   // classbook
+	w.write(1, 3)   // first pass only
+	w.write(0, 1)
+	w.write(0, 8)
+
+	w.write(0, 6) // one mapping
+	w.write(0, 16)
+	w.write(0, 1) // one submap
+	w.write(0, 1) // no coupling
+	w.write(0, 2)
+	w.write(0, 8)
+	w.write(0, 8) // floor
+	w.write(0, 8) // residue
+
+	w.write(0, 6) // one mode
+	w.write(0, 1) // short blocks
+	w.write(0, 16)
+	w.write(0, 16)
+	w.write(0, 8) // mapping
+	return w.data
+}
+
+// vorbisPacket encodes an audio packet with only spectral bin 1 set, or a
+// silent packet.
+func vorbisPacket(silent bool) []byte {
+	w := &bitWriter{}
+	w.write(0, 1) // audio packet
+	if silent {
+		w.write(0, 1)
+		return w.data
+	}
+	w.write(1, 1)
+	w.write(255, 8) // floor amplitudes of 1
+	w.write(255, 8)
+	w.write(0, 1) // classification
+	for bin := range 32 {
+		if bin == 1 {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+	}
+	return w.data
+}
+
+// vorbisSampleBytes encodes a sample of 40 samples whose second packet sets
+// bin 1, with an inverted loop end of 10.
+func vorbisSampleBytes() []byte {
+	var buf []byte
+	for _, field := range []int32{22050, 40, 0, ^10, 2} {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(field))
+	}
+	for _, packet := range [][]byte{vorbisPacket(true), vorbisPacket(false)} {
+		buf = append(buf, byte(len(packet)))
+		buf = append(buf, packet...)
+	}
+	return buf
+}
+
+func TestVorbisDecode(t *testing.T) {
+	setup, err := ReadVorbisSetup(vorbisSetupBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup.BlockSize0 != 64 || setup.BlockSize1 != 64 {
+		t.Errorf("block sizes = %d, %d, want 64", setup.BlockSize0, setup.BlockSize1)
+	}
+
+	sample := NewVorbisSample(1)
+	if err := sample.Read(vorbisSampleBytes()); err != nil {
+		t.Fatal(err)
+	}
+	if sample.LoopEnd != 10 || !sample.EndInverted || len(sample.Packets) != 2 {
+		t.Errorf("sample = %+v, want loop end 10 inverted and 2 packets", sample)
+	}
+
+	pcm, err := sample.Decode(setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pcm.SampleRate != 22050 || len(pcm.Samples) != 40 {
+		t.Fatalf("decoded %d samples at %d Hz, want 40 at 22050", len(pcm.Samples), pcm.SampleRate)
+	}
+
+	// The silent first packet leaves the first half of the second block,
+	// the inverse MDCT of bin 1 under the rising window.
+	for i, got := range pcm.Samples[:32] {
+		window := math.Sin(math.Pi / 2 * math.Pow(math.Sin((float64(i)+0.5)/32*math.Pi/2), 2))
+		value := math.Cos(math.Pi/128*float64(2*i+1+32)*3) * window
+		want := int(128+value*128) - 128
+		if diff := int(got) - want; diff < -1 || diff > 1 {
+			t.Errorf("sample %d = %d, want %d", i, got, want)
+		}
+	}
+	for i, got := range pcm.Samples[32:] {
+		if got != 0 {
+			t.Errorf("padding sample %d = %d, want 0", 32+i, got)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package whirlpool implements the Whirlpool hash function, which the game
+// uses for reference table and master index digests.
+package whirlpool
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+const (
+	Size      = 64
+	BlockSize = 64
+	rounds    = 10
+)
+
+var (
+	sbox      [256]byte
+	constants [rounds + 1][8]byte
+	circulant = [8]byte{1, 1, 4, 1, 8, 5, 2, 9}
+
+	// products holds v * circulant[i] for every byte v.
+	products [8][256]byte
+)
+
+func init() {
+	e := [16]byte{0x1, 0xB, 0x9, 0xC, 0xD, 0x6, 0xF, 0x3, 0xE, 0x8, 0x7, 0x4, 0xA, 0x2, 0x5, 0x0}
+	r := [16]byte{0x7, 0xC, 0xB, 0xD, 0xE, 0x4, 0x9, 0xF, 0x6, 0x3, 0x8, 0xA, 0x2, 0x5, 0x1, 0x0}
+
+	var eInv [16]byte
+	for i, v := range e {
+		eInv[v] = byte(i)
+	}
+
+	for x := 0; x < 256; x++ {
+		u, l := e[x>>4], eInv[x&0xF]
+		t := r[u^l]
+		sbox[x] = e[u^t]<<4 | eInv[l^t]
+	}
+
+	for round := 1; round <= rounds; round++ {
+		copy(constants[round][:], sbox[8*(round-1):8*round])
+	}
+
+	for i, c := range circulant {
+		for v := 0; v < 256; v++ {
+			products[i][v] = mul(byte(v), c)
+		}
+	}
+}
+
+// mul multiplies a and b in GF(2^8) with the reduction polynomial
+// x^8 + x^4 + x^3 + x^2 + 1.
+func mul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1D
+		}
+		b >>= 1
+	}
+	return p
+}
+
+type state [8][8]byte
+
+// round applies the non-linear layer, cyclical permutation and linear
+// diffusion layer to s, then adds the round key k.
+func (s *state) round(k *state) {
+	var permuted state
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			permuted[(i+j)%8][j] = sbox[s[i][j]]
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			var v byte
+			for x := 0; x < 8; x++ {
+				v ^= products[(j-x+8)%8][permuted[i][x]]
+			}
+			s[i][j] = v ^ k[i][j]
+		}
+	}
+}
+
+type digest struct {
+	h      state
+	buf    [BlockSize]byte
+	n      int
+	length uint64
+}
+
+// New returns a new hash.Hash computing the Whirlpool checksum.
+func New() hash.Hash {
+	d := &digest{}
+	d.Reset()
+	return d
+}
+
+// Sum returns the Whirlpool checksum of data.
+func Sum(data []byte) [Size]byte {
+	d := &digest{}
+	d.Write(data)
+	var sum [Size]byte
+	copy(sum[:], d.Sum(nil))
+	return sum
+}
+
+func (d *digest) Reset() {
+	*d = digest{}
+}
+
+func (d *digest) Size() int { return Size }
+
+func (d *digest) BlockSize() int { return BlockSize }
+
+func (d *digest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.length += uint64(n)
+	for len(p) > 0 {
+		c := copy(d.buf[d.n:], p)
+		d.n += c
+		p = p[c:]
+		if d.n == BlockSize {
+			d.block(d.buf[:])
+			d.n = 0
+		}
+	}
+	return n, nil
+}
+
+func (d *digest) Sum(in []byte) []byte {
+	c := *d
+
+	var pad [BlockSize * 2]byte
+	pad[0] = 0x80
+	padLen := (BlockSize+32-c.n-1)%BlockSize + 1
+	bits := c.length << 3
+	tail := make([]byte, 32)
+	binary.BigEndian.PutUint64(tail[16:], c.length>>61)
+	binary.BigEndian.PutUint64(tail[24:], bits)
+
+	c.Write(pad[:padLen])
+	c.Write(tail)
+
+	out := make([]byte, 0, Size)
+	for i := 0; i < 8; i++ {
+		out = append(out, c.h[i][:]...)
+	}
+	return append(in, out...)
+}
+
+func (d *digest) block(b []byte) {
+	var k, s, m state
+	for i := 0; i < 8; i++ {
+		copy(m[i][:], b[i*8:i*8+8])
+	}
+
+	k = d.h
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			s[i][j] = m[i][j] ^ k[i][j]
+		}
+	}
+
+	for round := 1; round <= rounds; round++ {
+		var c state
+		c[0] = constants[round]
+		k.round(&c)
+		s.round(&k)
+	}
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			d.h[i][j] ^= s[i][j] ^ m[i][j]
+		}
+	}
+}
@@ -0,0 +1,876 @@
+package osrscache
+
+import (
+	"fmt"
+)
+
+const (
+	WidgetTypeLayer     = 0
+	WidgetTypeInventory = 2
+	WidgetTypeRectangle = 3
+	WidgetTypeText      = 4
+	WidgetTypeGraphic   = 5
+	WidgetTypeModel     = 6
+	WidgetTypeItemList  = 7
+	WidgetTypeTooltip   = 8
+	WidgetTypeLine      = 9
+)
+
+// Interface is a group of archive 3. Its files are the interface's
+// components.
+type Interface struct {
+	ID         uint16             `json:"id"`
+	Components map[uint16]*Widget `json:"components"`
+}
+
+// ScriptListener is a client script bound to a widget event. Arguments are
+// int32 or string values; some ints are placeholders the client substitutes
+// when the event fires, such as the mouse position or the source component.
+type ScriptListener struct {
+	ScriptID int32 `json:"script_id"`
+	Args     []any `json:"args,omitempty"`
+}
+
+// Widget is a single interface component. ID packs the interface ID in the
+// upper 16 bits and the component index in the lower 16 bits, as does
+// ParentID when it is not -1.
+type Widget struct {
+	ID          uint32 `json:"id"`
+	IsIf3       bool   `json:"is_if3"`
+	Type        uint8  `json:"type"`
+	MenuType    uint8  `json:"menu_type"`
+	ContentType uint16 `json:"content_type"`
+
+	X             int16 `json:"x"`
+	Y             int16 `json:"y"`
+	Width         int32 `json:"width"`
+	Height        int32 `json:"height"`
+	WidthMode     int8  `json:"width_mode"`
+	HeightMode    int8  `json:"height_mode"`
+	XPositionMode int8  `json:"x_position_mode"`
+	YPositionMode int8  `json:"y_position_mode"`
+
+	ParentID         int32    `json:"parent_id"`
+	Children         []uint32 `json:"children,omitempty"`
+	HoveredSiblingID int32    `json:"hovered_sibling_id"`
+	IsHidden         bool     `json:"is_hidden"`
+	Opacity          uint8    `json:"opacity"`
+	ScrollWidth      uint16   `json:"scroll_width"`
+	ScrollHeight     uint16   `json:"scroll_height"`
+	NoClickThrough   bool     `json:"no_click_through"`
+
+	AlternateOperators []uint8   `json:"alternate_operators,omitempty"`
+	AlternateRHS       []uint16  `json:"alternate_rhs,omitempty"`
+	ClientScripts      [][]int32 `json:"client_scripts,omitempty"`
+
+	XPitch        int16    `json:"x_pitch"`
+	YPitch        int16    `json:"y_pitch"`
+	XOffsets      []int16  `json:"x_offsets,omitempty"`
+	YOffsets      []int16  `json:"y_offsets,omitempty"`
+	Sprites       []int32  `json:"sprites,omitempty"`
+	ConfigActions []string `json:"config_actions,omitempty"`
+
+	Filled                    bool   `json:"filled"`
+	FontID                    int32  `json:"font_id"`
+	Text                      string `json:"text"`
+	AlternateText             string `json:"alternate_text"`
+	LineHeight                uint8  `json:"line_height"`
+	XTextAlignment            uint8  `json:"x_text_alignment"`
+	YTextAlignment            uint8  `json:"y_text_alignment"`
+	TextShadowed              bool   `json:"text_shadowed"`
+	TextColor                 int32  `json:"text_color"`
+	AlternateTextColor        int32  `json:"alternate_text_color"`
+	HoveredTextColor          int32  `json:"hovered_text_color"`
+	AlternateHoveredTextColor int32  `json:"alternate_hovered_text_color"`
+
+	SpriteID            int32  `json:"sprite_id"`
+	AlternateSpriteID   int32  `json:"alternate_sprite_id"`
+	TextureID           uint16 `json:"texture_id"`
+	SpriteTiling        bool   `json:"sprite_tiling"`
+	BorderType          uint8  `json:"border_type"`
+	ShadowColor         int32  `json:"shadow_color"`
+	FlippedVertically   bool   `json:"flipped_vertically"`
+	FlippedHorizontally bool   `json:"flipped_horizontally"`
+
+	ModelType           uint8  `json:"model_type"`
+	ModelID             int32  `json:"model_id"`
+	AlternateModelType  uint8  `json:"alternate_model_type"`
+	AlternateModelID    int32  `json:"alternate_model_id"`
+	Animation           int32  `json:"animation"`
+	AlternateAnimation  int32  `json:"alternate_animation"`
+	ModelZoom           uint16 `json:"model_zoom"`
+	RotationX           uint16 `json:"rotation_x"`
+	RotationY           uint16 `json:"rotation_y"`
+	RotationZ           uint16 `json:"rotation_z"`
+	OffsetX2D           int16  `json:"offset_x_2d"`
+	OffsetY2D           int16  `json:"offset_y_2d"`
+	Orthogonal          bool   `json:"orthogonal"`
+	ModelHeightOverride uint16 `json:"model_height_override"`
+
+	LineWidth     uint8 `json:"line_width"`
+	LineDirection bool  `json:"line_direction"`
+
+	ClickMask          uint32   `json:"click_mask"`
+	Name               string   `json:"name"`
+	Actions            []string `json:"actions,omitempty"`
+	TargetVerb         string   `json:"target_verb"`
+	SpellName          string   `json:"spell_name"`
+	Tooltip            string   `json:"tooltip"`
+	DragDeadZone       uint8    `json:"drag_dead_zone"`
+	DragDeadTime       uint8    `json:"drag_dead_time"`
+	DragRenderBehavior bool     `json:"drag_render_behavior"`
+
+	OnLoad         *ScriptListener `json:"on_load,omitempty"`
+	OnMouseOver    *ScriptListener `json:"on_mouse_over,omitempty"`
+	OnMouseLeave   *ScriptListener `json:"on_mouse_leave,omitempty"`
+	OnTargetLeave  *ScriptListener `json:"on_target_leave,omitempty"`
+	OnTargetEnter  *ScriptListener `json:"on_target_enter,omitempty"`
+	OnVarTransmit  *ScriptListener `json:"on_var_transmit,omitempty"`
+	OnInvTransmit  *ScriptListener `json:"on_inv_transmit,omitempty"`
+	OnStatTransmit *ScriptListener `json:"on_stat_transmit,omitempty"`
+	OnTimer        *ScriptListener `json:"on_timer,omitempty"`
+	OnOp           *ScriptListener `json:"on_op,omitempty"`
+	OnMouseRepeat  *ScriptListener `json:"on_mouse_repeat,omitempty"`
+	OnClick        *ScriptListener `json:"on_click,omitempty"`
+	OnClickRepeat  *ScriptListener `json:"on_click_repeat,omitempty"`
+	OnRelease      *ScriptListener `json:"on_release,omitempty"`
+	OnHold         *ScriptListener `json:"on_hold,omitempty"`
+	OnDrag         *ScriptListener `json:"on_drag,omitempty"`
+	OnDragComplete *ScriptListener `json:"on_drag_complete,omitempty"`
+	OnScrollWheel  *ScriptListener `json:"on_scroll_wheel,omitempty"`
+
+	VarTransmitTriggers  []int32 `json:"var_transmit_triggers,omitempty"`
+	InvTransmitTriggers  []int32 `json:"inv_transmit_triggers,omitempty"`
+	StatTransmitTriggers []int32 `json:"stat_transmit_triggers,omitempty"`
+}
+
+func NewWidget(interfaceID, componentID uint16) *Widget {
+	return &Widget{
+		ID:                 uint32(interfaceID)<<16 | uint32(componentID),
+		ParentID:           -1,
+		HoveredSiblingID:   -1,
+		FontID:             -1,
+		SpriteID:           -1,
+		AlternateSpriteID:  -1,
+		ModelID:            -1,
+		AlternateModelID:   -1,
+		Animation:          -1,
+		AlternateAnimation: -1,
+		ModelZoom:          100,
+		LineWidth:          1,
+	}
+}
+
+func (w *Widget) InterfaceID() uint16 {
+	return uint16(w.ID >> 16)
+}
+
+func (w *Widget) ComponentID() uint16 {
+	return uint16(w.ID)
+}
+
+// Read decodes a component in either format. IF3 components start with a 0xFF
+// marker byte.
+func (w *Widget) Read(data []byte) error {
+	if len(data) > 0 && data[0] == 0xFF {
+		return w.readIf3(NewReader(data[1:]))
+	}
+	return w.readIf1(NewReader(data))
+}
+
+func (w *Widget) readIf1(reader *Reader) error {
+	var err error
+
+	w.Type, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading type: %w", err)
+	}
+	w.MenuType, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading menu type: %w", err)
+	}
+	w.ContentType, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading content type: %w", err)
+	}
+	if err := w.readBounds(reader); err != nil {
+		return err
+	}
+	w.Opacity, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading opacity: %w", err)
+	}
+	if err := w.readParent(reader); err != nil {
+		return err
+	}
+	w.HoveredSiblingID, err = readOptionalUint16(reader)
+	if err != nil {
+		return fmt.Errorf("reading hovered sibling: %w", err)
+	}
+
+	operatorCount, err := reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading operator count: %w", err)
+	}
+	if operatorCount > 0 {
+		w.AlternateOperators = make([]uint8, operatorCount)
+		w.AlternateRHS = make([]uint16, operatorCount)
+		for i := range operatorCount {
+			w.AlternateOperators[i], err = reader.ReadUint8()
+			if err != nil {
+				return fmt.Errorf("reading alternate operator: %w", err)
+			}
+			w.AlternateRHS[i], err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading alternate rhs: %w", err)
+			}
+		}
+	}
+
+	scriptCount, err := reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading client script count: %w", err)
+	}
+	if scriptCount > 0 {
+		w.ClientScripts = make([][]int32, scriptCount)
+		for i := range scriptCount {
+			length, err := reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading client script length: %w", err)
+			}
+			w.ClientScripts[i] = make([]int32, length)
+			for j := range length {
+				w.ClientScripts[i][j], err = readOptionalUint16(reader)
+				if err != nil {
+					return fmt.Errorf("reading client script: %w", err)
+				}
+			}
+		}
+	}
+
+	switch w.Type {
+	case WidgetTypeLayer:
+		w.ScrollHeight, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading scroll height: %w", err)
+		}
+		w.IsHidden, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading hidden: %w", err)
+		}
+	case 1:
+		if _, err := reader.ReadBytes(3); err != nil {
+			return fmt.Errorf("skipping unused fields: %w", err)
+		}
+	case WidgetTypeInventory:
+		flags := [4]uint32{1 << 28, 1 << 30, 1 << 31, 1 << 29}
+		for _, flag := range flags {
+			set, err := readBool(reader)
+			if err != nil {
+				return fmt.Errorf("reading inventory flag: %w", err)
+			}
+			if set {
+				w.ClickMask |= flag
+			}
+		}
+		xPitch, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading x pitch: %w", err)
+		}
+		yPitch, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading y pitch: %w", err)
+		}
+		w.XPitch, w.YPitch = int16(xPitch), int16(yPitch)
+
+		w.XOffsets = make([]int16, 20)
+		w.YOffsets = make([]int16, 20)
+		w.Sprites = make([]int32, 20)
+		for i := range w.Sprites {
+			hasSprite, err := readBool(reader)
+			if err != nil {
+				return fmt.Errorf("reading inventory sprite flag: %w", err)
+			}
+			if !hasSprite {
+				w.Sprites[i] = -1
+				continue
+			}
+			w.XOffsets[i], err = reader.ReadInt16()
+			if err != nil {
+				return fmt.Errorf("reading inventory sprite x offset: %w", err)
+			}
+			w.YOffsets[i], err = reader.ReadInt16()
+			if err != nil {
+				return fmt.Errorf("reading inventory sprite y offset: %w", err)
+			}
+			w.Sprites[i], err = reader.ReadInt32()
+			if err != nil {
+				return fmt.Errorf("reading inventory sprite: %w", err)
+			}
+		}
+		if err := w.readConfigActions(reader); err != nil {
+			return err
+		}
+	case WidgetTypeRectangle:
+		w.Filled, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading filled: %w", err)
+		}
+	}
+
+	if w.Type == 1 || w.Type == WidgetTypeText {
+		w.XTextAlignment, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading x text alignment: %w", err)
+		}
+		w.YTextAlignment, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading y text alignment: %w", err)
+		}
+		w.LineHeight, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading line height: %w", err)
+		}
+		w.FontID, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading font: %w", err)
+		}
+		w.TextShadowed, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading text shadowed: %w", err)
+		}
+	}
+
+	if w.Type == WidgetTypeText {
+		w.Text, err = reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading text: %w", err)
+		}
+		w.AlternateText, err = reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading alternate text: %w", err)
+		}
+	}
+
+	if w.Type == 1 || w.Type == WidgetTypeRectangle || w.Type == WidgetTypeText {
+		w.TextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading text color: %w", err)
+		}
+	}
+
+	if w.Type == WidgetTypeRectangle || w.Type == WidgetTypeText {
+		w.AlternateTextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading alternate text color: %w", err)
+		}
+		w.HoveredTextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading hovered text color: %w", err)
+		}
+		w.AlternateHoveredTextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading alternate hovered text color: %w", err)
+		}
+	}
+
+	switch w.Type {
+	case WidgetTypeGraphic:
+		w.SpriteID, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading sprite: %w", err)
+		}
+		w.AlternateSpriteID, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading alternate sprite: %w", err)
+		}
+	case WidgetTypeModel:
+		w.ModelType = 1
+		w.ModelID, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading model: %w", err)
+		}
+		w.AlternateModelType = 1
+		w.AlternateModelID, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading alternate model: %w", err)
+		}
+		w.Animation, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading animation: %w", err)
+		}
+		w.AlternateAnimation, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading alternate animation: %w", err)
+		}
+		w.ModelZoom, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading model zoom: %w", err)
+		}
+		w.RotationX, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading rotation x: %w", err)
+		}
+		w.RotationZ, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading rotation z: %w", err)
+		}
+	case WidgetTypeItemList:
+		w.XTextAlignment, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading x text alignment: %w", err)
+		}
+		w.FontID, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading font: %w", err)
+		}
+		w.TextShadowed, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading text shadowed: %w", err)
+		}
+		w.TextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading text color: %w", err)
+		}
+		w.XPitch, err = reader.ReadInt16()
+		if err != nil {
+			return fmt.Errorf("reading x pitch: %w", err)
+		}
+		w.YPitch, err = reader.ReadInt16()
+		if err != nil {
+			return fmt.Errorf("reading y pitch: %w", err)
+		}
+		clickable, err := readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading clickable: %w", err)
+		}
+		if clickable {
+			w.ClickMask |= 1 << 30
+		}
+		if err := w.readConfigActions(reader); err != nil {
+			return err
+		}
+	case WidgetTypeTooltip:
+		w.Text, err = reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading text: %w", err)
+		}
+	}
+
+	if w.MenuType == 2 || w.Type == WidgetTypeInventory {
+		w.TargetVerb, err = reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading target verb: %w", err)
+		}
+		w.SpellName, err = reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading spell name: %w", err)
+		}
+		targetMask, err := reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading target mask: %w", err)
+		}
+		w.ClickMask |= uint32(targetMask&0x3F) << 11
+	}
+
+	switch w.MenuType {
+	case 1, 4, 5, 6:
+		w.Tooltip, err = reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading tooltip: %w", err)
+		}
+		if w.Tooltip == "" {
+			w.Tooltip = map[uint8]string{1: "Ok", 4: "Select", 5: "Select", 6: "Continue"}[w.MenuType]
+		}
+	}
+
+	switch w.MenuType {
+	case 1, 4, 5:
+		w.ClickMask |= 1 << 22
+	case 6:
+		w.ClickMask |= 1
+	}
+	return nil
+}
+
+func (w *Widget) readIf3(reader *Reader) error {
+	var err error
+
+	w.IsIf3 = true
+	w.Type, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading type: %w", err)
+	}
+	w.ContentType, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading content type: %w", err)
+	}
+	if err := w.readBounds(reader); err != nil {
+		return err
+	}
+
+	modes := []*int8{&w.WidthMode, &w.HeightMode, &w.XPositionMode, &w.YPositionMode}
+	for _, mode := range modes {
+		*mode, err = reader.ReadInt8()
+		if err != nil {
+			return fmt.Errorf("reading layout mode: %w", err)
+		}
+	}
+
+	if err := w.readParent(reader); err != nil {
+		return err
+	}
+	w.IsHidden, err = readBool(reader)
+	if err != nil {
+		return fmt.Errorf("reading hidden: %w", err)
+	}
+
+	switch w.Type {
+	case WidgetTypeLayer:
+		w.ScrollWidth, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading scroll width: %w", err)
+		}
+		w.ScrollHeight, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading scroll height: %w", err)
+		}
+		w.NoClickThrough, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading no click through: %w", err)
+		}
+	case WidgetTypeGraphic:
+		w.SpriteID, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading sprite: %w", err)
+		}
+		w.TextureID, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading texture: %w", err)
+		}
+		w.SpriteTiling, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading sprite tiling: %w", err)
+		}
+		w.Opacity, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading opacity: %w", err)
+		}
+		w.BorderType, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading border type: %w", err)
+		}
+		w.ShadowColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading shadow color: %w", err)
+		}
+		w.FlippedVertically, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading flipped vertically: %w", err)
+		}
+		w.FlippedHorizontally, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading flipped horizontally: %w", err)
+		}
+	case WidgetTypeModel:
+		w.ModelType = 1
+		w.ModelID, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading model: %w", err)
+		}
+		w.OffsetX2D, err = reader.ReadInt16()
+		if err != nil {
+			return fmt.Errorf("reading offset x: %w", err)
+		}
+		w.OffsetY2D, err = reader.ReadInt16()
+		if err != nil {
+			return fmt.Errorf("reading offset y: %w", err)
+		}
+		w.RotationX, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading rotation x: %w", err)
+		}
+		w.RotationZ, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading rotation z: %w", err)
+		}
+		w.RotationY, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading rotation y: %w", err)
+		}
+		w.ModelZoom, err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("reading model zoom: %w", err)
+		}
+		w.Animation, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading animation: %w", err)
+		}
+		w.Orthogonal, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading orthogonal: %w", err)
+		}
+		if _, err := reader.ReadUint16(); err != nil {
+			return fmt.Errorf("skipping unused field: %w", err)
+		}
+		if w.WidthMode != 0 {
+			w.ModelHeightOverride, err = reader.ReadUint16()
+			if err != nil {
+				return fmt.Errorf("reading model height override: %w", err)
+			}
+		}
+		if w.HeightMode != 0 {
+			if _, err := reader.ReadUint16(); err != nil {
+				return fmt.Errorf("skipping unused field: %w", err)
+			}
+		}
+	case WidgetTypeText:
+		w.FontID, err = readOptionalUint16(reader)
+		if err != nil {
+			return fmt.Errorf("reading font: %w", err)
+		}
+		w.Text, err = reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading text: %w", err)
+		}
+		w.LineHeight, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading line height: %w", err)
+		}
+		w.XTextAlignment, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading x text alignment: %w", err)
+		}
+		w.YTextAlignment, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading y text alignment: %w", err)
+		}
+		w.TextShadowed, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading text shadowed: %w", err)
+		}
+		w.TextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading text color: %w", err)
+		}
+	case WidgetTypeRectangle:
+		w.TextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading color: %w", err)
+		}
+		w.Filled, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading filled: %w", err)
+		}
+		w.Opacity, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading opacity: %w", err)
+		}
+	case WidgetTypeLine:
+		w.LineWidth, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("reading line width: %w", err)
+		}
+		w.TextColor, err = reader.ReadInt32()
+		if err != nil {
+			return fmt.Errorf("reading color: %w", err)
+		}
+		w.LineDirection, err = readBool(reader)
+		if err != nil {
+			return fmt.Errorf("reading line direction: %w", err)
+		}
+	}
+
+	w.ClickMask, err = reader.ReadUint24()
+	if err != nil {
+		return fmt.Errorf("reading click mask: %w", err)
+	}
+	w.Name, err = reader.ReadString()
+	if err != nil {
+		return fmt.Errorf("reading name: %w", err)
+	}
+
+	actionCount, err := reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading action count: %w", err)
+	}
+	if actionCount > 0 {
+		w.Actions = make([]string, actionCount)
+		for i := range w.Actions {
+			w.Actions[i], err = reader.ReadString()
+			if err != nil {
+				return fmt.Errorf("reading action: %w", err)
+			}
+		}
+	}
+
+	w.DragDeadZone, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading drag dead zone: %w", err)
+	}
+	w.DragDeadTime, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("reading drag dead time: %w", err)
+	}
+	w.DragRenderBehavior, err = readBool(reader)
+	if err != nil {
+		return fmt.Errorf("reading drag render behavior: %w", err)
+	}
+	w.TargetVerb, err = reader.ReadString()
+	if err != nil {
+		return fmt.Errorf("reading target verb: %w", err)
+	}
+
+	listeners := []**ScriptListener{
+		&w.OnLoad, &w.OnMouseOver, &w.OnMouseLeave, &w.OnTargetLeave, &w.OnTargetEnter,
+		&w.OnVarTransmit, &w.OnInvTransmit, &w.OnStatTransmit, &w.OnTimer, &w.OnOp,
+		&w.OnMouseRepeat, &w.OnClick, &w.OnClickRepeat, &w.OnRelease, &w.OnHold,
+		&w.OnDrag, &w.OnDragComplete, &w.OnScrollWheel,
+	}
+	for _, listener := range listeners {
+		*listener, err = readScriptListener(reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	triggers := []*[]int32{&w.VarTransmitTriggers, &w.InvTransmitTriggers, &w.StatTransmitTriggers}
+	for _, trigger := range triggers {
+		*trigger, err = readTriggers(reader)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Widget) readBounds(reader *Reader) error {
+	var err error
+
+	w.X, err = reader.ReadInt16()
+	if err != nil {
+		return fmt.Errorf("reading x: %w", err)
+	}
+	w.Y, err = reader.ReadInt16()
+	if err != nil {
+		return fmt.Errorf("reading y: %w", err)
+	}
+
+	width, err := reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading width: %w", err)
+	}
+	w.Width = int32(width)
+
+	// Lines store a signed height so they can be drawn upwards.
+	height, err := reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading height: %w", err)
+	}
+	if w.IsIf3 && w.Type == WidgetTypeLine {
+		w.Height = int32(int16(height))
+	} else {
+		w.Height = int32(height)
+	}
+	return nil
+}
+
+func (w *Widget) readParent(reader *Reader) error {
+	parent, err := reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("reading parent: %w", err)
+	}
+	if parent != 0xFFFF {
+		w.ParentID = int32(w.ID&^0xFFFF | uint32(parent))
+	}
+	return nil
+}
+
+func (w *Widget) readConfigActions(reader *Reader) error {
+	w.ConfigActions = make([]string, 5)
+	for i := range w.ConfigActions {
+		action, err := reader.ReadString()
+		if err != nil {
+			return fmt.Errorf("reading config action: %w", err)
+		}
+		if action != "" {
+			w.ConfigActions[i] = action
+			w.ClickMask |= 1 << (i + 23)
+		}
+	}
+	return nil
+}
+
+func readScriptListener(reader *Reader) (*ScriptListener, error) {
+	count, err := reader.ReadUint8()
+	if err != nil {
+		return nil, fmt.Errorf("reading listener argument count: %w", err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, count)
+	for i := range args {
+		argType, err := reader.ReadUint8()
+		if err != nil {
+			return nil, fmt.Errorf("reading listener argument type: %w", err)
+		}
+		switch argType {
+		case 0:
+			args[i], err = reader.ReadInt32()
+		case 1:
+			args[i], err = reader.ReadString()
+		default:
+			return nil, fmt.Errorf("unknown listener argument type %d", argType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading listener argument: %w", err)
+		}
+	}
+
+	scriptID, ok := args[0].(int32)
+	if !ok {
+		return nil, fmt.Errorf("listener script id is not an int")
+	}
+	return &ScriptListener{ScriptID: scriptID, Args: args[1:]}, nil
+}
+
+func readTriggers(reader *Reader) ([]int32, error) {
+	count, err := reader.ReadUint8()
+	if err != nil {
+		return nil, fmt.Errorf("reading trigger count: %w", err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	triggers := make([]int32, count)
+	for i := range triggers {
+		triggers[i], err = reader.ReadInt32()
+		if err != nil {
+			return nil, fmt.Errorf("reading trigger: %w", err)
+		}
+	}
+	return triggers, nil
+}
+
+// readOptionalUint16 reads an unsigned short where 0xFFFF means -1.
+func readOptionalUint16(reader *Reader) (int32, error) {
+	value, err := reader.ReadUint16()
+	if err != nil {
+		return 0, err
+	}
+	if value == 0xFFFF {
+		return -1, nil
+	}
+	return int32(value), nil
+}
+
+func readBool(reader *Reader) (bool, error) {
+	value, err := reader.ReadUint8()
+	if err != nil {
+		return false, err
+	}
+	return value == 1, nil
+}